@@ -0,0 +1,67 @@
+package ante
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/x/auth/ante"
+	"github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+// HandlerOptions are the options required for constructing the Gaia
+// AnteHandler.
+type HandlerOptions struct {
+	AccountKeeper   ante.AccountKeeper
+	BankKeeper      authtypes.BankKeeper
+	SignModeHandler signing.SignModeHandler
+	SigGasConsumer  ante.SignatureVerificationGasConsumer
+	GlobalFeeKeeper GlobalFeeKeeper
+
+	// BypassMinFeeMsgTypes lists the Msg type URLs of transactions accepted
+	// without meeting the minimum fee, as long as every message of the
+	// transaction is in the list and the gas limit is within
+	// MaxBypassMinFeeMsgGasUsage.
+	BypassMinFeeMsgTypes       []string
+	MaxBypassMinFeeMsgGasUsage uint64
+}
+
+// NewAnteHandler returns an AnteHandler that checks and increments sequence
+// numbers, checks signatures & account numbers, enforces the global and local
+// minimum fees (subject to the configured bypass rules) and deducts fees from
+// the first signer.
+func NewAnteHandler(options HandlerOptions) (sdk.AnteHandler, error) {
+	if options.AccountKeeper == nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrLogic, "account keeper is required for AnteHandler")
+	}
+	if options.BankKeeper == nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrLogic, "bank keeper is required for AnteHandler")
+	}
+	if options.GlobalFeeKeeper == nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrLogic, "global fee keeper is required for AnteHandler")
+	}
+	if options.SignModeHandler == nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrLogic, "sign mode handler is required for AnteHandler")
+	}
+
+	sigGasConsumer := options.SigGasConsumer
+	if sigGasConsumer == nil {
+		sigGasConsumer = ante.DefaultSigVerificationGasConsumer
+	}
+
+	return sdk.ChainAnteDecorators(
+		ante.NewSetUpContextDecorator(), // outermost AnteDecorator. SetUpContext must be called first
+		ante.NewRejectExtensionOptionsDecorator(),
+		NewBypassMinFeeDecorator(options.GlobalFeeKeeper, options.BypassMinFeeMsgTypes, options.MaxBypassMinFeeMsgGasUsage),
+		ante.NewValidateBasicDecorator(),
+		ante.TxTimeoutHeightDecorator{},
+		ante.NewValidateMemoDecorator(options.AccountKeeper),
+		ante.NewConsumeGasForTxSizeDecorator(options.AccountKeeper),
+		ante.NewRejectFeeGranterDecorator(),
+		ante.NewSetPubKeyDecorator(options.AccountKeeper), // SetPubKeyDecorator must be called before all signature verification decorators
+		ante.NewValidateSigCountDecorator(options.AccountKeeper),
+		ante.NewDeductFeeDecorator(options.AccountKeeper, options.BankKeeper),
+		ante.NewSigGasConsumeDecorator(options.AccountKeeper, sigGasConsumer),
+		ante.NewSigVerificationDecorator(options.AccountKeeper, options.SignModeHandler),
+		ante.NewIncrementSequenceDecorator(options.AccountKeeper),
+	), nil
+}
@@ -0,0 +1,53 @@
+package ante
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/spf13/cast"
+
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	clienttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/02-client/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+)
+
+// app.toml options of the Gaia ante chain. They live in an [ante] section:
+//
+//	[ante]
+//	bypass-min-fee-msg-types = ["/ibc.core.channel.v1.MsgRecvPacket"]
+//	max-bypass-min-fee-msg-gas-usage = 1000000
+//
+// An empty bypass-min-fee-msg-types list disables the relayer fee bypass.
+const (
+	FlagBypassMinFeeMsgTypes       = "ante.bypass-min-fee-msg-types"
+	FlagMaxBypassMinFeeMsgGasUsage = "ante.max-bypass-min-fee-msg-gas-usage"
+)
+
+// DefaultMaxBypassMinFeeMsgGasUsage is the default gas cap of transactions
+// accepted without fees.
+const DefaultMaxBypassMinFeeMsgGasUsage uint64 = 1_000_000
+
+// DefaultBypassMinFeeMsgTypes returns the Msg type URLs of the pure IBC
+// relayer transactions accepted without fees by default.
+func DefaultBypassMinFeeMsgTypes() []string {
+	return []string{
+		"/" + proto.MessageName(&clienttypes.MsgUpdateClient{}),
+		"/" + proto.MessageName(&channeltypes.MsgRecvPacket{}),
+		"/" + proto.MessageName(&channeltypes.MsgAcknowledgement{}),
+		"/" + proto.MessageName(&channeltypes.MsgTimeout{}),
+	}
+}
+
+// BypassOptionsFromAppOpts reads the fee bypass options from app.toml, falling
+// back to the defaults for options that are not set.
+func BypassOptionsFromAppOpts(appOpts servertypes.AppOptions) ([]string, uint64) {
+	msgTypes := DefaultBypassMinFeeMsgTypes()
+	if opt := appOpts.Get(FlagBypassMinFeeMsgTypes); opt != nil {
+		msgTypes = cast.ToStringSlice(opt)
+	}
+
+	maxGas := DefaultMaxBypassMinFeeMsgGasUsage
+	if opt := appOpts.Get(FlagMaxBypassMinFeeMsgGasUsage); opt != nil {
+		maxGas = cast.ToUint64(opt)
+	}
+
+	return msgTypes, maxGas
+}
@@ -0,0 +1,147 @@
+package ante
+
+import (
+	"github.com/gogo/protobuf/proto"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// GlobalFeeKeeper defines the expected keeper providing the chain-wide
+// minimum gas prices set by governance.
+type GlobalFeeKeeper interface {
+	GetMinimumGasPrices(ctx sdk.Context) sdk.DecCoins
+}
+
+// BypassMinFeeDecorator will check if the transaction's fee is at least as
+// large as the minimum fee, unless every message of the transaction is of a
+// bypass type and the gas limit does not exceed the bypass gas cap. This lets
+// relayers submit IBC packets and client updates without paying fees.
+//
+// The chain-wide minimum gas prices of the global fee module are enforced in
+// both CheckTx and DeliverTx. In CheckTx, the local validator's minimum gas
+// prices (defined in validator config) may further raise the floor of the
+// denominations accepted by the global fee, or set the floor on their own if
+// no global fee is set.
+// CONTRACT: Tx must implement FeeTx to use BypassMinFeeDecorator
+type BypassMinFeeDecorator struct {
+	globalFeeKeeper GlobalFeeKeeper
+	bypassMsgTypes  map[string]bool
+	maxBypassGas    uint64
+}
+
+func NewBypassMinFeeDecorator(globalFeeKeeper GlobalFeeKeeper, bypassMsgTypes []string, maxBypassGas uint64) BypassMinFeeDecorator {
+	msgTypes := make(map[string]bool, len(bypassMsgTypes))
+	for _, msgType := range bypassMsgTypes {
+		msgTypes[msgType] = true
+	}
+
+	return BypassMinFeeDecorator{
+		globalFeeKeeper: globalFeeKeeper,
+		bypassMsgTypes:  msgTypes,
+		maxBypassGas:    maxBypassGas,
+	}
+}
+
+func (mfd BypassMinFeeDecorator) AnteHandle(ctx sdk.Context, tx sdk.Tx, simulate bool, next sdk.AnteHandler) (newCtx sdk.Context, err error) {
+	feeTx, ok := tx.(sdk.FeeTx)
+	if !ok {
+		return ctx, sdkerrors.Wrap(sdkerrors.ErrTxDecode, "Tx must be a FeeTx")
+	}
+
+	// genesis transactions are not subject to fees
+	if simulate || ctx.BlockHeight() == 0 {
+		return next(ctx, tx, simulate)
+	}
+
+	feeCoins := feeTx.GetFee()
+	gas := feeTx.GetGas()
+
+	if !mfd.IsBypassTx(tx.GetMsgs(), gas) {
+		requiredFees := GetMinFee(mfd.minGasPrices(ctx), gas)
+		if !requiredFees.IsZero() && !feeCoins.IsAnyGTE(requiredFees) {
+			return ctx, sdkerrors.Wrapf(sdkerrors.ErrInsufficientFee, "insufficient fees; got: %s required: %s", feeCoins, requiredFees)
+		}
+	}
+
+	return next(ctx, tx, simulate)
+}
+
+// minGasPrices returns the minimum gas prices to enforce. Outside of CheckTx
+// only the global minimum gas prices apply, as the local ones differ between
+// validators.
+func (mfd BypassMinFeeDecorator) minGasPrices(ctx sdk.Context) sdk.DecCoins {
+	var globalMinGasPrices sdk.DecCoins
+	if mfd.globalFeeKeeper != nil {
+		globalMinGasPrices = mfd.globalFeeKeeper.GetMinimumGasPrices(ctx)
+	}
+
+	if !ctx.IsCheckTx() {
+		return globalMinGasPrices
+	}
+
+	return CombinedMinGasPrices(globalMinGasPrices, ctx.MinGasPrices())
+}
+
+// IsBypassTx returns true if all the messages are of a bypass type and the
+// gas limit is within the bypass gas cap.
+func (mfd BypassMinFeeDecorator) IsBypassTx(msgs []sdk.Msg, gas uint64) bool {
+	if len(msgs) == 0 || len(mfd.bypassMsgTypes) == 0 || gas > mfd.maxBypassGas {
+		return false
+	}
+
+	for _, msg := range msgs {
+		if !mfd.bypassMsgTypes[MsgTypeURL(msg)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CombinedMinGasPrices returns the global minimum gas prices, each raised to the
+// local minimum gas price of the same denomination if that one is higher.
+// Local prices of denominations not accepted by the global fee are ignored. If
+// no global minimum gas price is set, the local ones are returned.
+func CombinedMinGasPrices(globalMinGasPrices, localMinGasPrices sdk.DecCoins) sdk.DecCoins {
+	if globalMinGasPrices.IsZero() {
+		return localMinGasPrices
+	}
+
+	combined := make(sdk.DecCoins, len(globalMinGasPrices))
+	for i, gp := range globalMinGasPrices {
+		combined[i] = gp
+		if local := localMinGasPrices.AmountOf(gp.Denom); local.GT(gp.Amount) {
+			combined[i] = sdk.NewDecCoinFromDec(gp.Denom, local)
+		}
+	}
+
+	return combined
+}
+
+// GetMinFee returns the fee required for the given gas limit, where
+// fee = ceil(minGasPrice * gasLimit) for each minimum gas price.
+func GetMinFee(minGasPrices sdk.DecCoins, gas uint64) sdk.Coins {
+	if minGasPrices.IsZero() {
+		return sdk.Coins{}
+	}
+
+	requiredFees := make(sdk.Coins, len(minGasPrices))
+	glDec := sdk.NewDec(int64(gas))
+	for i, gp := range minGasPrices {
+		fee := gp.Amount.Mul(glDec)
+		requiredFees[i] = sdk.NewCoin(gp.Denom, fee.Ceil().RoundInt())
+	}
+
+	return requiredFees
+}
+
+// MsgTypeURL returns the type URL of a Msg, looking through service Msgs to
+// the request they wrap.
+func MsgTypeURL(msg sdk.Msg) string {
+	if svcMsg, ok := msg.(sdk.ServiceMsg); ok {
+		return "/" + proto.MessageName(svcMsg.Request)
+	}
+
+	return "/" + proto.MessageName(msg)
+}
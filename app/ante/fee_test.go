@@ -0,0 +1,148 @@
+package ante_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	clienttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/02-client/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+
+	"github.com/cosmos/gaia/v4/app/ante"
+)
+
+// feeTx is a minimal sdk.FeeTx used to drive the fee decorators.
+type feeTx struct {
+	msgs []sdk.Msg
+	gas  uint64
+	fee  sdk.Coins
+}
+
+func (tx feeTx) GetMsgs() []sdk.Msg         { return tx.msgs }
+func (tx feeTx) ValidateBasic() error       { return nil }
+func (tx feeTx) GetGas() uint64             { return tx.gas }
+func (tx feeTx) GetFee() sdk.Coins          { return tx.fee }
+func (tx feeTx) FeePayer() sdk.AccAddress   { return nil }
+func (tx feeTx) FeeGranter() sdk.AccAddress { return nil }
+
+type mockGlobalFeeKeeper struct {
+	minGasPrices sdk.DecCoins
+}
+
+func (k mockGlobalFeeKeeper) GetMinimumGasPrices(sdk.Context) sdk.DecCoins {
+	return k.minGasPrices
+}
+
+func nextAnteHandler(ctx sdk.Context, _ sdk.Tx, _ bool) (sdk.Context, error) {
+	return ctx, nil
+}
+
+func TestBypassMinFeeDecorator(t *testing.T) {
+	minGasPrices := sdk.NewDecCoins(sdk.NewDecCoinFromDec("uatom", sdk.NewDecWithPrec(25, 4)))
+	ctx := sdk.NewContext(nil, tmproto.Header{Height: 1}, true, log.NewNopLogger()).WithMinGasPrices(minGasPrices)
+	mfd := ante.NewBypassMinFeeDecorator(nil, ante.DefaultBypassMinFeeMsgTypes(), 200000)
+
+	recvPacket := &channeltypes.MsgRecvPacket{}
+	updateClient := &clienttypes.MsgUpdateClient{}
+	send := &banktypes.MsgSend{}
+
+	testCases := []struct {
+		name   string
+		tx     feeTx
+		expErr bool
+	}{
+		{"enough fees", feeTx{[]sdk.Msg{send}, 100000, sdk.NewCoins(sdk.NewInt64Coin("uatom", 250))}, false},
+		{"insufficient fees", feeTx{[]sdk.Msg{send}, 100000, sdk.NewCoins(sdk.NewInt64Coin("uatom", 249))}, true},
+		{"relayer msgs without fees", feeTx{[]sdk.Msg{updateClient, recvPacket}, 200000, nil}, false},
+		{"relayer service msg without fees", feeTx{[]sdk.Msg{sdk.ServiceMsg{
+			MethodName: "/ibc.core.channel.v1.Msg/RecvPacket",
+			Request:    recvPacket,
+		}}, 100000, nil}, false},
+		{"relayer msgs above gas cap", feeTx{[]sdk.Msg{recvPacket}, 200001, nil}, true},
+		{"relayer msg mixed with send", feeTx{[]sdk.Msg{recvPacket, send}, 100000, nil}, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := mfd.AnteHandle(ctx, tc.tx, false, nextAnteHandler)
+			if tc.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// fees are only checked in CheckTx
+			_, err = mfd.AnteHandle(ctx.WithIsCheckTx(false), tc.tx, false, nextAnteHandler)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGlobalMinFee(t *testing.T) {
+	globalFee := mockGlobalFeeKeeper{sdk.NewDecCoins(
+		sdk.NewDecCoinFromDec("stake", sdk.NewDecWithPrec(1, 2)),
+		sdk.NewDecCoinFromDec("uatom", sdk.NewDecWithPrec(1, 3)),
+	)}
+	localMinGasPrices := sdk.NewDecCoins(
+		sdk.NewDecCoinFromDec("photon", sdk.NewDecWithPrec(1, 1)),
+		sdk.NewDecCoinFromDec("uatom", sdk.NewDecWithPrec(25, 4)),
+	)
+	ctx := sdk.NewContext(nil, tmproto.Header{Height: 1}, false, log.NewNopLogger()).WithMinGasPrices(localMinGasPrices)
+	mfd := ante.NewBypassMinFeeDecorator(globalFee, ante.DefaultBypassMinFeeMsgTypes(), 200000)
+	send := &banktypes.MsgSend{}
+
+	testCases := []struct {
+		name        string
+		fee         sdk.Coins
+		expCheckErr bool
+		expDelivErr bool
+	}{
+		{"no fee", nil, true, true},
+		{"global fee in stake", sdk.NewCoins(sdk.NewInt64Coin("stake", 1000)), false, false},
+		{"global fee in uatom below local price", sdk.NewCoins(sdk.NewInt64Coin("uatom", 100)), true, false},
+		{"local price in uatom", sdk.NewCoins(sdk.NewInt64Coin("uatom", 250)), false, false},
+		{"denom not accepted by global fee", sdk.NewCoins(sdk.NewInt64Coin("photon", 100000)), true, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tx := feeTx{[]sdk.Msg{send}, 100000, tc.fee}
+
+			_, err := mfd.AnteHandle(ctx.WithIsCheckTx(true), tx, false, nextAnteHandler)
+			require.Equal(t, tc.expCheckErr, err != nil, "CheckTx: %v", err)
+
+			_, err = mfd.AnteHandle(ctx, tx, false, nextAnteHandler)
+			require.Equal(t, tc.expDelivErr, err != nil, "DeliverTx: %v", err)
+		})
+	}
+
+	// relayer transactions bypass the global fee as well
+	tx := feeTx{[]sdk.Msg{&channeltypes.MsgRecvPacket{}}, 100000, nil}
+	_, err := mfd.AnteHandle(ctx, tx, false, nextAnteHandler)
+	require.NoError(t, err)
+
+	// genesis transactions are not subject to the global fee
+	_, err = mfd.AnteHandle(ctx.WithBlockHeight(0), feeTx{[]sdk.Msg{send}, 100000, nil}, false, nextAnteHandler)
+	require.NoError(t, err)
+}
+
+func TestBypassMinFeeDecoratorDisabled(t *testing.T) {
+	mfd := ante.NewBypassMinFeeDecorator(nil, nil, 200000)
+	require.False(t, mfd.IsBypassTx([]sdk.Msg{&channeltypes.MsgRecvPacket{}}, 1))
+}
+
+func TestGetMinFee(t *testing.T) {
+	minGasPrices := sdk.NewDecCoins(
+		sdk.NewDecCoinFromDec("stake", sdk.NewDecWithPrec(1, 1)),
+		sdk.NewDecCoinFromDec("uatom", sdk.NewDecWithPrec(25, 4)),
+	)
+
+	require.Equal(t, "10stake,1uatom", ante.GetMinFee(minGasPrices, 100).String())
+	require.True(t, ante.GetMinFee(sdk.DecCoins{}, 100).IsZero())
+}
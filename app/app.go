@@ -1,6 +1,7 @@
 package gaia
 
 import (
+	"fmt"
 	"io"
 	stdlog "log"
 	"net/http"
@@ -31,7 +32,6 @@ import (
 	"github.com/cosmos/cosmos-sdk/types/module"
 	"github.com/cosmos/cosmos-sdk/version"
 	"github.com/cosmos/cosmos-sdk/x/auth"
-	"github.com/cosmos/cosmos-sdk/x/auth/ante"
 	authrest "github.com/cosmos/cosmos-sdk/x/auth/client/rest"
 	authkeeper "github.com/cosmos/cosmos-sdk/x/auth/keeper"
 	authsims "github.com/cosmos/cosmos-sdk/x/auth/simulation"
@@ -85,7 +85,17 @@ import (
 	upgradeclient "github.com/cosmos/cosmos-sdk/x/upgrade/client"
 	upgradekeeper "github.com/cosmos/cosmos-sdk/x/upgrade/keeper"
 	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+	gaiaante "github.com/cosmos/gaia/v4/app/ante"
+	"github.com/cosmos/gaia/v4/app/ibcsim"
 	gaiaappparams "github.com/cosmos/gaia/v4/app/params"
+	"github.com/cosmos/gaia/v4/app/upgrades"
+	v5 "github.com/cosmos/gaia/v4/app/upgrades/v5"
+	"github.com/cosmos/gaia/v4/x/globalfee"
+	globalfeekeeper "github.com/cosmos/gaia/v4/x/globalfee/keeper"
+	globalfeetypes "github.com/cosmos/gaia/v4/x/globalfee/types"
+	"github.com/cosmos/gaia/v4/x/ratelimit"
+	ratelimitkeeper "github.com/cosmos/gaia/v4/x/ratelimit/keeper"
+	ratelimittypes "github.com/cosmos/gaia/v4/x/ratelimit/types"
 
 	// unnamed import of statik for swagger UI support
 	_ "github.com/cosmos/cosmos-sdk/client/docs/statik"
@@ -119,6 +129,8 @@ var (
 		evidence.AppModuleBasic{},
 		transfer.AppModuleBasic{},
 		vesting.AppModuleBasic{},
+		globalfee.AppModuleBasic{},
+		ratelimit.AppModuleBasic{},
 	)
 
 	// module account permissions
@@ -131,6 +143,11 @@ var (
 		govtypes.ModuleName:            {authtypes.Burner},
 		ibctransfertypes.ModuleName:    {authtypes.Minter, authtypes.Burner},
 	}
+
+	// Upgrades lists every chain upgrade known to this binary. Each upgrade
+	// registers its handler and, when the node restarts at the upgrade height,
+	// its store upgrades.
+	Upgrades = []upgrades.Upgrade{v5.Upgrade}
 )
 
 var (
@@ -148,6 +165,10 @@ type GaiaApp struct { // nolint: golint
 	interfaceRegistry types.InterfaceRegistry
 
 	invCheckPeriod uint
+	homePath       string
+	// genesisFile is the genesis document of the node, next to which the
+	// genesis shards are read
+	genesisFile string
 
 	// keys to access the substores
 	keys    map[string]*sdk.KVStoreKey
@@ -169,6 +190,8 @@ type GaiaApp struct { // nolint: golint
 	IBCKeeper        *ibckeeper.Keeper // IBC Keeper must be a pointer in the app, so we can SetRouter on it correctly
 	EvidenceKeeper   evidencekeeper.Keeper
 	TransferKeeper   ibctransferkeeper.Keeper
+	GlobalFeeKeeper  globalfeekeeper.Keeper
+	RateLimitKeeper  ratelimitkeeper.Keeper
 
 	// make scoped keepers public for test purposes
 	ScopedIBCKeeper      capabilitykeeper.ScopedKeeper
@@ -210,6 +233,7 @@ func NewGaiaApp(
 		minttypes.StoreKey, distrtypes.StoreKey, slashingtypes.StoreKey,
 		govtypes.StoreKey, paramstypes.StoreKey, ibchost.StoreKey, upgradetypes.StoreKey,
 		evidencetypes.StoreKey, ibctransfertypes.StoreKey, capabilitytypes.StoreKey,
+		ratelimittypes.StoreKey,
 	)
 	tkeys := sdk.NewTransientStoreKeys(paramstypes.TStoreKey)
 	memKeys := sdk.NewMemoryStoreKeys(capabilitytypes.MemStoreKey)
@@ -220,6 +244,8 @@ func NewGaiaApp(
 		appCodec:          appCodec,
 		interfaceRegistry: interfaceRegistry,
 		invCheckPeriod:    invCheckPeriod,
+		homePath:          homePath,
+		genesisFile:       NodeGenesisFile(homePath, appOpts),
 		keys:              keys,
 		tkeys:             tkeys,
 		memKeys:           memKeys,
@@ -259,6 +285,7 @@ func NewGaiaApp(
 		app.GetSubspace(crisistypes.ModuleName), invCheckPeriod, app.BankKeeper, authtypes.FeeCollectorName,
 	)
 	app.UpgradeKeeper = upgradekeeper.NewKeeper(skipUpgradeHeights, keys[upgradetypes.StoreKey], appCodec, homePath)
+	app.GlobalFeeKeeper = globalfeekeeper.NewKeeper(app.GetSubspace(globalfeetypes.ModuleName))
 
 	// register the staking hooks
 	// NOTE: stakingKeeper above is passed by reference, so that it will contain these hooks
@@ -283,17 +310,25 @@ func NewGaiaApp(
 		&stakingKeeper, govRouter,
 	)
 
+	// Create the rate limit keeper, which sits between the transfer keeper and
+	// the IBC channel keeper to check outgoing transfers against their quota
+	app.RateLimitKeeper = ratelimitkeeper.NewKeeper(
+		keys[ratelimittypes.StoreKey], app.GetSubspace(ratelimittypes.ModuleName),
+		app.BankKeeper, app.IBCKeeper.ChannelKeeper,
+	)
+
 	// Create Transfer Keepers
 	app.TransferKeeper = ibctransferkeeper.NewKeeper(
 		appCodec, keys[ibctransfertypes.StoreKey], app.GetSubspace(ibctransfertypes.ModuleName),
-		app.IBCKeeper.ChannelKeeper, &app.IBCKeeper.PortKeeper,
+		app.RateLimitKeeper, &app.IBCKeeper.PortKeeper,
 		app.AccountKeeper, app.BankKeeper, scopedTransferKeeper,
 	)
 	transferModule := transfer.NewAppModule(app.TransferKeeper)
+	transferIBCModule := ratelimit.NewIBCMiddleware(transferModule, app.RateLimitKeeper)
 
-	// Create static IBC router, add transfer route, then set and seal it
+	// Create static IBC router, add rate limited transfer route, then set and seal it
 	ibcRouter := porttypes.NewRouter()
-	ibcRouter.AddRoute(ibctransfertypes.ModuleName, transferModule)
+	ibcRouter.AddRoute(ibctransfertypes.ModuleName, transferIBCModule)
 	app.IBCKeeper.SetRouter(ibcRouter)
 
 	// create evidence keeper with router
@@ -336,6 +371,8 @@ func NewGaiaApp(
 		ibc.NewAppModule(app.IBCKeeper),
 		params.NewAppModule(app.ParamsKeeper),
 		transferModule,
+		globalfee.NewAppModule(app.GlobalFeeKeeper),
+		ratelimit.NewAppModule(app.RateLimitKeeper),
 	)
 
 	// During begin block slashing happens after distr.BeginBlocker so that
@@ -357,6 +394,7 @@ func NewGaiaApp(
 		capabilitytypes.ModuleName, authtypes.ModuleName, banktypes.ModuleName, distrtypes.ModuleName, stakingtypes.ModuleName,
 		slashingtypes.ModuleName, govtypes.ModuleName, minttypes.ModuleName, crisistypes.ModuleName,
 		ibchost.ModuleName, genutiltypes.ModuleName, evidencetypes.ModuleName, ibctransfertypes.ModuleName,
+		globalfeetypes.ModuleName, ratelimittypes.ModuleName,
 	)
 
 	app.mm.RegisterInvariants(&app.CrisisKeeper)
@@ -379,7 +417,9 @@ func NewGaiaApp(
 		params.NewAppModule(app.ParamsKeeper),
 		evidence.NewAppModule(app.EvidenceKeeper),
 		ibc.NewAppModule(app.IBCKeeper),
-		transferModule,
+		ibcsim.NewTransferModule(
+			transferModule, appCodec, app.AccountKeeper, app.BankKeeper, app.TransferKeeper, app.IBCKeeper,
+		),
 	)
 
 	app.sm.RegisterStoreDecoders()
@@ -392,14 +432,26 @@ func NewGaiaApp(
 	// initialize BaseApp
 	app.SetInitChainer(app.InitChainer)
 	app.SetBeginBlocker(app.BeginBlocker)
-	app.SetAnteHandler(
-		ante.NewAnteHandler(
-			app.AccountKeeper, app.BankKeeper, ante.DefaultSigVerificationGasConsumer,
-			encodingConfig.TxConfig.SignModeHandler(),
-		),
+	bypassMinFeeMsgTypes, maxBypassMinFeeMsgGasUsage := gaiaante.BypassOptionsFromAppOpts(appOpts)
+	anteHandler, err := gaiaante.NewAnteHandler(
+		gaiaante.HandlerOptions{
+			AccountKeeper:              app.AccountKeeper,
+			BankKeeper:                 app.BankKeeper,
+			SignModeHandler:            encodingConfig.TxConfig.SignModeHandler(),
+			GlobalFeeKeeper:            app.GlobalFeeKeeper,
+			BypassMinFeeMsgTypes:       bypassMinFeeMsgTypes,
+			MaxBypassMinFeeMsgGasUsage: maxBypassMinFeeMsgGasUsage,
+		},
 	)
+	if err != nil {
+		panic(err)
+	}
+	app.SetAnteHandler(anteHandler)
 	app.SetEndBlocker(app.EndBlocker)
 
+	app.setupUpgradeHandlers()
+	app.setupUpgradeStoreLoaders()
+
 	if loadLatest {
 		if err := app.LoadLatestVersion(); err != nil {
 			tmos.Exit(err.Error())
@@ -445,9 +497,19 @@ func (app *GaiaApp) EndBlocker(ctx sdk.Context, req abci.RequestEndBlock) abci.R
 // InitChainer application update at chain initialization
 func (app *GaiaApp) InitChainer(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
 	var genesisState GenesisState
-	if err := tmjson.Unmarshal(req.AppStateBytes, &genesisState); err != nil {
+
+	// a sharded genesis document has no app state, which is read from the
+	// shards directory next to the genesis document of the node
+	shardsDir := GenesisShardsDir(app.genesisFile)
+	if len(req.AppStateBytes) == 0 && app.homePath != "" && HasGenesisShards(shardsDir) {
+		var err error
+		if genesisState, err = ReadGenesisShards(shardsDir); err != nil {
+			panic(err)
+		}
+	} else if err := tmjson.Unmarshal(req.AppStateBytes, &genesisState); err != nil {
 		panic(err)
 	}
+
 	return app.mm.InitGenesis(ctx, app.appCodec, genesisState)
 }
 
@@ -573,6 +635,64 @@ func GetMaccPerms() map[string][]string {
 	return dupMaccPerms
 }
 
+// setupUpgradeHandlers registers the handler of every known upgrade with the
+// upgrade keeper.
+func (app *GaiaApp) setupUpgradeHandlers() {
+	if err := upgrades.ValidateUpgrades(Upgrades); err != nil {
+		panic(err)
+	}
+
+	keepers := &upgrades.AppKeepers{
+		AccountKeeper:    app.AccountKeeper,
+		BankKeeper:       app.BankKeeper,
+		CapabilityKeeper: app.CapabilityKeeper,
+		StakingKeeper:    app.StakingKeeper,
+		SlashingKeeper:   app.SlashingKeeper,
+		MintKeeper:       app.MintKeeper,
+		DistrKeeper:      app.DistrKeeper,
+		GovKeeper:        app.GovKeeper,
+		CrisisKeeper:     app.CrisisKeeper,
+		UpgradeKeeper:    app.UpgradeKeeper,
+		ParamsKeeper:     app.ParamsKeeper,
+		IBCKeeper:        app.IBCKeeper,
+		EvidenceKeeper:   app.EvidenceKeeper,
+		TransferKeeper:   app.TransferKeeper,
+		GlobalFeeKeeper:  app.GlobalFeeKeeper,
+		RateLimitKeeper:  app.RateLimitKeeper,
+	}
+
+	for _, upgrade := range Upgrades {
+		app.UpgradeKeeper.SetUpgradeHandler(upgrade.UpgradeName, upgrade.Handler(app.mm, keepers))
+	}
+}
+
+// setupUpgradeStoreLoaders reads the upgrade-info.json written by the previous
+// binary when it halted and, if it names one of the known upgrades, installs a
+// store loader applying that upgrade's store changes at the upgrade height.
+func (app *GaiaApp) setupUpgradeStoreLoaders() {
+	// no home directory (e.g. export); there is no upgrade info to read
+	if app.homePath == "" {
+		return
+	}
+
+	upgradeInfo, err := app.UpgradeKeeper.ReadUpgradeInfoFromDisk()
+	if err != nil {
+		panic(fmt.Sprintf("failed to read upgrade info from disk: %s", err))
+	}
+
+	if upgradeInfo.Name == "" || app.UpgradeKeeper.IsSkipHeight(upgradeInfo.Height) {
+		return
+	}
+
+	upgrade, found := upgrades.FindUpgrade(Upgrades, upgradeInfo.Name)
+	if !found {
+		return
+	}
+
+	storeUpgrades := upgrade.StoreUpgrades
+	app.SetStoreLoader(upgradetypes.UpgradeStoreLoader(upgradeInfo.Height, &storeUpgrades))
+}
+
 // initParamsKeeper init params keeper and its subspaces
 func initParamsKeeper(appCodec codec.BinaryMarshaler, legacyAmino *codec.LegacyAmino, key, tkey sdk.StoreKey) paramskeeper.Keeper {
 	paramsKeeper := paramskeeper.NewKeeper(appCodec, legacyAmino, key, tkey)
@@ -587,6 +707,8 @@ func initParamsKeeper(appCodec codec.BinaryMarshaler, legacyAmino *codec.LegacyA
 	paramsKeeper.Subspace(crisistypes.ModuleName)
 	paramsKeeper.Subspace(ibctransfertypes.ModuleName)
 	paramsKeeper.Subspace(ibchost.ModuleName)
+	paramsKeeper.Subspace(globalfeetypes.ModuleName).WithKeyTable(globalfeetypes.ParamKeyTable())
+	paramsKeeper.Subspace(ratelimittypes.ModuleName).WithKeyTable(ratelimittypes.ParamKeyTable())
 
 	return paramsKeeper
 }
@@ -0,0 +1,187 @@
+package gaia
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingtypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// Account types of an AccountBalance.
+const (
+	AccountTypeBase              = "base"
+	AccountTypeModule            = "module"
+	AccountTypeContinuousVesting = "continuous_vesting"
+	AccountTypeDelayedVesting    = "delayed_vesting"
+	AccountTypePeriodicVesting   = "periodic_vesting"
+	AccountTypeOther             = "other"
+)
+
+// AccountBalance is the breakdown of the balance of an address.
+type AccountBalance struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	// Module is the name of a module account.
+	Module string `json:"module,omitempty"`
+
+	// Liquid is the spendable bank balance.
+	Liquid sdk.Coins `json:"liquid"`
+	// VestingLocked is the bank balance locked by vesting.
+	VestingLocked sdk.Coins `json:"vesting_locked"`
+	// Staked is the amount of tokens delegated.
+	Staked sdk.Coins `json:"staked"`
+	// Unbonding is the amount of tokens being unbonded.
+	Unbonding sdk.Coins `json:"unbonding"`
+	// Rewards are the unclaimed delegation rewards, truncated.
+	Rewards sdk.Coins `json:"rewards"`
+	// Commission is the unclaimed commission of a validator operator,
+	// truncated.
+	Commission sdk.Coins `json:"commission"`
+
+	Total sdk.Coins `json:"total"`
+}
+
+// ExportBalances returns the balance breakdown of every address at the loaded
+// height, sorted by address. blockTime is the time of the block of the height,
+// which determines the vesting locked amounts.
+func (app *GaiaApp) ExportBalances(blockTime time.Time) ([]AccountBalance, error) {
+	ctx := app.NewContext(true, tmproto.Header{Height: app.LastBlockHeight(), Time: blockTime})
+	bondDenom := app.StakingKeeper.BondDenom(ctx)
+
+	balances := make(map[string]*AccountBalance)
+	get := func(addr string) *AccountBalance {
+		b, ok := balances[addr]
+		if !ok {
+			b = &AccountBalance{
+				Address:       addr,
+				Type:          AccountTypeBase,
+				Liquid:        sdk.Coins{},
+				VestingLocked: sdk.Coins{},
+				Staked:        sdk.Coins{},
+				Unbonding:     sdk.Coins{},
+				Rewards:       sdk.Coins{},
+				Commission:    sdk.Coins{},
+			}
+			balances[addr] = b
+		}
+		return b
+	}
+
+	app.AccountKeeper.IterateAccounts(ctx, func(acc authtypes.AccountI) (stop bool) {
+		b := get(acc.GetAddress().String())
+		b.Type, b.Module = accountType(acc)
+
+		all := app.BankKeeper.GetAllBalances(ctx, acc.GetAddress())
+		b.Liquid = app.BankKeeper.SpendableCoins(ctx, acc.GetAddress())
+		b.VestingLocked = all.Sub(b.Liquid)
+		return false
+	})
+
+	var err error
+	app.StakingKeeper.IterateAllDelegations(ctx, func(del stakingtypes.Delegation) (stop bool) {
+		valAddr, valErr := sdk.ValAddressFromBech32(del.ValidatorAddress)
+		if valErr != nil {
+			err = valErr
+			return true
+		}
+
+		val, found := app.StakingKeeper.GetValidator(ctx, valAddr)
+		if !found {
+			err = fmt.Errorf("validator %s of delegator %s not found", del.ValidatorAddress, del.DelegatorAddress)
+			return true
+		}
+
+		b := get(del.DelegatorAddress)
+		b.Staked = b.Staked.Add(sdk.NewCoin(bondDenom, val.TokensFromShares(del.Shares).TruncateInt()))
+
+		// as computed by the rewards query, without changing the state
+		cacheCtx, _ := ctx.CacheContext()
+		endingPeriod := app.DistrKeeper.IncrementValidatorPeriod(cacheCtx, val)
+		rewards, _ := app.DistrKeeper.CalculateDelegationRewards(cacheCtx, val, del, endingPeriod).TruncateDecimal()
+		b.Rewards = b.Rewards.Add(rewards...)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	app.StakingKeeper.IterateUnbondingDelegations(ctx, func(_ int64, ubd stakingtypes.UnbondingDelegation) (stop bool) {
+		b := get(ubd.DelegatorAddress)
+		for _, entry := range ubd.Entries {
+			b.Unbonding = b.Unbonding.Add(sdk.NewCoin(bondDenom, entry.Balance))
+		}
+		return false
+	})
+
+	app.StakingKeeper.IterateValidators(ctx, func(_ int64, val stakingtypes.ValidatorI) (stop bool) {
+		commission, _ := app.DistrKeeper.GetValidatorAccumulatedCommission(ctx, val.GetOperator()).Commission.TruncateDecimal()
+		if !commission.IsZero() {
+			b := get(sdk.AccAddress(val.GetOperator()).String())
+			b.Commission = b.Commission.Add(commission...)
+		}
+		return false
+	})
+
+	addrs := make([]string, 0, len(balances))
+	for addr := range balances {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	result := make([]AccountBalance, len(addrs))
+	for i, addr := range addrs {
+		b := balances[addr]
+		b.Total = b.Liquid.Add(b.VestingLocked...).Add(b.Staked...).Add(b.Unbonding...).Add(b.Rewards...).Add(b.Commission...)
+		result[i] = *b
+	}
+
+	return result, nil
+}
+
+// accountType returns the type of an account and, for module accounts, the
+// module name.
+func accountType(acc authtypes.AccountI) (string, string) {
+	switch acc := acc.(type) {
+	case authtypes.ModuleAccountI:
+		return AccountTypeModule, acc.GetName()
+	case *vestingtypes.ContinuousVestingAccount:
+		return AccountTypeContinuousVesting, ""
+	case *vestingtypes.DelayedVestingAccount:
+		return AccountTypeDelayedVesting, ""
+	case *vestingtypes.PeriodicVestingAccount:
+		return AccountTypePeriodicVesting, ""
+	case *authtypes.BaseAccount:
+		return AccountTypeBase, ""
+	default:
+		return AccountTypeOther, ""
+	}
+}
+
+// WriteBalancesCSV writes the balances as CSV, with a header row.
+func WriteBalancesCSV(w io.Writer, balances []AccountBalance) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"address", "type", "module", "liquid", "vesting_locked", "staked", "unbonding", "rewards", "commission", "total"}); err != nil {
+		return err
+	}
+
+	for _, b := range balances {
+		if err := cw.Write([]string{
+			b.Address, b.Type, b.Module,
+			b.Liquid.String(), b.VestingLocked.String(), b.Staked.String(), b.Unbonding.String(),
+			b.Rewards.String(), b.Commission.String(), b.Total.String(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
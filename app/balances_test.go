@@ -0,0 +1,99 @@
+package gaia_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	authvesting "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestExportBalances(t *testing.T) {
+	f := setupStakingFixture(t)
+	app := f.app
+	ctx := app.NewContext(true, tmproto.Header{Height: app.LastBlockHeight()})
+	blockTime := time.Unix(1000, 0)
+
+	base := sdk.AccAddress([]byte("base________________"))
+	vesting := sdk.AccAddress([]byte("vesting_____________"))
+
+	app.AccountKeeper.SetAccount(ctx, app.AccountKeeper.NewAccountWithAddress(ctx, base))
+	require.NoError(t, app.BankKeeper.SetBalances(ctx, base, sdk.NewCoins(sdk.NewInt64Coin("uatom", 100))))
+
+	vestingAcc := authvesting.NewDelayedVestingAccount(authtypes.NewBaseAccountWithAddress(vesting), sdk.NewCoins(sdk.NewInt64Coin("uatom", 50)), blockTime.Unix()+1)
+	app.AccountKeeper.SetAccount(ctx, app.AccountKeeper.NewAccount(ctx, vestingAcc))
+	require.NoError(t, app.BankKeeper.SetBalances(ctx, vesting, sdk.NewCoins(sdk.NewInt64Coin("uatom", 80))))
+
+	balances, err := app.ExportBalances(blockTime)
+	require.NoError(t, err)
+
+	byAddr := make(map[string]gaia.AccountBalance)
+	for _, b := range balances {
+		byAddr[b.Address] = b
+	}
+
+	require.Equal(t, gaia.AccountTypeBase, byAddr[base.String()].Type)
+	require.Equal(t, "100uatom", byAddr[base.String()].Liquid.String())
+	require.Equal(t, "100uatom", byAddr[base.String()].Total.String())
+
+	require.Equal(t, gaia.AccountTypeDelayedVesting, byAddr[vesting.String()].Type)
+	require.Equal(t, "30uatom", byAddr[vesting.String()].Liquid.String())
+	require.Equal(t, "50uatom", byAddr[vesting.String()].VestingLocked.String())
+	require.Equal(t, "80uatom", byAddr[vesting.String()].Total.String())
+
+	// the delegator staked 2500000stake, is unbonding 500000stake and earned
+	// 60.6stake of rewards
+	delegator := byAddr[f.delegator.String()]
+	require.Equal(t, gaia.AccountTypeBase, delegator.Type)
+	require.True(t, delegator.Liquid.IsZero())
+	require.Equal(t, "2500000stake", delegator.Staked.String())
+	require.Equal(t, "500000stake", delegator.Unbonding.String())
+	require.Equal(t, "60stake", delegator.Rewards.String())
+	require.True(t, delegator.Commission.IsZero())
+	require.Equal(t, "3000060stake", delegator.Total.String())
+
+	// the first validator earned 30.3stake of rewards and 10.1stake of
+	// commission
+	operator := byAddr[sdk.AccAddress(f.validators[0]).String()]
+	require.Equal(t, "1000000stake", operator.Staked.String())
+	require.True(t, operator.Unbonding.IsZero())
+	require.Equal(t, "30stake", operator.Rewards.String())
+	require.Equal(t, "10stake", operator.Commission.String())
+	require.Equal(t, "1000040stake", operator.Total.String())
+
+	operator = byAddr[sdk.AccAddress(f.validators[1]).String()]
+	require.Equal(t, "1000000stake", operator.Staked.String())
+	require.True(t, operator.Rewards.IsZero())
+	require.True(t, operator.Commission.IsZero())
+	require.Equal(t, "1000000stake", operator.Total.String())
+
+	distrAcc := byAddr[authtypes.NewModuleAddress(distrtypes.ModuleName).String()]
+	require.Equal(t, gaia.AccountTypeModule, distrAcc.Type)
+	require.Equal(t, distrtypes.ModuleName, distrAcc.Module)
+
+	// vested once the end time is reached
+	balances, err = app.ExportBalances(blockTime.Add(time.Second))
+	require.NoError(t, err)
+	for _, b := range balances {
+		if b.Address == vesting.String() {
+			require.Equal(t, "80uatom", b.Liquid.String())
+			require.True(t, b.VestingLocked.IsZero())
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, gaia.WriteBalancesCSV(&buf, balances))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, len(balances)+1)
+	require.Equal(t, "address", records[0][0])
+}
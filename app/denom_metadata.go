@@ -0,0 +1,97 @@
+package gaia
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultDenomMetadata returns the metadata of the native staking token of the
+// Cosmos Hub.
+func DefaultDenomMetadata() []bank.Metadata {
+	return []bank.Metadata{
+		{
+			Description: "The native staking token of the Cosmos Hub.",
+			DenomUnits: []*bank.DenomUnit{
+				{Denom: "uatom", Exponent: uint32(0), Aliases: []string{"microatom"}},
+				{Denom: "matom", Exponent: uint32(3), Aliases: []string{"milliatom"}},
+				{Denom: "atom", Exponent: uint32(6), Aliases: []string{}},
+			},
+			Base:    "uatom",
+			Display: "atom",
+		},
+	}
+}
+
+// ParseDenomMetadata parses a JSON object or list of denom metadata.
+func ParseDenomMetadata(cdc codec.JSONMarshaler, bz []byte) ([]bank.Metadata, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(bz, &raw); err != nil {
+		// not a list, parse a single metadata object
+		raw = []json.RawMessage{bz}
+	}
+
+	metadata := make([]bank.Metadata, len(raw))
+	for i, bz := range raw {
+		if err := cdc.UnmarshalJSON(bz, &metadata[i]); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse denom metadata %d", i)
+		}
+	}
+
+	return metadata, nil
+}
+
+// ReadDenomMetadata reads a JSON object or list of denom metadata from a file.
+func ReadDenomMetadata(cdc codec.JSONMarshaler, path string) ([]bank.Metadata, error) {
+	bz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read denom metadata file")
+	}
+
+	return ParseDenomMetadata(cdc, bz)
+}
+
+// ValidateDenomMetadata validates every metadata and ensures that no denom unit
+// or alias is claimed by two metadata. Besides the bank module checks, the
+// display unit must have the largest exponent.
+func ValidateDenomMetadata(metadata []bank.Metadata) error {
+	claimed := make(map[string]string)
+	claim := func(denom, base string) error {
+		if prev, ok := claimed[denom]; ok {
+			if prev == base {
+				return fmt.Errorf("denom metadata %s: %s is used twice", base, denom)
+			}
+			return fmt.Errorf("denom metadata %s: %s is already used by the metadata of %s", base, denom, prev)
+		}
+
+		claimed[denom] = base
+		return nil
+	}
+
+	for _, m := range metadata {
+		if err := m.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid denom metadata %s", m.Base)
+		}
+
+		for _, unit := range m.DenomUnits {
+			if err := claim(unit.Denom, m.Base); err != nil {
+				return err
+			}
+			for _, alias := range unit.Aliases {
+				if err := claim(alias, m.Base); err != nil {
+					return err
+				}
+			}
+		}
+
+		if last := m.DenomUnits[len(m.DenomUnits)-1]; last.Denom != m.Display {
+			return fmt.Errorf("denom metadata %s: display unit %s must have the largest exponent, %s has a larger one", m.Base, m.Display, last.Denom)
+		}
+	}
+
+	return nil
+}
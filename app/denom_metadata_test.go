@@ -0,0 +1,100 @@
+package gaia_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestParseDenomMetadata(t *testing.T) {
+	cdc := gaia.MakeEncodingConfig().Marshaler
+	stake := `{
+		"description": "The staking token.",
+		"denom_units": [
+			{"denom": "ustake", "exponent": 0},
+			{"denom": "stake", "exponent": 6}
+		],
+		"base": "ustake",
+		"display": "stake"
+	}`
+
+	metadata, err := gaia.ParseDenomMetadata(cdc, []byte(stake))
+	require.NoError(t, err)
+	require.Len(t, metadata, 1)
+	require.Equal(t, "ustake", metadata[0].Base)
+	require.Equal(t, uint32(6), metadata[0].DenomUnits[1].Exponent)
+
+	metadata, err = gaia.ParseDenomMetadata(cdc, []byte("["+stake+","+stake+"]"))
+	require.NoError(t, err)
+	require.Len(t, metadata, 2)
+
+	_, err = gaia.ParseDenomMetadata(cdc, []byte(`{"base": 1}`))
+	require.Error(t, err)
+}
+
+func TestValidateDenomMetadata(t *testing.T) {
+	require.NoError(t, gaia.ValidateDenomMetadata(gaia.DefaultDenomMetadata()))
+
+	stake := bank.Metadata{
+		DenomUnits: []*bank.DenomUnit{
+			{Denom: "ustake", Exponent: 0},
+			{Denom: "stake", Exponent: 6},
+		},
+		Base:    "ustake",
+		Display: "stake",
+	}
+	require.NoError(t, gaia.ValidateDenomMetadata(append(gaia.DefaultDenomMetadata(), stake)))
+
+	// metadata of the same base twice
+	require.Error(t, gaia.ValidateDenomMetadata([]bank.Metadata{stake, stake}))
+
+	// unit claimed by another metadata
+	colliding := bank.Metadata{
+		DenomUnits: []*bank.DenomUnit{
+			{Denom: "nstake", Exponent: 0},
+			{Denom: "stake", Exponent: 9},
+		},
+		Base:    "nstake",
+		Display: "stake",
+	}
+	require.Error(t, gaia.ValidateDenomMetadata([]bank.Metadata{stake, colliding}))
+
+	// alias colliding with a unit
+	aliased := stake
+	aliased.DenomUnits = []*bank.DenomUnit{
+		{Denom: "ustake", Exponent: 0, Aliases: []string{"stake"}},
+		{Denom: "stake", Exponent: 6},
+	}
+	require.Error(t, gaia.ValidateDenomMetadata([]bank.Metadata{aliased}))
+
+	// exponents not increasing
+	unsorted := stake
+	unsorted.DenomUnits = []*bank.DenomUnit{
+		{Denom: "ustake", Exponent: 0},
+		{Denom: "stake", Exponent: 6},
+		{Denom: "mstake", Exponent: 3},
+	}
+	require.Error(t, gaia.ValidateDenomMetadata([]bank.Metadata{unsorted}))
+
+	// display unit not the largest one
+	smallDisplay := stake
+	smallDisplay.DenomUnits = []*bank.DenomUnit{
+		{Denom: "ustake", Exponent: 0},
+		{Denom: "mstake", Exponent: 3},
+		{Denom: "stake", Exponent: 6},
+	}
+	smallDisplay.Display = "mstake"
+	require.Error(t, gaia.ValidateDenomMetadata([]bank.Metadata{smallDisplay}))
+
+	// base unit with a non-zero exponent
+	badBase := stake
+	badBase.DenomUnits = []*bank.DenomUnit{
+		{Denom: "ustake", Exponent: 1},
+		{Denom: "stake", Exponent: 6},
+	}
+	require.Error(t, gaia.ValidateDenomMetadata([]bank.Metadata{badBase}))
+}
@@ -1,13 +1,19 @@
 package gaia
 
 import (
+	"bytes"
 	"encoding/json"
-	"log"
+	"fmt"
+	"io"
+	"os"
+	"sort"
 
+	"github.com/pkg/errors"
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 
 	servertypes "github.com/cosmos/cosmos-sdk/server/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
 	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
 	"github.com/cosmos/cosmos-sdk/x/staking"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
@@ -18,26 +24,170 @@ import (
 func (app *GaiaApp) ExportAppStateAndValidators(
 	forZeroHeight bool, jailAllowedAddrs []string,
 ) (servertypes.ExportedApp, error) {
+	ctx, height, err := app.exportContext(forZeroHeight, ZeroHeightOptions{JailAllowedAddrs: jailAllowedAddrs})
+	if err != nil {
+		return servertypes.ExportedApp{}, err
+	}
+
+	genState := app.mm.ExportGenesis(ctx, app.appCodec)
+	appState, err := json.MarshalIndent(genState, "", "  ")
+	if err != nil {
+		return servertypes.ExportedApp{}, err
+	}
+
+	exported, err := app.exportValidators(ctx, height)
+	exported.AppState = appState
+	return exported, err
+}
+
+// StreamAppStateAndValidators exports the genesis state of the given modules,
+// or of every module if modules is empty, and writes it to w as a JSON object.
+// zeroHeightOpts are the options of a zero height export. Each module genesis
+// is written, sorted by module name, as soon as it is exported, so that only
+// one module genesis is held in memory at a time. The returned ExportedApp has
+// no AppState.
+func (app *GaiaApp) StreamAppStateAndValidators(
+	w io.Writer, forZeroHeight bool, zeroHeightOpts ZeroHeightOptions, modules []string,
+) (servertypes.ExportedApp, error) {
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return servertypes.ExportedApp{}, err
+	}
+
+	sep := ""
+	exported, err := app.exportModules(forZeroHeight, zeroHeightOpts, modules, func(moduleName string, moduleGenesis json.RawMessage) error {
+		_, err := fmt.Fprintf(w, "%s%q:%s", sep, moduleName, moduleGenesis)
+		sep = ","
+		return err
+	})
+	if err != nil {
+		return exported, err
+	}
+
+	_, err = io.WriteString(w, "}")
+	return exported, err
+}
+
+// ExportShardedAppStateAndValidators exports the genesis state of the given
+// modules, or of every module if modules is empty, as a sharded genesis: the
+// indented genesis state of each module is written to its own file of dir as
+// soon as it is exported, followed by the manifest. The returned ExportedApp
+// has no AppState.
+func (app *GaiaApp) ExportShardedAppStateAndValidators(
+	dir string, forZeroHeight bool, zeroHeightOpts ZeroHeightOptions, modules []string,
+) (servertypes.ExportedApp, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return servertypes.ExportedApp{}, errors.Wrap(err, "failed to create genesis shards directory")
+	}
+
+	manifest := GenesisManifest{}
+	exported, err := app.exportModules(forZeroHeight, zeroHeightOpts, modules, func(moduleName string, moduleGenesis json.RawMessage) error {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, moduleGenesis, "", "  "); err != nil {
+			return err
+		}
+
+		shard, err := WriteGenesisShard(dir, moduleName, buf.Bytes())
+		manifest.Modules = append(manifest.Modules, shard)
+		return err
+	})
+	if err != nil {
+		return exported, err
+	}
+
+	return exported, WriteGenesisManifest(dir, manifest)
+}
+
+// exportModules exports the genesis state of the given modules, or of every
+// module if modules is empty, passing the sorted JSON genesis state of each
+// module to write, by module name.
+func (app *GaiaApp) exportModules(
+	forZeroHeight bool, zeroHeightOpts ZeroHeightOptions, modules []string, write func(string, json.RawMessage) error,
+) (servertypes.ExportedApp, error) {
+	modules, err := app.exportedModules(modules)
+	if err != nil {
+		return servertypes.ExportedApp{}, err
+	}
+
+	ctx, height, err := app.exportContext(forZeroHeight, zeroHeightOpts)
+	if err != nil {
+		return servertypes.ExportedApp{}, err
+	}
+
+	for _, moduleName := range modules {
+		// modules without genesis state, e.g. params, export nothing
+		moduleGenesis := []byte("null")
+		if bz := app.mm.Modules[moduleName].ExportGenesis(ctx, app.appCodec); len(bz) > 0 {
+			if moduleGenesis, err = sdk.SortJSON(bz); err != nil {
+				return servertypes.ExportedApp{}, errors.Wrapf(err, "failed to sort %s genesis state", moduleName)
+			}
+		}
+
+		if err := write(moduleName, moduleGenesis); err != nil {
+			return servertypes.ExportedApp{}, errors.Wrapf(err, "failed to write %s genesis state", moduleName)
+		}
+	}
+
+	return app.exportValidators(ctx, height)
+}
+
+// exportedModules returns the sorted modules to export, failing on modules
+// without genesis state.
+func (app *GaiaApp) exportedModules(modules []string) ([]string, error) {
+	if len(modules) == 0 {
+		modules = app.mm.OrderExportGenesis
+	}
+
+	exported := make([]string, 0, len(modules))
+	seen := make(map[string]bool, len(modules))
+	for _, moduleName := range modules {
+		if _, ok := app.mm.Modules[moduleName]; !ok {
+			return nil, fmt.Errorf("unknown module %s; modules are %v", moduleName, app.mm.OrderExportGenesis)
+		}
+		if !seen[moduleName] {
+			seen[moduleName] = true
+			exported = append(exported, moduleName)
+		}
+	}
+	sort.Strings(exported)
+
+	return exported, nil
+}
+
+// exportContext returns the context to export state with, and the height the
+// exported genesis starts at. For a zero height export, the audit of the
+// state changes is written to zeroHeightOpts.AuditOut, if set.
+func (app *GaiaApp) exportContext(forZeroHeight bool, zeroHeightOpts ZeroHeightOptions) (sdk.Context, int64, error) {
 	// as if they could withdraw from the start of the next block
 	ctx := app.NewContext(true, tmproto.Header{Height: app.LastBlockHeight()})
 
 	// We export at last height + 1, because that's the height at which
 	// Tendermint will start InitChain.
 	height := app.LastBlockHeight() + 1
-	if forZeroHeight {
-		height = 0
-		app.prepForZeroHeightGenesis(ctx, jailAllowedAddrs)
+	if !forZeroHeight {
+		return ctx, height, nil
 	}
 
-	genState := app.mm.ExportGenesis(ctx, app.appCodec)
-	appState, err := json.MarshalIndent(genState, "", "  ")
+	audit, err := app.prepForZeroHeightGenesis(ctx, zeroHeightOpts)
 	if err != nil {
-		return servertypes.ExportedApp{}, err
+		return ctx, 0, errors.Wrap(err, "failed to prepare zero height genesis")
 	}
 
+	if zeroHeightOpts.AuditOut != nil {
+		bz, err := json.MarshalIndent(audit, "", "  ")
+		if err != nil {
+			return ctx, 0, errors.Wrap(err, "failed to marshal zero height audit")
+		}
+		if _, err := fmt.Fprintln(zeroHeightOpts.AuditOut, string(bz)); err != nil {
+			return ctx, 0, errors.Wrap(err, "failed to write zero height audit")
+		}
+	}
+
+	return ctx, 0, nil
+}
+
+func (app *GaiaApp) exportValidators(ctx sdk.Context, height int64) (servertypes.ExportedApp, error) {
 	validators, err := staking.WriteValidators(ctx, app.StakingKeeper)
 	return servertypes.ExportedApp{
-		AppState:        appState,
 		Validators:      validators,
 		Height:          height,
 		ConsensusParams: app.BaseApp.GetConsensusParams(ctx),
@@ -47,20 +197,22 @@ func (app *GaiaApp) ExportAppStateAndValidators(
 // prepare for fresh start at zero height
 // NOTE zero height genesis is a temporary feature which will be deprecated
 //      in favour of export at a block height
-func (app *GaiaApp) prepForZeroHeightGenesis(ctx sdk.Context, jailAllowedAddrs []string) {
-	applyAllowedAddrs := false
-
-	// check if there is a allowed address list
-	if len(jailAllowedAddrs) > 0 {
-		applyAllowedAddrs = true
+func (app *GaiaApp) prepForZeroHeightGenesis(ctx sdk.Context, opts ZeroHeightOptions) (ZeroHeightAudit, error) {
+	audit := ZeroHeightAudit{
+		Height:                 ctx.BlockHeight(),
+		CommissionWithdrawals:  []CommissionWithdrawal{},
+		RewardWithdrawals:      []RewardWithdrawal{},
+		CommunityPoolDonations: []CommunityPoolDonation{},
+		JailedValidators:       []string{},
+		HeightResets:           []HeightReset{},
 	}
 
 	allowedAddrsMap := make(map[string]bool)
 
-	for _, addr := range jailAllowedAddrs {
+	for _, addr := range opts.JailAllowedAddrs {
 		_, err := sdk.ValAddressFromBech32(addr)
 		if err != nil {
-			log.Fatal(err)
+			return audit, errors.Wrapf(err, "invalid jail allowed address %s", addr)
 		}
 		allowedAddrsMap[addr] = true
 	}
@@ -70,25 +222,164 @@ func (app *GaiaApp) prepForZeroHeightGenesis(ctx sdk.Context, jailAllowedAddrs [
 
 	/* Handle fee distribution state. */
 
+	if opts.SkipRewardWithdrawal {
+		if err := app.rebaseDistributionHeights(ctx, &audit); err != nil {
+			return audit, err
+		}
+	} else if err := app.resetDistribution(ctx, &audit); err != nil {
+		return audit, err
+	}
+
+	/* Handle staking state. */
+
+	if !opts.KeepCreationHeights {
+		// iterate through redelegations, reset creation height
+		app.StakingKeeper.IterateRedelegations(ctx, func(_ int64, red stakingtypes.Redelegation) (stop bool) {
+			for i := range red.Entries {
+				if red.Entries[i].CreationHeight != 0 {
+					audit.HeightResets = append(audit.HeightResets, HeightReset{
+						Field:        ResetRedelegationCreationHeight,
+						Delegator:    red.DelegatorAddress,
+						Validator:    red.ValidatorSrcAddress,
+						ValidatorDst: red.ValidatorDstAddress,
+						Before:       red.Entries[i].CreationHeight,
+					})
+				}
+				red.Entries[i].CreationHeight = 0
+			}
+			app.StakingKeeper.SetRedelegation(ctx, red)
+			return false
+		})
+
+		// iterate through unbonding delegations, reset creation height
+		app.StakingKeeper.IterateUnbondingDelegations(ctx, func(_ int64, ubd stakingtypes.UnbondingDelegation) (stop bool) {
+			for i := range ubd.Entries {
+				if ubd.Entries[i].CreationHeight != 0 {
+					audit.HeightResets = append(audit.HeightResets, HeightReset{
+						Field:     ResetUnbondingDelegationCreationHeight,
+						Delegator: ubd.DelegatorAddress,
+						Validator: ubd.ValidatorAddress,
+						Before:    ubd.Entries[i].CreationHeight,
+					})
+				}
+				ubd.Entries[i].CreationHeight = 0
+			}
+			app.StakingKeeper.SetUnbondingDelegation(ctx, ubd)
+			return false
+		})
+	}
+
+	// Iterate through validators by power descending, reset bond heights, and
+	// update bond intra-tx counters.
+	store := ctx.KVStore(app.keys[stakingtypes.StoreKey])
+	iter := sdk.KVStoreReversePrefixIterator(store, stakingtypes.ValidatorsKey)
+	counter := int16(0)
+
+	for ; iter.Valid(); iter.Next() {
+		addr := sdk.ValAddress(iter.Key()[1:])
+		validator, found := app.StakingKeeper.GetValidator(ctx, addr)
+		if !found {
+			iter.Close()
+			return audit, fmt.Errorf("expected validator %s, not found", addr)
+		}
+
+		if validator.UnbondingHeight != 0 {
+			audit.HeightResets = append(audit.HeightResets, HeightReset{
+				Field:     ResetValidatorUnbondingHeight,
+				Validator: addr.String(),
+				Before:    validator.UnbondingHeight,
+			})
+		}
+		validator.UnbondingHeight = 0
+		if len(allowedAddrsMap) > 0 && !allowedAddrsMap[addr.String()] && !validator.Jailed {
+			// jailed validators are not in the power index, as when jailed by
+			// the staking keeper
+			app.StakingKeeper.DeleteValidatorByPowerIndex(ctx, validator)
+			validator.Jailed = true
+			audit.JailedValidators = append(audit.JailedValidators, addr.String())
+		}
+
+		app.StakingKeeper.SetValidator(ctx, validator)
+		counter++
+	}
+
+	iter.Close()
+
+	if _, err := app.StakingKeeper.ApplyAndReturnValidatorSetUpdates(ctx); err != nil {
+		return audit, errors.Wrap(err, "failed to apply validator set updates")
+	}
+
+	/* Handle slashing state. */
+
+	// reset start height on signing infos
+	app.SlashingKeeper.IterateValidatorSigningInfos(
+		ctx,
+		func(addr sdk.ConsAddress, info slashingtypes.ValidatorSigningInfo) (stop bool) {
+			if info.StartHeight != 0 {
+				audit.HeightResets = append(audit.HeightResets, HeightReset{
+					Field:       ResetSigningInfoStartHeight,
+					ConsAddress: addr.String(),
+					Before:      info.StartHeight,
+				})
+			}
+			info.StartHeight = 0
+			app.SlashingKeeper.SetValidatorSigningInfo(ctx, addr, info)
+			return false
+		},
+	)
+
+	return audit, nil
+}
+
+// resetDistribution withdraws all commission and rewards, donates the
+// outstanding reward remainders to the community pool and reinitializes the
+// rewards of all validators and delegations at height 0.
+func (app *GaiaApp) resetDistribution(ctx sdk.Context, audit *ZeroHeightAudit) error {
 	// withdraw all validator commission
+	var err error
 	app.StakingKeeper.IterateValidators(ctx, func(_ int64, val stakingtypes.ValidatorI) (stop bool) {
-		_, _ = app.DistrKeeper.WithdrawValidatorCommission(ctx, val.GetOperator())
+		commission, withdrawErr := app.DistrKeeper.WithdrawValidatorCommission(ctx, val.GetOperator())
+		switch {
+		case distrtypes.ErrNoValidatorCommission.Is(withdrawErr):
+		case withdrawErr != nil:
+			err = errors.Wrapf(withdrawErr, "failed to withdraw commission of validator %s", val.GetOperator())
+			return true
+		default:
+			audit.CommissionWithdrawals = append(audit.CommissionWithdrawals, CommissionWithdrawal{
+				Validator: val.GetOperator().String(),
+				Amount:    commission,
+			})
+		}
 		return false
 	})
+	if err != nil {
+		return err
+	}
 
 	// withdraw all delegator rewards
 	dels := app.StakingKeeper.GetAllDelegations(ctx)
 	for _, delegation := range dels {
 		valAddr, err := sdk.ValAddressFromBech32(delegation.ValidatorAddress)
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		delAddr, err := sdk.AccAddressFromBech32(delegation.DelegatorAddress)
 		if err != nil {
-			panic(err)
+			return err
+		}
+
+		rewards, err := app.DistrKeeper.WithdrawDelegationRewards(ctx, delAddr, valAddr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to withdraw rewards of delegator %s from validator %s", delAddr, valAddr)
+		}
+		if !rewards.IsZero() {
+			audit.RewardWithdrawals = append(audit.RewardWithdrawals, RewardWithdrawal{
+				Delegator: delegation.DelegatorAddress,
+				Validator: delegation.ValidatorAddress,
+				Amount:    rewards,
+			})
 		}
-		_, _ = app.DistrKeeper.WithdrawDelegationRewards(ctx, delAddr, valAddr)
 	}
 
 	// clear validator slash events
@@ -98,13 +389,18 @@ func (app *GaiaApp) prepForZeroHeightGenesis(ctx sdk.Context, jailAllowedAddrs [
 	app.DistrKeeper.DeleteAllValidatorHistoricalRewards(ctx)
 
 	// set context height to zero
-	height := ctx.BlockHeight()
 	ctx = ctx.WithBlockHeight(0)
 
 	// reinitialize all validators
 	app.StakingKeeper.IterateValidators(ctx, func(_ int64, val stakingtypes.ValidatorI) (stop bool) {
 		// donate any unwithdrawn outstanding reward fraction tokens to the community pool
 		scraps := app.DistrKeeper.GetValidatorOutstandingRewardsCoins(ctx, val.GetOperator())
+		if !scraps.IsZero() {
+			audit.CommunityPoolDonations = append(audit.CommunityPoolDonations, CommunityPoolDonation{
+				Validator: val.GetOperator().String(),
+				Amount:    scraps,
+			})
+		}
 		feePool := app.DistrKeeper.GetFeePool(ctx)
 		feePool.CommunityPool = feePool.CommunityPool.Add(scraps...)
 		app.DistrKeeper.SetFeePool(ctx, feePool)
@@ -117,77 +413,47 @@ func (app *GaiaApp) prepForZeroHeightGenesis(ctx sdk.Context, jailAllowedAddrs [
 	for _, del := range dels {
 		valAddr, err := sdk.ValAddressFromBech32(del.ValidatorAddress)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		delAddr, err := sdk.AccAddressFromBech32(del.DelegatorAddress)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		app.DistrKeeper.Hooks().BeforeDelegationCreated(ctx, delAddr, valAddr)
 		app.DistrKeeper.Hooks().AfterDelegationModified(ctx, delAddr, valAddr)
 	}
 
-	// reset context height
-	ctx = ctx.WithBlockHeight(height)
-
-	/* Handle staking state. */
+	return nil
+}
 
-	// iterate through redelegations, reset creation height
-	app.StakingKeeper.IterateRedelegations(ctx, func(_ int64, red stakingtypes.Redelegation) (stop bool) {
-		for i := range red.Entries {
-			red.Entries[i].CreationHeight = 0
-		}
-		app.StakingKeeper.SetRedelegation(ctx, red)
-		return false
+// rebaseDistributionHeights resets the heights of the delegator starting infos
+// to 0, keeping the rewards of the delegations, so that the rewards are
+// calculated from the start of the new chain. The slash events cannot be moved
+// to height 0 without applying them to the delegations started after them, so
+// the state is rejected if there are any.
+func (app *GaiaApp) rebaseDistributionHeights(ctx sdk.Context, audit *ZeroHeightAudit) error {
+	var slashed sdk.ValAddress
+	app.DistrKeeper.IterateValidatorSlashEvents(ctx, func(val sdk.ValAddress, _ uint64, _ distrtypes.ValidatorSlashEvent) (stop bool) {
+		slashed = val
+		return true
 	})
+	if slashed != nil {
+		return fmt.Errorf("cannot keep the rewards of slashed validator %s; withdraw all commission and rewards instead", slashed)
+	}
 
-	// iterate through unbonding delegations, reset creation height
-	app.StakingKeeper.IterateUnbondingDelegations(ctx, func(_ int64, ubd stakingtypes.UnbondingDelegation) (stop bool) {
-		for i := range ubd.Entries {
-			ubd.Entries[i].CreationHeight = 0
+	app.DistrKeeper.IterateDelegatorStartingInfos(ctx, func(val sdk.ValAddress, del sdk.AccAddress, info distrtypes.DelegatorStartingInfo) (stop bool) {
+		if info.Height != 0 {
+			audit.HeightResets = append(audit.HeightResets, HeightReset{
+				Field:     ResetDelegatorStartingInfoHeight,
+				Delegator: del.String(),
+				Validator: val.String(),
+				Before:    int64(info.Height),
+			})
 		}
-		app.StakingKeeper.SetUnbondingDelegation(ctx, ubd)
+		info.Height = 0
+		app.DistrKeeper.SetDelegatorStartingInfo(ctx, val, del, info)
 		return false
 	})
 
-	// Iterate through validators by power descending, reset bond heights, and
-	// update bond intra-tx counters.
-	store := ctx.KVStore(app.keys[stakingtypes.StoreKey])
-	iter := sdk.KVStoreReversePrefixIterator(store, stakingtypes.ValidatorsKey)
-	counter := int16(0)
-
-	for ; iter.Valid(); iter.Next() {
-		addr := sdk.ValAddress(iter.Key()[1:])
-		validator, found := app.StakingKeeper.GetValidator(ctx, addr)
-		if !found {
-			panic("expected validator, not found")
-		}
-
-		validator.UnbondingHeight = 0
-		if applyAllowedAddrs && !allowedAddrsMap[addr.String()] {
-			validator.Jailed = true
-		}
-
-		app.StakingKeeper.SetValidator(ctx, validator)
-		counter++
-	}
-
-	iter.Close()
-
-	_, err := app.StakingKeeper.ApplyAndReturnValidatorSetUpdates(ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	/* Handle slashing state. */
-
-	// reset start height on signing infos
-	app.SlashingKeeper.IterateValidatorSigningInfos(
-		ctx,
-		func(addr sdk.ConsAddress, info slashingtypes.ValidatorSigningInfo) (stop bool) {
-			info.StartHeight = 0
-			app.SlashingKeeper.SetValidatorSigningInfo(ctx, addr, info)
-			return false
-		},
-	)
+	return nil
 }
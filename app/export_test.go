@@ -0,0 +1,327 @@
+package gaia_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	"github.com/cosmos/cosmos-sdk/simapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingkeeper "github.com/cosmos/cosmos-sdk/x/staking/keeper"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func setupExportApp(t *testing.T) *gaia.GaiaApp {
+	app := gaia.NewGaiaApp(log.NewNopLogger(), dbm.NewMemDB(), nil, true, map[int64]bool{}, t.TempDir(), 0, gaia.MakeEncodingConfig(), simapp.EmptyAppOptions{})
+
+	appState, err := json.Marshal(gaia.NewDefaultGenesisState())
+	require.NoError(t, err)
+
+	app.InitChain(abci.RequestInitChain{
+		Validators:      []abci.ValidatorUpdate{},
+		AppStateBytes:   appState,
+		ConsensusParams: simapp.DefaultConsensusParams,
+	})
+	app.Commit()
+
+	return app
+}
+
+// stakingFixture is a chain of two bonded validators with a self-delegation of
+// 1000000stake each and a 10% commission rate, and a delegator who delegated
+// 2000000stake to the first one and 1000000stake to the second one, then
+// undelegated 500000stake from the second one. All of it happened in the block
+// at height, and the first validator earned 101stake of rewards in the next
+// one.
+type stakingFixture struct {
+	app        *gaia.GaiaApp
+	height     int64
+	validators []sdk.ValAddress
+	delegator  sdk.AccAddress
+}
+
+func setupStakingFixture(t *testing.T) stakingFixture {
+	f := stakingFixture{app: setupExportApp(t), delegator: sdk.AccAddress([]byte("delegator___________"))}
+	app := f.app
+
+	ctx := beginBlock(app)
+	f.height = ctx.BlockHeight()
+	msgServer := stakingkeeper.NewMsgServerImpl(app.StakingKeeper)
+	bondDenom := app.StakingKeeper.BondDenom(ctx)
+
+	fund := func(addr sdk.AccAddress, amount int64) {
+		coins := sdk.NewCoins(sdk.NewInt64Coin(bondDenom, amount))
+		require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, coins))
+		require.NoError(t, app.BankKeeper.SendCoinsFromModuleToAccount(ctx, minttypes.ModuleName, addr, coins))
+	}
+
+	for _, operator := range []string{"validator1__________", "validator2__________"} {
+		valAddr := sdk.ValAddress(operator)
+		fund(sdk.AccAddress(valAddr), 1000000)
+
+		msg, err := stakingtypes.NewMsgCreateValidator(
+			valAddr, ed25519.GenPrivKey().PubKey(), sdk.NewInt64Coin(bondDenom, 1000000), stakingtypes.Description{Moniker: operator},
+			stakingtypes.NewCommissionRates(sdk.NewDecWithPrec(1, 1), sdk.NewDecWithPrec(2, 1), sdk.NewDecWithPrec(1, 2)), sdk.OneInt(),
+		)
+		require.NoError(t, err)
+		_, err = msgServer.CreateValidator(sdk.WrapSDKContext(ctx), msg)
+		require.NoError(t, err)
+		f.validators = append(f.validators, valAddr)
+	}
+	_, err := app.StakingKeeper.ApplyAndReturnValidatorSetUpdates(ctx)
+	require.NoError(t, err)
+
+	fund(f.delegator, 3000000)
+	_, err = msgServer.Delegate(sdk.WrapSDKContext(ctx), stakingtypes.NewMsgDelegate(f.delegator, f.validators[0], sdk.NewInt64Coin(bondDenom, 2000000)))
+	require.NoError(t, err)
+	_, err = msgServer.Delegate(sdk.WrapSDKContext(ctx), stakingtypes.NewMsgDelegate(f.delegator, f.validators[1], sdk.NewInt64Coin(bondDenom, 1000000)))
+	require.NoError(t, err)
+	_, err = msgServer.Undelegate(sdk.WrapSDKContext(ctx), stakingtypes.NewMsgUndelegate(f.delegator, f.validators[1], sdk.NewInt64Coin(bondDenom, 500000)))
+	require.NoError(t, err)
+
+	endBlock(app, ctx)
+
+	// rewards are earned from the next block on
+	ctx = beginBlock(app)
+	rewards := sdk.NewCoins(sdk.NewInt64Coin(bondDenom, 101))
+	require.NoError(t, app.BankKeeper.MintCoins(ctx, minttypes.ModuleName, rewards))
+	require.NoError(t, app.BankKeeper.SendCoinsFromModuleToModule(ctx, minttypes.ModuleName, distrtypes.ModuleName, rewards))
+	app.DistrKeeper.AllocateTokensToValidator(ctx, app.StakingKeeper.Validator(ctx, f.validators[0]), sdk.NewDecCoinsFromCoins(rewards...))
+	endBlock(app, ctx)
+
+	return f
+}
+
+// beginBlock begins the block after the last one, and returns the context to
+// deliver its transactions with.
+func beginBlock(app *gaia.GaiaApp) sdk.Context {
+	header := tmproto.Header{Height: app.LastBlockHeight() + 1}
+	app.BeginBlock(abci.RequestBeginBlock{Header: header})
+	return app.NewContext(false, header)
+}
+
+// endBlock ends and commits the block of ctx.
+func endBlock(app *gaia.GaiaApp, ctx sdk.Context) {
+	app.EndBlock(abci.RequestEndBlock{Height: ctx.BlockHeight()})
+	app.Commit()
+}
+
+func TestStreamAppStateAndValidators(t *testing.T) {
+	app := setupExportApp(t)
+
+	exported, err := app.ExportAppStateAndValidators(false, nil)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	streamed, err := app.StreamAppStateAndValidators(&buf, false, gaia.ZeroHeightOptions{}, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, string(exported.AppState), buf.String())
+	require.Equal(t, exported.Height, streamed.Height)
+	require.Equal(t, exported.Validators, streamed.Validators)
+	require.Nil(t, streamed.AppState)
+
+	buf.Reset()
+	_, err = app.StreamAppStateAndValidators(&buf, false, gaia.ZeroHeightOptions{}, []string{stakingtypes.ModuleName, banktypes.ModuleName, stakingtypes.ModuleName})
+	require.NoError(t, err)
+
+	var appState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &appState))
+	require.Len(t, appState, 2)
+	require.Contains(t, appState, banktypes.ModuleName)
+	require.Contains(t, appState, stakingtypes.ModuleName)
+
+	_, err = app.StreamAppStateAndValidators(&buf, false, gaia.ZeroHeightOptions{}, []string{"unknown"})
+	require.Error(t, err)
+}
+
+func TestExportShardedAppStateAndValidators(t *testing.T) {
+	app := setupExportApp(t)
+
+	var buf bytes.Buffer
+	_, err := app.StreamAppStateAndValidators(&buf, false, gaia.ZeroHeightOptions{}, nil)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	_, err = app.ExportShardedAppStateAndValidators(dir, false, gaia.ZeroHeightOptions{}, nil)
+	require.NoError(t, err)
+
+	appState, err := gaia.ReadGenesisShards(dir)
+	require.NoError(t, err)
+
+	appStateJSON, err := json.Marshal(appState)
+	require.NoError(t, err)
+	require.JSONEq(t, buf.String(), string(appStateJSON))
+}
+
+func TestZeroHeightExportAudit(t *testing.T) {
+	app := setupExportApp(t)
+
+	consAddr := sdk.ConsAddress([]byte("cons________________"))
+	ctx := app.NewContext(true, tmproto.Header{Height: app.LastBlockHeight()})
+	app.SlashingKeeper.SetValidatorSigningInfo(ctx, consAddr, slashingtypes.NewValidatorSigningInfo(consAddr, 5, 0, ctx.BlockTime(), false, 0))
+
+	_, err := app.ExportAppStateAndValidators(true, []string{"invalid"})
+	require.Error(t, err)
+
+	var buf, audit bytes.Buffer
+	exported, err := app.StreamAppStateAndValidators(&buf, true, gaia.ZeroHeightOptions{AuditOut: &audit}, []string{slashingtypes.ModuleName})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), exported.Height)
+
+	var zeroHeightAudit gaia.ZeroHeightAudit
+	require.NoError(t, json.Unmarshal(audit.Bytes(), &zeroHeightAudit))
+	require.Equal(t, app.LastBlockHeight(), zeroHeightAudit.Height)
+	require.Equal(t, []gaia.HeightReset{{
+		Field:       gaia.ResetSigningInfoStartHeight,
+		ConsAddress: consAddr.String(),
+		Before:      5,
+	}}, zeroHeightAudit.HeightResets)
+	require.Empty(t, zeroHeightAudit.JailedValidators)
+
+	var appState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &appState))
+
+	var slashingGenState slashingtypes.GenesisState
+	app.AppCodec().MustUnmarshalJSON(appState[slashingtypes.ModuleName], &slashingGenState)
+	require.Len(t, slashingGenState.SigningInfos, 1)
+	require.Equal(t, int64(0), slashingGenState.SigningInfos[0].ValidatorSigningInfo.StartHeight)
+}
+
+func TestZeroHeightExportAuditOptions(t *testing.T) {
+	stake := func(amount int64) sdk.Coins {
+		return sdk.NewCoins(sdk.NewInt64Coin(sdk.DefaultBondDenom, amount))
+	}
+
+	export := func(t *testing.T, f stakingFixture, opts gaia.ZeroHeightOptions) (gaia.ZeroHeightAudit, error) {
+		var buf, audit bytes.Buffer
+		opts.AuditOut = &audit
+		if _, err := f.app.StreamAppStateAndValidators(&buf, true, opts, nil); err != nil {
+			return gaia.ZeroHeightAudit{}, err
+		}
+
+		var zeroHeightAudit gaia.ZeroHeightAudit
+		require.NoError(t, json.Unmarshal(audit.Bytes(), &zeroHeightAudit))
+		return zeroHeightAudit, nil
+	}
+
+	resets := func(audit gaia.ZeroHeightAudit, field string) []gaia.HeightReset {
+		var resets []gaia.HeightReset
+		for _, reset := range audit.HeightResets {
+			if reset.Field == field {
+				resets = append(resets, reset)
+			}
+		}
+		return resets
+	}
+
+	t.Run("withdrawals and donations", func(t *testing.T) {
+		f := setupStakingFixture(t)
+		val1, val2 := f.validators[0].String(), f.validators[1].String()
+
+		audit, err := export(t, f, gaia.ZeroHeightOptions{})
+		require.NoError(t, err)
+
+		// the commission of 10.1stake and the rewards of 30.3stake and 60.6stake
+		// are withdrawn. The remainders of the rewards go to the community pool
+		// on withdrawal, and the one of the commission is donated to it.
+		require.Equal(t, []gaia.CommissionWithdrawal{{Validator: val1, Amount: stake(10)}}, audit.CommissionWithdrawals)
+		require.ElementsMatch(t, []gaia.RewardWithdrawal{
+			{Delegator: sdk.AccAddress(f.validators[0]).String(), Validator: val1, Amount: stake(30)},
+			{Delegator: f.delegator.String(), Validator: val1, Amount: stake(60)},
+		}, audit.RewardWithdrawals)
+		require.Equal(t, []gaia.CommunityPoolDonation{{Validator: val1, Amount: sdk.NewDecCoins(sdk.NewDecCoinFromDec(sdk.DefaultBondDenom, sdk.NewDecWithPrec(1, 1)))}}, audit.CommunityPoolDonations)
+		require.Empty(t, audit.JailedValidators)
+
+		ctx := f.app.NewContext(true, tmproto.Header{})
+		require.Equal(t, sdk.NewDecCoins(sdk.NewInt64DecCoin(sdk.DefaultBondDenom, 1)), f.app.DistrKeeper.GetFeePoolCommunityCoins(ctx))
+		require.Equal(t, stake(60), f.app.BankKeeper.GetAllBalances(ctx, f.delegator))
+
+		// the heights are reset
+		require.Equal(t, []gaia.HeightReset{{
+			Field:     gaia.ResetUnbondingDelegationCreationHeight,
+			Delegator: f.delegator.String(),
+			Validator: val2,
+			Before:    f.height,
+		}}, resets(audit, gaia.ResetUnbondingDelegationCreationHeight))
+		require.Len(t, resets(audit, gaia.ResetSigningInfoStartHeight), 2)
+		require.Empty(t, resets(audit, gaia.ResetDelegatorStartingInfoHeight))
+	})
+
+	t.Run("jail allowed addresses", func(t *testing.T) {
+		f := setupStakingFixture(t)
+
+		audit, err := export(t, f, gaia.ZeroHeightOptions{JailAllowedAddrs: []string{f.validators[0].String()}})
+		require.NoError(t, err)
+		require.Equal(t, []string{f.validators[1].String()}, audit.JailedValidators)
+
+		ctx := f.app.NewContext(true, tmproto.Header{})
+		require.False(t, f.app.StakingKeeper.Validator(ctx, f.validators[0]).IsJailed())
+		require.True(t, f.app.StakingKeeper.Validator(ctx, f.validators[1]).IsJailed())
+	})
+
+	t.Run("keep creation heights", func(t *testing.T) {
+		f := setupStakingFixture(t)
+
+		audit, err := export(t, f, gaia.ZeroHeightOptions{KeepCreationHeights: true})
+		require.NoError(t, err)
+		require.Empty(t, resets(audit, gaia.ResetUnbondingDelegationCreationHeight))
+
+		ctx := f.app.NewContext(true, tmproto.Header{})
+		ubd, found := f.app.StakingKeeper.GetUnbondingDelegation(ctx, f.delegator, f.validators[1])
+		require.True(t, found)
+		require.Equal(t, f.height, ubd.Entries[0].CreationHeight)
+	})
+
+	t.Run("skip reward withdrawal", func(t *testing.T) {
+		f := setupStakingFixture(t)
+
+		audit, err := export(t, f, gaia.ZeroHeightOptions{SkipRewardWithdrawal: true})
+		require.NoError(t, err)
+		require.Empty(t, audit.CommissionWithdrawals)
+		require.Empty(t, audit.RewardWithdrawals)
+		require.Empty(t, audit.CommunityPoolDonations)
+
+		// the rewards are kept, calculated from height 0
+		require.Len(t, resets(audit, gaia.ResetDelegatorStartingInfoHeight), 4)
+		require.Contains(t, audit.HeightResets, gaia.HeightReset{
+			Field:     gaia.ResetDelegatorStartingInfoHeight,
+			Delegator: f.delegator.String(),
+			Validator: f.validators[0].String(),
+			Before:    f.height,
+		})
+
+		ctx := f.app.NewContext(true, tmproto.Header{Height: 1})
+		require.Equal(t, uint64(0), f.app.DistrKeeper.GetDelegatorStartingInfo(ctx, f.validators[0], f.delegator).Height)
+		rewards, err := f.app.DistrKeeper.WithdrawDelegationRewards(ctx, f.delegator, f.validators[0])
+		require.NoError(t, err)
+		require.Equal(t, stake(60), rewards)
+	})
+
+	t.Run("skip reward withdrawal of slashed validator", func(t *testing.T) {
+		f := setupStakingFixture(t)
+
+		ctx := beginBlock(f.app)
+		validator := f.app.StakingKeeper.Validator(ctx, f.validators[0])
+		consAddr, err := validator.GetConsAddr()
+		require.NoError(t, err)
+		f.app.StakingKeeper.Slash(ctx, consAddr, ctx.BlockHeight(), validator.GetConsensusPower(), sdk.NewDecWithPrec(1, 2))
+		endBlock(f.app, ctx)
+
+		_, err = export(t, f, gaia.ZeroHeightOptions{SkipRewardWithdrawal: true})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "slashed validator "+f.validators[0].String())
+	})
+}
@@ -0,0 +1,205 @@
+package gaia
+
+// This file implements the sharded genesis layout: the genesis document is
+// kept without app state, and the genesis state of each module is written to
+// its own file of the shards directory next to it, listed together with its
+// checksum by the manifest of the directory.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	tmcfg "github.com/tendermint/tendermint/config"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	// GenesisShardsDirName is the name of the shards directory, next to the
+	// genesis document.
+	GenesisShardsDirName = "genesis"
+	// GenesisManifestFile is the name of the manifest of the shards directory.
+	GenesisManifestFile = "manifest.json"
+)
+
+// GenesisShard is the genesis state file of a module.
+type GenesisShard struct {
+	Module string `json:"module"`
+	// File is the name of the file, relative to the shards directory.
+	File string `json:"file"`
+	// SHA256 is the hex-encoded checksum of the file.
+	SHA256 string `json:"sha256"`
+}
+
+// GenesisManifest lists the genesis state files of the modules.
+type GenesisManifest struct {
+	Modules []GenesisShard `json:"modules"`
+}
+
+// Validate fails on duplicate modules and on files outside of the shards
+// directory.
+func (m GenesisManifest) Validate() error {
+	seen := make(map[string]bool, len(m.Modules))
+	for _, shard := range m.Modules {
+		if shard.Module == "" {
+			return fmt.Errorf("genesis shard %s has no module", shard.File)
+		}
+		if seen[shard.Module] {
+			return fmt.Errorf("module %s is listed twice", shard.Module)
+		}
+		seen[shard.Module] = true
+
+		if shard.File == "" || shard.File != filepath.Base(shard.File) || shard.File == GenesisManifestFile {
+			return fmt.Errorf("invalid genesis shard file %q of module %s", shard.File, shard.Module)
+		}
+	}
+
+	return nil
+}
+
+// NodeGenesisFile returns the genesis document of the node with the given home
+// directory, as configured by genesis_file in config.toml.
+func NodeGenesisFile(homePath string, appOpts servertypes.AppOptions) string {
+	config := tmcfg.DefaultBaseConfig()
+	config.RootDir = homePath
+	if genFile := cast.ToString(appOpts.Get("genesis_file")); genFile != "" {
+		config.Genesis = genFile
+	}
+
+	return config.GenesisFile()
+}
+
+// GenesisShardsDir returns the shards directory of a genesis document.
+func GenesisShardsDir(genFile string) string {
+	return filepath.Join(filepath.Dir(genFile), GenesisShardsDirName)
+}
+
+// HasGenesisShards returns true if dir holds a manifest.
+func HasGenesisShards(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, GenesisManifestFile))
+	return err == nil
+}
+
+// WriteGenesisShard writes the genesis state of a module to dir.
+func WriteGenesisShard(dir, module string, bz json.RawMessage) (GenesisShard, error) {
+	shard := GenesisShard{
+		Module: module,
+		File:   module + ".json",
+		SHA256: checksum(bz),
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, shard.File), bz, 0644); err != nil {
+		return shard, errors.Wrapf(err, "failed to write %s genesis state", module)
+	}
+
+	return shard, nil
+}
+
+// WriteGenesisManifest writes the manifest of dir.
+func WriteGenesisManifest(dir string, manifest GenesisManifest) error {
+	bz, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal genesis manifest")
+	}
+
+	return errors.Wrap(ioutil.WriteFile(filepath.Join(dir, GenesisManifestFile), bz, 0644), "failed to write genesis manifest")
+}
+
+// WriteGenesisShards writes the genesis state of every module of appState,
+// and the manifest, to dir. The directory is created if needed.
+func WriteGenesisShards(dir string, appState map[string]json.RawMessage) (GenesisManifest, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return GenesisManifest{}, errors.Wrap(err, "failed to create genesis shards directory")
+	}
+
+	modules := make([]string, 0, len(appState))
+	for module := range appState {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	manifest := GenesisManifest{}
+	for _, module := range modules {
+		shard, err := WriteGenesisShard(dir, module, appState[module])
+		if err != nil {
+			return manifest, err
+		}
+
+		manifest.Modules = append(manifest.Modules, shard)
+	}
+
+	return manifest, WriteGenesisManifest(dir, manifest)
+}
+
+// ReadGenesisShards reads the genesis state of the modules listed by the
+// manifest of dir, failing if the checksum of a file does not match.
+func ReadGenesisShards(dir string) (map[string]json.RawMessage, error) {
+	bz, err := ioutil.ReadFile(filepath.Join(dir, GenesisManifestFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read genesis manifest")
+	}
+
+	var manifest GenesisManifest
+	if err := json.Unmarshal(bz, &manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal genesis manifest")
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid genesis manifest")
+	}
+
+	appState := make(map[string]json.RawMessage, len(manifest.Modules))
+	for _, shard := range manifest.Modules {
+		bz, err := ioutil.ReadFile(filepath.Join(dir, shard.File))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s genesis state", shard.Module)
+		}
+
+		if sum := checksum(bz); sum != shard.SHA256 {
+			return nil, fmt.Errorf("checksum %s of %s does not match the manifest checksum %s", sum, shard.File, shard.SHA256)
+		}
+
+		appState[shard.Module] = bz
+	}
+
+	return appState, nil
+}
+
+// GenesisStateFromGenFile reads the genesis document of genFile and its app
+// state, read from the shards directory if the document has none.
+func GenesisStateFromGenFile(genFile string) (map[string]json.RawMessage, *tmtypes.GenesisDoc, error) {
+	genDoc, err := tmtypes.GenesisDocFromFile(genFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appState, err := GenesisAppState(genDoc, GenesisShardsDir(genFile))
+	return appState, genDoc, err
+}
+
+// GenesisAppState returns the app state of the genesis document, or the app
+// state of the shards directory if the document has none.
+func GenesisAppState(genDoc *tmtypes.GenesisDoc, shardsDir string) (map[string]json.RawMessage, error) {
+	if len(genDoc.AppState) == 0 && HasGenesisShards(shardsDir) {
+		return ReadGenesisShards(shardsDir)
+	}
+
+	var appState map[string]json.RawMessage
+	if err := json.Unmarshal(genDoc.AppState, &appState); err != nil {
+		return nil, errors.Wrap(err, "failed to JSON unmarshal genesis state")
+	}
+
+	return appState, nil
+}
+
+func checksum(bz []byte) string {
+	sum := sha256.Sum256(bz)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,88 @@
+package gaia_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestGenesisShards(t *testing.T) {
+	appState := map[string]json.RawMessage{
+		"bank":    json.RawMessage(`{"balances":[]}`),
+		"staking": json.RawMessage(`{"validators":[]}`),
+	}
+
+	dir := filepath.Join(t.TempDir(), gaia.GenesisShardsDirName)
+	manifest, err := gaia.WriteGenesisShards(dir, appState)
+	require.NoError(t, err)
+	require.Len(t, manifest.Modules, 2)
+	require.Equal(t, "bank", manifest.Modules[0].Module)
+	require.Equal(t, "bank.json", manifest.Modules[0].File)
+	require.True(t, gaia.HasGenesisShards(dir))
+
+	readState, err := gaia.ReadGenesisShards(dir)
+	require.NoError(t, err)
+	require.Equal(t, appState, readState)
+
+	// a genesis document without app state is read from its shards
+	genFile := filepath.Join(filepath.Dir(dir), "genesis.json")
+	genDoc := &tmtypes.GenesisDoc{ChainID: "test"}
+	require.NoError(t, genDoc.SaveAs(genFile))
+	readState, _, err = gaia.GenesisStateFromGenFile(genFile)
+	require.NoError(t, err)
+	require.Equal(t, appState, readState)
+
+	// modified shards are rejected
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bank.json"), []byte(`{"balances":null}`), 0644))
+	_, err = gaia.ReadGenesisShards(dir)
+	require.Error(t, err)
+}
+
+func TestGenesisManifestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		manifest gaia.GenesisManifest
+		expErr   bool
+	}{
+		{"valid", gaia.GenesisManifest{Modules: []gaia.GenesisShard{{Module: "bank", File: "bank.json"}, {Module: "auth", File: "auth.json"}}}, false},
+		{"duplicate module", gaia.GenesisManifest{Modules: []gaia.GenesisShard{{Module: "bank", File: "bank.json"}, {Module: "bank", File: "bank2.json"}}}, true},
+		{"no module", gaia.GenesisManifest{Modules: []gaia.GenesisShard{{File: "bank.json"}}}, true},
+		{"file outside of directory", gaia.GenesisManifest{Modules: []gaia.GenesisShard{{Module: "bank", File: "../bank.json"}}}, true},
+		{"manifest file", gaia.GenesisManifest{Modules: []gaia.GenesisShard{{Module: "bank", File: gaia.GenesisManifestFile}}}, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.manifest.Validate()
+			if tc.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+type appOptions map[string]interface{}
+
+func (o appOptions) Get(key string) interface{} {
+	return o[key]
+}
+
+func TestNodeGenesisFile(t *testing.T) {
+	home := t.TempDir()
+
+	require.Equal(t, filepath.Join(home, "config", "genesis.json"), gaia.NodeGenesisFile(home, appOptions{}))
+	require.Equal(t, filepath.Join(home, "chain", "genesis.json"), gaia.NodeGenesisFile(home, appOptions{"genesis_file": "chain/genesis.json"}))
+	require.Equal(t, "/chain/genesis.json", gaia.NodeGenesisFile(home, appOptions{"genesis_file": "/chain/genesis.json"}))
+
+	// the shards are read next to the configured genesis document
+	require.Equal(t, filepath.Join(home, "chain", gaia.GenesisShardsDirName), gaia.GenesisShardsDir(gaia.NodeGenesisFile(home, appOptions{"genesis_file": "chain/genesis.json"})))
+}
@@ -0,0 +1,274 @@
+package ibcsim
+
+import (
+	"fmt"
+	"time"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmprotoversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	tmtypes "github.com/tendermint/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/version"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	clienttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/02-client/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	commitmenttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/23-commitment/types"
+	host "github.com/cosmos/cosmos-sdk/x/ibc/core/24-host"
+	ibctmtypes "github.com/cosmos/cosmos-sdk/x/ibc/light-clients/07-tendermint/types"
+)
+
+// The identifiers of the client, connection and transfer channel of the
+// simulated chain to the mock counterparty, and of their counterparts on the
+// counterparty.
+const (
+	counterpartyChainID = "counterparty-1"
+
+	clientID     = "07-tendermint-0"
+	connectionID = "connection-0"
+	channelID    = "channel-0"
+
+	counterpartyClientID     = "07-tendermint-0"
+	counterpartyConnectionID = "connection-0"
+	counterpartyPortID       = transfertypes.PortID
+	counterpartyChannelID    = "channel-0"
+)
+
+// The parameters of the client of the counterparty. The trusting period is
+// long enough for the client not to expire between two simulated IBC
+// operations, blocks being hours apart in simulations.
+const (
+	trustingPeriod  = 365 * 24 * time.Hour
+	unbondingPeriod = 3 * trustingPeriod / 2
+	maxClockDrift   = 10 * time.Second
+)
+
+// counterpartyDenoms are the native denoms of the counterparty.
+var counterpartyDenoms = []string{"uosmo", "ujuno", "uakt"}
+
+// successAck is the acknowledgement of a packet successfully received, as
+// written by the transfer module.
+var successAck = channeltypes.NewResultAcknowledgement([]byte{byte(1)})
+
+// sentPacket is a packet sent by the simulated chain to the counterparty.
+type sentPacket struct {
+	channeltypes.Packet
+	data transfertypes.FungibleTokenPacketData
+}
+
+// counterparty is a mock chain at the other end of the transfer channel of the
+// simulated chain. It has a single validator, whose key is fixed so that the
+// client of the simulated genesis trusts it, and an IBC store from which it
+// proves its packet commitments, receipts and acknowledgements. It does not
+// run the transfer module: it keeps the ledger of the tokens it escrowed and
+// of the vouchers it minted, which the invariants check the chain against.
+type counterparty struct {
+	store  *rootmulti.Store
+	key    sdk.StoreKey
+	signer tmtypes.PrivValidator
+	valSet *tmtypes.ValidatorSet
+
+	// escrow holds the native tokens of the counterparty sent to the chain.
+	escrow sdk.Coins
+	// vouchers holds the tokens of the chain received by the counterparty, by
+	// their denom on the chain.
+	vouchers sdk.Coins
+	// pending are the packets sent by the chain which are neither
+	// acknowledged nor timed out yet.
+	pending []sentPacket
+	// synced is whether the ledger was initialized from the chain.
+	synced bool
+}
+
+// newCounterparty returns the counterparty, with the next sequence of its
+// transfer channel in its IBC store.
+func newCounterparty() *counterparty {
+	key := sdk.NewKVStoreKey(host.StoreKey)
+	store := rootmulti.NewStore(dbm.NewMemDB())
+	store.MountStoreWithDB(key, sdk.StoreTypeIAVL, nil)
+	if err := store.LoadLatestVersion(); err != nil {
+		panic(err)
+	}
+
+	privKey := ed25519.GenPrivKeyFromSecret([]byte(counterpartyChainID))
+	c := &counterparty{
+		store:  store,
+		key:    key,
+		signer: tmtypes.NewMockPVWithParams(privKey, false, false),
+		valSet: tmtypes.NewValidatorSet([]*tmtypes.Validator{tmtypes.NewValidator(privKey.PubKey(), 1)}),
+	}
+	c.setNextSequenceSend(1)
+	c.store.Commit()
+
+	return c
+}
+
+// clientState returns the client state of the counterparty at the height.
+func (c *counterparty) clientState(height clienttypes.Height) *ibctmtypes.ClientState {
+	return ibctmtypes.NewClientState(
+		counterpartyChainID, ibctmtypes.DefaultTrustLevel, trustingPeriod, unbondingPeriod, maxClockDrift,
+		height, commitmenttypes.GetSDKSpecs(), []string{"upgrade", "upgradedIBCState"}, false, false,
+	)
+}
+
+// consensusState returns the consensus state of the counterparty at the
+// time, with the root of its last commit.
+func (c *counterparty) consensusState(timestamp time.Time) *ibctmtypes.ConsensusState {
+	return ibctmtypes.NewConsensusState(
+		timestamp, commitmenttypes.NewMerkleRoot(c.store.LastCommitID().Hash), c.valSet.Hash(),
+	)
+}
+
+func (c *counterparty) kvStore() sdk.KVStore {
+	return c.store.GetKVStore(c.key)
+}
+
+func (c *counterparty) nextSequenceSend() uint64 {
+	return sdk.BigEndianToUint64(c.kvStore().Get(host.NextSequenceSendKey(counterpartyPortID, counterpartyChannelID)))
+}
+
+func (c *counterparty) setNextSequenceSend(sequence uint64) {
+	c.kvStore().Set(host.NextSequenceSendKey(counterpartyPortID, counterpartyChannelID), sdk.Uint64ToBigEndian(sequence))
+}
+
+// sendPacket commits a packet of the data, with the next sequence of the
+// channel, to the port and channel of the chain, and escrows or burns the
+// tokens.
+func (c *counterparty) sendPacket(cdc codec.BinaryMarshaler, portID string, data transfertypes.FungibleTokenPacketData, timeoutTimestamp uint64) channeltypes.Packet {
+	sequence := c.nextSequenceSend()
+	packet := channeltypes.NewPacket(
+		data.GetBytes(), sequence, counterpartyPortID, counterpartyChannelID, portID, channelID,
+		clienttypes.ZeroHeight(), timeoutTimestamp,
+	)
+
+	c.kvStore().Set(host.PacketCommitmentKey(counterpartyPortID, counterpartyChannelID, sequence), channeltypes.CommitPacket(cdc, packet))
+	c.setNextSequenceSend(sequence + 1)
+
+	if transfertypes.SenderChainIsSource(counterpartyPortID, counterpartyChannelID, data.Denom) {
+		c.escrow = c.escrow.Add(sdk.NewCoin(data.Denom, sdk.NewIntFromUint64(data.Amount)))
+	} else {
+		denom := data.Denom[len(transfertypes.GetDenomPrefix(counterpartyPortID, counterpartyChannelID)):]
+		c.vouchers = c.vouchers.Sub(sdk.NewCoins(sdk.NewCoin(denom, sdk.NewIntFromUint64(data.Amount))))
+	}
+
+	return packet
+}
+
+// acknowledgePacket clears the commitment of a packet sent by the
+// counterparty, and refunds the tokens if the chain failed to receive it.
+func (c *counterparty) acknowledgePacket(packet channeltypes.Packet, data transfertypes.FungibleTokenPacketData, success bool) {
+	c.kvStore().Delete(host.PacketCommitmentKey(counterpartyPortID, counterpartyChannelID, packet.Sequence))
+	if success {
+		return
+	}
+
+	if transfertypes.SenderChainIsSource(counterpartyPortID, counterpartyChannelID, data.Denom) {
+		c.escrow = c.escrow.Sub(sdk.NewCoins(sdk.NewCoin(data.Denom, sdk.NewIntFromUint64(data.Amount))))
+	} else {
+		denom := data.Denom[len(transfertypes.GetDenomPrefix(counterpartyPortID, counterpartyChannelID)):]
+		c.vouchers = c.vouchers.Add(sdk.NewCoin(denom, sdk.NewIntFromUint64(data.Amount)))
+	}
+}
+
+// receivePacket writes the receipt and acknowledgement of a packet sent by
+// the chain and, if the acknowledgement is a success, unescrows the tokens or
+// mints vouchers for them.
+func (c *counterparty) receivePacket(packet sentPacket, ack channeltypes.Acknowledgement) {
+	c.kvStore().Set(host.PacketReceiptKey(counterpartyPortID, counterpartyChannelID, packet.Sequence), []byte{byte(1)})
+	c.kvStore().Set(
+		host.PacketAcknowledgementKey(counterpartyPortID, counterpartyChannelID, packet.Sequence),
+		channeltypes.CommitAcknowledgement(ack.GetBytes()),
+	)
+	if ack.GetError() != "" {
+		return
+	}
+
+	if transfertypes.ReceiverChainIsSource(packet.SourcePort, packet.SourceChannel, packet.data.Denom) {
+		denom := packet.data.Denom[len(transfertypes.GetDenomPrefix(packet.SourcePort, packet.SourceChannel)):]
+		c.escrow = c.escrow.Sub(sdk.NewCoins(sdk.NewCoin(denom, sdk.NewIntFromUint64(packet.data.Amount))))
+	} else {
+		c.vouchers = c.vouchers.Add(sdk.NewCoin(packet.data.Denom, sdk.NewIntFromUint64(packet.data.Amount)))
+	}
+}
+
+// removePending removes the pending packet i.
+func (c *counterparty) removePending(i int) {
+	c.pending = append(c.pending[:i], c.pending[i+1:]...)
+}
+
+// header commits the IBC store of the counterparty, and returns the header of
+// the height and time with its app hash, signed by the validator, for the
+// client to update from its trusted height.
+func (c *counterparty) header(height, trustedHeight clienttypes.Height, timestamp time.Time) (*ibctmtypes.Header, error) {
+	commitID := c.store.Commit()
+
+	tmHeader := tmtypes.Header{
+		Version:            tmprotoversion.Consensus{Block: tmversion.BlockProtocol, App: 2},
+		ChainID:            counterpartyChainID,
+		Height:             int64(height.RevisionHeight),
+		Time:               timestamp,
+		LastBlockID:        makeBlockID(make([]byte, tmhash.Size), 10_000, make([]byte, tmhash.Size)),
+		LastCommitHash:     tmhash.Sum([]byte("last_commit_hash")),
+		DataHash:           tmhash.Sum([]byte("data_hash")),
+		ValidatorsHash:     c.valSet.Hash(),
+		NextValidatorsHash: c.valSet.Hash(),
+		ConsensusHash:      tmhash.Sum([]byte("consensus_hash")),
+		AppHash:            commitID.Hash,
+		LastResultsHash:    tmhash.Sum([]byte("last_results_hash")),
+		EvidenceHash:       tmhash.Sum([]byte("evidence_hash")),
+		ProposerAddress:    c.valSet.Proposer.Address,
+	}
+
+	blockID := makeBlockID(tmHeader.Hash(), 3, tmhash.Sum([]byte("part_set")))
+	voteSet := tmtypes.NewVoteSet(counterpartyChainID, tmHeader.Height, 1, tmproto.PrecommitType, c.valSet)
+	commit, err := tmtypes.MakeCommit(blockID, tmHeader.Height, 1, voteSet, []tmtypes.PrivValidator{c.signer}, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	valSet, err := c.valSet.ToProto()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ibctmtypes.Header{
+		SignedHeader:      &tmproto.SignedHeader{Header: tmHeader.ToProto(), Commit: commit.ToProto()},
+		ValidatorSet:      valSet,
+		TrustedHeight:     trustedHeight,
+		TrustedValidators: valSet,
+	}, nil
+}
+
+// proof returns the proof of the value, or of the absence, of the key of the
+// IBC store at its last commit.
+func (c *counterparty) proof(cdc codec.BinaryMarshaler, key []byte) ([]byte, error) {
+	res := c.store.Query(abci.RequestQuery{
+		Path:   fmt.Sprintf("/%s/key", host.StoreKey),
+		Data:   key,
+		Height: c.store.LastCommitID().Version,
+		Prove:  true,
+	})
+	if res.Code != 0 {
+		return nil, fmt.Errorf("failed to query the proof of %s: %s", key, res.Log)
+	}
+
+	merkleProof, err := commitmenttypes.ConvertProofs(res.ProofOps)
+	if err != nil {
+		return nil, err
+	}
+
+	return cdc.MarshalBinaryBare(&merkleProof)
+}
+
+func makeBlockID(hash []byte, partSetSize uint32, partSetHash []byte) tmtypes.BlockID {
+	return tmtypes.BlockID{
+		Hash:          hash,
+		PartSetHeader: tmtypes.PartSetHeader{Total: partSetSize, Hash: partSetHash},
+	}
+}
@@ -0,0 +1,61 @@
+package ibcsim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	clienttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/02-client/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	commitmenttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/23-commitment/types"
+	host "github.com/cosmos/cosmos-sdk/x/ibc/core/24-host"
+)
+
+func TestCounterpartyHeaderAndProofs(t *testing.T) {
+	cdc := codec.NewProtoCodec(codectypes.NewInterfaceRegistry())
+	prefix := commitmenttypes.NewMerklePrefix([]byte(host.StoreKey))
+	cp := newCounterparty()
+
+	data := transfertypes.NewFungibleTokenPacketData("uosmo", 10, "sender", "receiver")
+	packet := cp.sendPacket(cdc, "port", data, 1)
+	require.Equal(t, uint64(1), packet.Sequence)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uosmo", 10)), cp.escrow)
+
+	header, err := cp.header(clienttypes.NewHeight(1, 2), counterpartyGenesisHeight, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, header.ValidateBasic())
+	root := commitmenttypes.NewMerkleRoot(header.Header.AppHash)
+
+	// the packet commitment is proven at the header
+	bz, err := cp.proof(cdc, host.PacketCommitmentKey(counterpartyPortID, counterpartyChannelID, packet.Sequence))
+	require.NoError(t, err)
+	var proof commitmenttypes.MerkleProof
+	require.NoError(t, cdc.UnmarshalBinaryBare(bz, &proof))
+	path, err := commitmenttypes.ApplyPrefix(prefix, commitmenttypes.NewMerklePath(
+		host.PacketCommitmentPath(counterpartyPortID, counterpartyChannelID, packet.Sequence),
+	))
+	require.NoError(t, err)
+	require.NoError(t, proof.VerifyMembership(
+		commitmenttypes.GetSDKSpecs(), root, path, channeltypes.CommitPacket(cdc, packet),
+	))
+
+	// so is the absence of a receipt
+	bz, err = cp.proof(cdc, host.PacketReceiptKey(counterpartyPortID, counterpartyChannelID, 1))
+	require.NoError(t, err)
+	proof = commitmenttypes.MerkleProof{}
+	require.NoError(t, cdc.UnmarshalBinaryBare(bz, &proof))
+	path, err = commitmenttypes.ApplyPrefix(prefix, commitmenttypes.NewMerklePath(
+		host.PacketReceiptPath(counterpartyPortID, counterpartyChannelID, 1),
+	))
+	require.NoError(t, err)
+	require.NoError(t, proof.VerifyNonMembership(commitmenttypes.GetSDKSpecs(), root, path))
+
+	// a failed receive refunds the escrowed tokens
+	cp.acknowledgePacket(packet, data, false)
+	require.True(t, cp.escrow.Empty())
+}
@@ -0,0 +1,91 @@
+package ibcsim
+
+import (
+	"github.com/cosmos/cosmos-sdk/types/module"
+	capabilitytypes "github.com/cosmos/cosmos-sdk/x/capability/types"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	clienttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/02-client/types"
+	connectiontypes "github.com/cosmos/cosmos-sdk/x/ibc/core/03-connection/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	commitmenttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/23-commitment/types"
+	host "github.com/cosmos/cosmos-sdk/x/ibc/core/24-host"
+	ibctypes "github.com/cosmos/cosmos-sdk/x/ibc/core/types"
+)
+
+// counterpartyGenesisHeight is the height of the counterparty trusted by its
+// client in the simulated genesis.
+var counterpartyGenesisHeight = clienttypes.NewHeight(clienttypes.ParseChainID(counterpartyChainID), 1)
+
+// RandomizedGenState adds to the simulated genesis the client of the mock
+// counterparty and an open connection and transfer channel to it, with the
+// channel capability owned by the IBC and transfer modules. The IBC, transfer
+// and capability genesis states must have been generated first.
+func RandomizedGenState(simState *module.SimulationState) {
+	var transferGenesis transfertypes.GenesisState
+	simState.Cdc.MustUnmarshalJSON(simState.GenState[transfertypes.ModuleName], &transferGenesis)
+	portID := transferGenesis.PortId
+
+	var ibcGenesis ibctypes.GenesisState
+	simState.Cdc.MustUnmarshalJSON(simState.GenState[host.ModuleName], &ibcGenesis)
+
+	cp := newCounterparty()
+
+	clientGenesis := &ibcGenesis.ClientGenesis
+	clientGenesis.Clients = append(clientGenesis.Clients, clienttypes.NewIdentifiedClientState(
+		clientID, cp.clientState(counterpartyGenesisHeight),
+	))
+	clientGenesis.ClientsConsensus = append(clientGenesis.ClientsConsensus, clienttypes.NewClientConsensusStates(
+		clientID, []clienttypes.ConsensusStateWithHeight{
+			clienttypes.NewConsensusStateWithHeight(counterpartyGenesisHeight, cp.consensusState(simState.GenTimestamp)),
+		},
+	))
+	clientGenesis.NextClientSequence = 1
+
+	connectionGenesis := &ibcGenesis.ConnectionGenesis
+	connectionGenesis.Connections = append(connectionGenesis.Connections, connectiontypes.NewIdentifiedConnection(
+		connectionID, connectiontypes.NewConnectionEnd(
+			connectiontypes.OPEN, clientID,
+			connectiontypes.NewCounterparty(
+				counterpartyClientID, counterpartyConnectionID, commitmenttypes.NewMerklePrefix([]byte(host.StoreKey)),
+			),
+			connectiontypes.ExportedVersionsToProto(connectiontypes.GetCompatibleVersions()), 0,
+		),
+	))
+	connectionGenesis.ClientConnectionPaths = append(connectionGenesis.ClientConnectionPaths,
+		connectiontypes.NewConnectionPaths(clientID, []string{connectionID}),
+	)
+	connectionGenesis.NextConnectionSequence = 1
+
+	channelGenesis := &ibcGenesis.ChannelGenesis
+	channelGenesis.Channels = append(channelGenesis.Channels, channeltypes.NewIdentifiedChannel(
+		portID, channelID, channeltypes.NewChannel(
+			channeltypes.OPEN, channeltypes.UNORDERED,
+			channeltypes.NewCounterparty(counterpartyPortID, counterpartyChannelID),
+			[]string{connectionID}, transfertypes.Version,
+		),
+	))
+	channelGenesis.SendSequences = append(channelGenesis.SendSequences, channeltypes.NewPacketSequence(portID, channelID, 1))
+	channelGenesis.RecvSequences = append(channelGenesis.RecvSequences, channeltypes.NewPacketSequence(portID, channelID, 1))
+	channelGenesis.AckSequences = append(channelGenesis.AckSequences, channeltypes.NewPacketSequence(portID, channelID, 1))
+	channelGenesis.NextChannelSequence = 1
+
+	simState.GenState[host.ModuleName] = simState.Cdc.MustMarshalJSON(&ibcGenesis)
+
+	var capabilityGenesis capabilitytypes.GenesisState
+	simState.Cdc.MustUnmarshalJSON(simState.GenState[capabilitytypes.ModuleName], &capabilityGenesis)
+
+	owners := capabilitytypes.NewCapabilityOwners()
+	capName := host.ChannelCapabilityPath(portID, channelID)
+	for _, name := range []string{host.ModuleName, transfertypes.ModuleName} {
+		if err := owners.Set(capabilitytypes.NewOwner(name, capName)); err != nil {
+			panic(err)
+		}
+	}
+	capabilityGenesis.Owners = append(capabilityGenesis.Owners, capabilitytypes.GenesisOwners{
+		Index:       capabilityGenesis.Index,
+		IndexOwners: *owners,
+	})
+	capabilityGenesis.Index++
+
+	simState.GenState[capabilitytypes.ModuleName] = simState.Cdc.MustMarshalJSON(&capabilityGenesis)
+}
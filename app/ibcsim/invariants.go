@@ -0,0 +1,97 @@
+package ibcsim
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	ibctransferkeeper "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/keeper"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+)
+
+var errUnexpectedPacket = errors.New("the commitment of the sent packet does not match the transfer")
+
+// EscrowInvariant checks that the escrow account of the transfer channel holds
+// the native tokens of the chain held as vouchers by the counterparty or in
+// flight to it.
+func EscrowInvariant(bk BankKeeper, tk ibctransferkeeper.Keeper, cp *counterparty) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		portID := tk.GetPort(ctx)
+		prefix := transfertypes.GetDenomPrefix(portID, channelID)
+
+		expected := cp.vouchers
+		for _, packet := range cp.pending {
+			if !strings.HasPrefix(packet.data.Denom, prefix) {
+				expected = expected.Add(sdk.NewCoin(packet.data.Denom, sdk.NewIntFromUint64(packet.data.Amount)))
+			}
+		}
+
+		escrowed := bk.GetAllBalances(ctx, transfertypes.GetEscrowAddress(portID, channelID))
+		diff, hasNeg := escrowed.SafeSub(expected)
+		broken := hasNeg || !diff.IsZero()
+
+		return sdk.FormatInvariant(transfertypes.ModuleName, "escrow", fmt.Sprintf(
+			"\tescrowed coins: %s\n\tcoins held by the counterparty or in flight: %s\n", escrowed, expected,
+		)), broken
+	}
+}
+
+// VoucherSupplyInvariant checks that every voucher in the supply has a denom
+// trace, and that the supply of the vouchers of the tokens of the
+// counterparty, with the ones burnt in flight back to it, matches the tokens
+// it escrowed.
+func VoucherSupplyInvariant(bk BankKeeper, tk ibctransferkeeper.Keeper, cp *counterparty) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		prefix := transfertypes.GetDenomPrefix(tk.GetPort(ctx), channelID)
+
+		var expected sdk.Coins
+		for _, coin := range cp.escrow {
+			expected = expected.Add(sdk.NewCoin(transfertypes.ParseDenomTrace(prefix+coin.Denom).IBCDenom(), coin.Amount))
+		}
+
+		var (
+			actual   sdk.Coins
+			untraced []string
+		)
+		for _, coin := range bk.GetSupply(ctx).GetTotal() {
+			if !strings.HasPrefix(coin.Denom, transfertypes.DenomPrefix+"/") {
+				continue
+			}
+			hash, err := transfertypes.ParseHexHash(strings.TrimPrefix(coin.Denom, transfertypes.DenomPrefix+"/"))
+			if err != nil || !tk.HasDenomTrace(ctx, hash) {
+				untraced = append(untraced, coin.Denom)
+			}
+			actual = actual.Add(coin)
+		}
+		for _, packet := range cp.pending {
+			if strings.HasPrefix(packet.data.Denom, prefix) {
+				denom := transfertypes.ParseDenomTrace(packet.data.Denom).IBCDenom()
+				actual = actual.Add(sdk.NewCoin(denom, sdk.NewIntFromUint64(packet.data.Amount)))
+			}
+		}
+
+		diff, hasNeg := actual.SafeSub(expected)
+		broken := hasNeg || !diff.IsZero() || len(untraced) > 0
+
+		return sdk.FormatInvariant(transfertypes.ModuleName, "voucher supply", fmt.Sprintf(
+			"\tvouchers in supply or in flight: %s\n\ttokens escrowed by the counterparty: %s\n\tvouchers without denom trace: %v\n",
+			actual, expected, untraced,
+		)), broken
+	}
+}
+
+// checkInvariants returns an error if the escrow or voucher supply invariant
+// is broken.
+func checkInvariants(ctx sdk.Context, bk BankKeeper, tk ibctransferkeeper.Keeper, cp *counterparty) error {
+	for _, invariant := range []sdk.Invariant{
+		EscrowInvariant(bk, tk, cp),
+		VoucherSupplyInvariant(bk, tk, cp),
+	} {
+		if msg, broken := invariant(ctx); broken {
+			return errors.New(msg)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,567 @@
+package ibcsim
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/simapp/helpers"
+	simappparams "github.com/cosmos/cosmos-sdk/simapp/params"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
+	ibctransferkeeper "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/keeper"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	clienttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/02-client/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	host "github.com/cosmos/cosmos-sdk/x/ibc/core/24-host"
+	ibckeeper "github.com/cosmos/cosmos-sdk/x/ibc/core/keeper"
+	ibctmtypes "github.com/cosmos/cosmos-sdk/x/ibc/light-clients/07-tendermint/types"
+	"github.com/cosmos/cosmos-sdk/x/simulation"
+
+	"github.com/cosmos/gaia/v4/app/params"
+)
+
+// Simulation operation weights constants
+const (
+	OpWeightMsgTransfer        = "op_weight_msg_transfer"
+	OpWeightMsgRecvPacket      = "op_weight_msg_recv_packet"
+	OpWeightMsgAcknowledgement = "op_weight_msg_acknowledgement"
+	OpWeightMsgTimeout         = "op_weight_msg_timeout"
+)
+
+// The bounds of the timeouts of the packets sent to the counterparty, after
+// the latest height of its client or the block time, and the timeout of the
+// packets it sends.
+const (
+	maxTimeoutHeightDelta = 50
+	minTimeoutDuration    = 10 * time.Minute
+	maxTimeoutDuration    = 24 * time.Hour
+
+	counterpartyTimeoutDuration = 24 * time.Hour
+)
+
+// The maximum amounts of the packets: packet amounts are uint64, and the
+// counterparty mints its own tokens out of thin air.
+var (
+	maxPacketAmount       = sdk.NewIntFromUint64(1 << 62)
+	maxCounterpartyAmount = sdk.NewInt(1_000_000_000_000)
+)
+
+var (
+	typeMsgRecvPacket      = channeltypes.MsgRecvPacket{}.Type()
+	typeMsgAcknowledgement = channeltypes.MsgAcknowledgement{}.Type()
+	typeMsgTimeout         = channeltypes.MsgTimeout{}.Type()
+)
+
+// WeightedOperations returns the operations transferring tokens between the
+// chain and the mock counterparty, with their respective weights.
+func WeightedOperations(
+	appParams simtypes.AppParams, cdc codec.JSONMarshaler, appCodec codec.Marshaler,
+	ak AccountKeeper, bk BankKeeper, tk ibctransferkeeper.Keeper, k *ibckeeper.Keeper,
+) simulation.WeightedOperations {
+	var weightMsgTransfer, weightMsgRecvPacket, weightMsgAcknowledgement, weightMsgTimeout int
+	appParams.GetOrGenerate(cdc, OpWeightMsgTransfer, &weightMsgTransfer, nil,
+		func(_ *rand.Rand) {
+			weightMsgTransfer = params.DefaultWeightMsgTransfer
+		},
+	)
+
+	appParams.GetOrGenerate(cdc, OpWeightMsgRecvPacket, &weightMsgRecvPacket, nil,
+		func(_ *rand.Rand) {
+			weightMsgRecvPacket = params.DefaultWeightMsgRecvPacket
+		},
+	)
+
+	appParams.GetOrGenerate(cdc, OpWeightMsgAcknowledgement, &weightMsgAcknowledgement, nil,
+		func(_ *rand.Rand) {
+			weightMsgAcknowledgement = params.DefaultWeightMsgAcknowledgement
+		},
+	)
+
+	appParams.GetOrGenerate(cdc, OpWeightMsgTimeout, &weightMsgTimeout, nil,
+		func(_ *rand.Rand) {
+			weightMsgTimeout = params.DefaultWeightMsgTimeout
+		},
+	)
+
+	cp := newCounterparty()
+
+	return simulation.WeightedOperations{
+		simulation.NewWeightedOperation(
+			weightMsgTransfer,
+			SimulateMsgTransfer(appCodec, ak, bk, tk, k, cp),
+		),
+		simulation.NewWeightedOperation(
+			weightMsgRecvPacket,
+			SimulateMsgRecvPacket(appCodec, ak, bk, tk, k, cp),
+		),
+		simulation.NewWeightedOperation(
+			weightMsgAcknowledgement,
+			SimulateMsgAcknowledgement(appCodec, ak, bk, tk, k, cp),
+		),
+		simulation.NewWeightedOperation(
+			weightMsgTimeout,
+			SimulateMsgTimeout(appCodec, ak, bk, tk, k, cp),
+		),
+	}
+}
+
+// SimulateMsgTransfer sends random spendable tokens of a random account to the
+// counterparty, with a random timeout height and/or timestamp.
+// nolint: interfacer
+func SimulateMsgTransfer(
+	cdc codec.Marshaler, ak AccountKeeper, bk BankKeeper, tk ibctransferkeeper.Keeper, k *ibckeeper.Keeper,
+	cp *counterparty,
+) simtypes.Operation {
+	return func(
+		r *rand.Rand, app *baseapp.BaseApp, ctx sdk.Context, accs []simtypes.Account, chainID string,
+	) (simtypes.OperationMsg, []simtypes.FutureOperation, error) {
+		state, skip := getChannelState(ctx, bk, tk, k, cp)
+		if skip != "" {
+			return simtypes.NoOpMsg(transfertypes.ModuleName, transfertypes.TypeMsgTransfer, skip), nil, nil
+		}
+
+		if !tk.GetSendEnabled(ctx) {
+			return simtypes.NoOpMsg(transfertypes.ModuleName, transfertypes.TypeMsgTransfer, "transfers are disabled"), nil, nil
+		}
+
+		simAccount, _ := simtypes.RandomAcc(r, accs)
+		receiver, _ := simtypes.RandomAcc(r, accs)
+
+		spendable := bk.SpendableCoins(ctx, simAccount.Address)
+		if spendable.Empty() {
+			return simtypes.NoOpMsg(transfertypes.ModuleName, transfertypes.TypeMsgTransfer, "no spendable coins"), nil, nil
+		}
+
+		coin := spendable[r.Intn(len(spendable))]
+		amount, err := simtypes.RandPositiveInt(r, sdk.MinInt(coin.Amount, maxPacketAmount))
+		if err != nil {
+			return simtypes.NoOpMsg(transfertypes.ModuleName, transfertypes.TypeMsgTransfer, "unable to generate amount"), nil, err
+		}
+		token := sdk.NewCoin(coin.Denom, amount)
+
+		fullDenomPath := coin.Denom
+		if strings.HasPrefix(coin.Denom, transfertypes.DenomPrefix+"/") {
+			hash, err := transfertypes.ParseHexHash(strings.TrimPrefix(coin.Denom, transfertypes.DenomPrefix+"/"))
+			if err != nil {
+				return simtypes.NoOpMsg(transfertypes.ModuleName, transfertypes.TypeMsgTransfer, "invalid voucher denom"), nil, err
+			}
+			trace, found := tk.GetDenomTrace(ctx, hash)
+			if !found {
+				return simtypes.NoOpMsg(transfertypes.ModuleName, transfertypes.TypeMsgTransfer, "voucher without denom trace"), nil, nil
+			}
+			fullDenomPath = trace.GetFullDenomPath()
+		}
+
+		timeoutHeight, timeoutTimestamp := randomTimeout(r, ctx, state.height)
+		sequence, _ := k.ChannelKeeper.GetNextSequenceSend(ctx, state.portID, channelID)
+
+		msg := transfertypes.NewMsgTransfer(
+			state.portID, channelID, token, simAccount.Address, receiver.Address.String(), timeoutHeight, timeoutTimestamp,
+		)
+
+		err = deliverTx(r, app, ctx, ak, bk, chainID, simAccount, []sdk.Msg{msg}, sdk.NewCoins(token))
+		if err != nil {
+			return simtypes.NoOpMsg(transfertypes.ModuleName, msg.Type(), "unable to deliver tx"), nil, err
+		}
+
+		data := transfertypes.NewFungibleTokenPacketData(fullDenomPath, amount.Uint64(), msg.Sender, msg.Receiver)
+		packet := channeltypes.NewPacket(
+			data.GetBytes(), sequence, state.portID, channelID,
+			state.channel.Counterparty.PortId, state.channel.Counterparty.ChannelId, timeoutHeight, timeoutTimestamp,
+		)
+		if !bytes.Equal(k.ChannelKeeper.GetPacketCommitment(ctx, state.portID, channelID, sequence), channeltypes.CommitPacket(cdc, packet)) {
+			return simtypes.NoOpMsg(transfertypes.ModuleName, msg.Type(), "unexpected packet"), nil, errUnexpectedPacket
+		}
+		cp.pending = append(cp.pending, sentPacket{Packet: packet, data: data})
+
+		if err := checkInvariants(ctx, bk, tk, cp); err != nil {
+			return simtypes.NoOpMsg(transfertypes.ModuleName, msg.Type(), "invariant broken"), nil, err
+		}
+
+		return simtypes.NewOperationMsg(msg, true, ""), nil, nil
+	}
+}
+
+// SimulateMsgRecvPacket has the counterparty send its own tokens, or vouchers
+// of the chain it holds, to a random account, and relays the packet.
+// nolint: interfacer
+func SimulateMsgRecvPacket(
+	cdc codec.Marshaler, ak AccountKeeper, bk BankKeeper, tk ibctransferkeeper.Keeper, k *ibckeeper.Keeper,
+	cp *counterparty,
+) simtypes.Operation {
+	return func(
+		r *rand.Rand, app *baseapp.BaseApp, ctx sdk.Context, accs []simtypes.Account, chainID string,
+	) (simtypes.OperationMsg, []simtypes.FutureOperation, error) {
+		state, skip := getChannelState(ctx, bk, tk, k, cp)
+		if skip != "" {
+			return simtypes.NoOpMsg(host.ModuleName, typeMsgRecvPacket, skip), nil, nil
+		}
+
+		relayer, _ := simtypes.RandomAcc(r, accs)
+		sender, _ := simtypes.RandomAcc(r, accs)
+		receiver, _ := simtypes.RandomAcc(r, accs)
+
+		var (
+			denom  string
+			amount sdk.Int
+			err    error
+		)
+		if len(cp.vouchers) > 0 && r.Intn(2) == 0 {
+			coin := cp.vouchers[r.Intn(len(cp.vouchers))]
+			denom = transfertypes.GetPrefixedDenom(counterpartyPortID, counterpartyChannelID, coin.Denom)
+			amount, err = simtypes.RandPositiveInt(r, coin.Amount)
+		} else {
+			denom = counterpartyDenoms[r.Intn(len(counterpartyDenoms))]
+			amount, err = simtypes.RandPositiveInt(r, maxCounterpartyAmount)
+		}
+		if err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, typeMsgRecvPacket, "unable to generate amount"), nil, err
+		}
+
+		data := transfertypes.NewFungibleTokenPacketData(
+			denom, amount.Uint64(), sender.Address.String(), receiver.Address.String(),
+		)
+		packet := cp.sendPacket(cdc, state.portID, data, uint64(ctx.BlockTime().Add(counterpartyTimeoutDuration).UnixNano()))
+
+		proofHeight := nextHeight(state.height)
+		update, proof, err := cp.relay(
+			cdc, proofHeight, state.height, state.nextTime,
+			host.PacketCommitmentKey(counterpartyPortID, counterpartyChannelID, packet.Sequence), relayer.Address,
+		)
+		if err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, typeMsgRecvPacket, "unable to relay packet"), nil, err
+		}
+
+		msg := channeltypes.NewMsgRecvPacket(packet, proof, proofHeight, relayer.Address)
+
+		err = deliverTx(r, app, ctx, ak, bk, chainID, relayer, []sdk.Msg{update, msg}, nil)
+		if err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, msg.Type(), "unable to deliver tx"), nil, err
+		}
+
+		ack, _ := k.ChannelKeeper.GetPacketAcknowledgement(ctx, state.portID, channelID, packet.Sequence)
+		cp.acknowledgePacket(packet, data, bytes.Equal(ack, channeltypes.CommitAcknowledgement(successAck.GetBytes())))
+
+		if err := checkInvariants(ctx, bk, tk, cp); err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, msg.Type(), "invariant broken"), nil, err
+		}
+
+		return channelOperationMsg(cdc, msg), nil, nil
+	}
+}
+
+// SimulateMsgAcknowledgement has the counterparty receive a random packet sent
+// by the chain, before its timeout, and relays the acknowledgement, which is
+// an error one time out of ten.
+// nolint: interfacer
+func SimulateMsgAcknowledgement(
+	cdc codec.Marshaler, ak AccountKeeper, bk BankKeeper, tk ibctransferkeeper.Keeper, k *ibckeeper.Keeper,
+	cp *counterparty,
+) simtypes.Operation {
+	return func(
+		r *rand.Rand, app *baseapp.BaseApp, ctx sdk.Context, accs []simtypes.Account, chainID string,
+	) (simtypes.OperationMsg, []simtypes.FutureOperation, error) {
+		state, skip := getChannelState(ctx, bk, tk, k, cp)
+		if skip != "" {
+			return simtypes.NoOpMsg(host.ModuleName, typeMsgAcknowledgement, skip), nil, nil
+		}
+
+		proofHeight := nextHeight(state.height)
+
+		var receivable []int
+		for i, packet := range cp.pending {
+			if !timedOut(packet.Packet, proofHeight, state.nextTime) {
+				receivable = append(receivable, i)
+			}
+		}
+		if len(receivable) == 0 {
+			return simtypes.NoOpMsg(host.ModuleName, typeMsgAcknowledgement, "no packet to acknowledge"), nil, nil
+		}
+
+		i := receivable[r.Intn(len(receivable))]
+		packet := cp.pending[i]
+		relayer, _ := simtypes.RandomAcc(r, accs)
+
+		ack := successAck
+		if r.Intn(10) == 0 {
+			ack = channeltypes.NewErrorAcknowledgement("counterparty failed to receive the packet")
+		}
+		cp.receivePacket(packet, ack)
+
+		update, proof, err := cp.relay(
+			cdc, proofHeight, state.height, state.nextTime,
+			host.PacketAcknowledgementKey(counterpartyPortID, counterpartyChannelID, packet.Sequence), relayer.Address,
+		)
+		if err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, typeMsgAcknowledgement, "unable to relay acknowledgement"), nil, err
+		}
+
+		msg := channeltypes.NewMsgAcknowledgement(packet.Packet, ack.GetBytes(), proof, proofHeight, relayer.Address)
+
+		err = deliverTx(r, app, ctx, ak, bk, chainID, relayer, []sdk.Msg{update, msg}, nil)
+		if err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, msg.Type(), "unable to deliver tx"), nil, err
+		}
+		cp.removePending(i)
+
+		if err := checkInvariants(ctx, bk, tk, cp); err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, msg.Type(), "invariant broken"), nil, err
+		}
+
+		return channelOperationMsg(cdc, msg), nil, nil
+	}
+}
+
+// SimulateMsgTimeout times out a random packet sent by the chain, whose
+// timeout timestamp has passed or which has a timeout height, the
+// counterparty then producing blocks up to that height without receiving it.
+// nolint: interfacer
+func SimulateMsgTimeout(
+	cdc codec.Marshaler, ak AccountKeeper, bk BankKeeper, tk ibctransferkeeper.Keeper, k *ibckeeper.Keeper,
+	cp *counterparty,
+) simtypes.Operation {
+	return func(
+		r *rand.Rand, app *baseapp.BaseApp, ctx sdk.Context, accs []simtypes.Account, chainID string,
+	) (simtypes.OperationMsg, []simtypes.FutureOperation, error) {
+		state, skip := getChannelState(ctx, bk, tk, k, cp)
+		if skip != "" {
+			return simtypes.NoOpMsg(host.ModuleName, typeMsgTimeout, skip), nil, nil
+		}
+
+		proofHeight := nextHeight(state.height)
+
+		var expirable []int
+		for i, packet := range cp.pending {
+			if !packet.TimeoutHeight.IsZero() || timedOut(packet.Packet, proofHeight, state.nextTime) {
+				expirable = append(expirable, i)
+			}
+		}
+		if len(expirable) == 0 {
+			return simtypes.NoOpMsg(host.ModuleName, typeMsgTimeout, "no packet to time out"), nil, nil
+		}
+
+		i := expirable[r.Intn(len(expirable))]
+		packet := cp.pending[i]
+		relayer, _ := simtypes.RandomAcc(r, accs)
+
+		if proofHeight.LT(packet.TimeoutHeight) {
+			proofHeight = packet.TimeoutHeight
+		}
+
+		update, proof, err := cp.relay(
+			cdc, proofHeight, state.height, state.nextTime,
+			host.PacketReceiptKey(counterpartyPortID, counterpartyChannelID, packet.Sequence), relayer.Address,
+		)
+		if err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, typeMsgTimeout, "unable to relay timeout"), nil, err
+		}
+
+		// the next receive sequence is only checked on ordered channels
+		msg := channeltypes.NewMsgTimeout(packet.Packet, 1, proof, proofHeight, relayer.Address)
+
+		err = deliverTx(r, app, ctx, ak, bk, chainID, relayer, []sdk.Msg{update, msg}, nil)
+		if err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, msg.Type(), "unable to deliver tx"), nil, err
+		}
+		cp.removePending(i)
+
+		if err := checkInvariants(ctx, bk, tk, cp); err != nil {
+			return simtypes.NoOpMsg(host.ModuleName, msg.Type(), "invariant broken"), nil, err
+		}
+
+		return channelOperationMsg(cdc, msg), nil, nil
+	}
+}
+
+// channelState is the transfer channel to the counterparty, with the latest
+// height of its client and the time of the next header of the counterparty.
+type channelState struct {
+	portID   string
+	channel  channeltypes.Channel
+	height   clienttypes.Height
+	nextTime time.Time
+}
+
+// getChannelState returns the state of the transfer channel to the
+// counterparty, or why no packet can be relayed over it. The counterparty is
+// synced with the chain the first time.
+func getChannelState(
+	ctx sdk.Context, bk BankKeeper, tk ibctransferkeeper.Keeper, k *ibckeeper.Keeper, cp *counterparty,
+) (channelState, string) {
+	portID := tk.GetPort(ctx)
+	channel, found := k.ChannelKeeper.GetChannel(ctx, portID, channelID)
+	if !found || channel.State != channeltypes.OPEN {
+		return channelState{}, "no open channel to the counterparty"
+	}
+
+	if !cp.synced {
+		syncCounterparty(ctx, bk, tk, k, cp, portID)
+	}
+
+	clientState, found := k.ClientKeeper.GetClientState(ctx, clientID)
+	if !found {
+		return channelState{}, "no client of the counterparty"
+	}
+	height := clientState.GetLatestHeight().(clienttypes.Height)
+
+	consensusState, found := k.ClientKeeper.GetClientConsensusState(ctx, clientID, height)
+	if !found {
+		return channelState{}, "no consensus state of the counterparty"
+	}
+	trustedTime := consensusState.(*ibctmtypes.ConsensusState).Timestamp
+	if !trustedTime.Add(trustingPeriod).After(ctx.BlockTime()) {
+		return channelState{}, "client of the counterparty expired"
+	}
+
+	// the headers of the counterparty must have increasing times
+	nextTime := ctx.BlockTime()
+	if !nextTime.After(trustedTime) {
+		nextTime = trustedTime.Add(time.Nanosecond)
+	}
+
+	return channelState{portID: portID, channel: channel, height: height, nextTime: nextTime}, ""
+}
+
+// syncCounterparty initializes the ledger and the next send sequence of the
+// counterparty from the state of the chain, which is not the simulated genesis
+// when the simulation starts from an exported state. The packets in flight in
+// that state are lost: the counterparty never relays them, and holds their
+// tokens.
+func syncCounterparty(
+	ctx sdk.Context, bk BankKeeper, tk ibctransferkeeper.Keeper, k *ibckeeper.Keeper, cp *counterparty, portID string,
+) {
+	prefix := transfertypes.GetDenomPrefix(portID, channelID)
+
+	cp.vouchers = bk.GetAllBalances(ctx, transfertypes.GetEscrowAddress(portID, channelID))
+	for _, coin := range bk.GetSupply(ctx).GetTotal() {
+		if !strings.HasPrefix(coin.Denom, transfertypes.DenomPrefix+"/") {
+			continue
+		}
+		hash, err := transfertypes.ParseHexHash(strings.TrimPrefix(coin.Denom, transfertypes.DenomPrefix+"/"))
+		if err != nil {
+			continue
+		}
+		trace, found := tk.GetDenomTrace(ctx, hash)
+		if !found || !strings.HasPrefix(trace.GetFullDenomPath(), prefix) {
+			continue
+		}
+		cp.escrow = cp.escrow.Add(sdk.NewCoin(strings.TrimPrefix(trace.GetFullDenomPath(), prefix), coin.Amount))
+	}
+
+	sequence := cp.nextSequenceSend()
+	for _, receipt := range k.ChannelKeeper.GetAllPacketReceipts(ctx) {
+		if receipt.PortId == portID && receipt.ChannelId == channelID && receipt.Sequence >= sequence {
+			sequence = receipt.Sequence + 1
+		}
+	}
+	cp.setNextSequenceSend(sequence)
+
+	cp.synced = true
+}
+
+// relay returns the message updating the client of the counterparty to a
+// header of the height and time, which commits the IBC store of the
+// counterparty, and the proof of the key at that height.
+func (c *counterparty) relay(
+	cdc codec.BinaryMarshaler, height, trustedHeight clienttypes.Height, timestamp time.Time, key []byte,
+	signer sdk.AccAddress,
+) (*clienttypes.MsgUpdateClient, []byte, error) {
+	header, err := c.header(height, trustedHeight, timestamp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	update, err := clienttypes.NewMsgUpdateClient(clientID, header, signer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proof, err := c.proof(cdc, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return update, proof, nil
+}
+
+// randomTimeout returns a random timeout height of the counterparty after the
+// latest height of its client and/or a random timeout timestamp after the
+// block time.
+func randomTimeout(r *rand.Rand, ctx sdk.Context, height clienttypes.Height) (clienttypes.Height, uint64) {
+	timeoutHeight := clienttypes.ZeroHeight()
+	var timeoutTimestamp uint64
+
+	kind := r.Intn(3)
+	if kind != 1 {
+		timeoutHeight = clienttypes.NewHeight(height.RevisionNumber, height.RevisionHeight+1+uint64(r.Intn(maxTimeoutHeightDelta)))
+	}
+	if kind != 0 {
+		timeout := minTimeoutDuration + time.Duration(r.Int63n(int64(maxTimeoutDuration-minTimeoutDuration)))
+		timeoutTimestamp = uint64(ctx.BlockTime().Add(timeout).UnixNano())
+	}
+
+	return timeoutHeight, timeoutTimestamp
+}
+
+// timedOut returns whether the packet timed out on the counterparty at the
+// height and time.
+func timedOut(packet channeltypes.Packet, height clienttypes.Height, timestamp time.Time) bool {
+	return (!packet.TimeoutHeight.IsZero() && height.GTE(packet.TimeoutHeight)) ||
+		(packet.TimeoutTimestamp != 0 && uint64(timestamp.UnixNano()) >= packet.TimeoutTimestamp)
+}
+
+func nextHeight(height clienttypes.Height) clienttypes.Height {
+	return clienttypes.NewHeight(height.RevisionNumber, height.RevisionHeight+1)
+}
+
+// deliverTx delivers a transaction of the msgs signed by the account, which
+// pays random fees out of its spendable coins but the spent ones.
+// nolint: interfacer
+func deliverTx(
+	r *rand.Rand, app *baseapp.BaseApp, ctx sdk.Context, ak AccountKeeper, bk BankKeeper, chainID string,
+	simAccount simtypes.Account, msgs []sdk.Msg, spent sdk.Coins,
+) error {
+	var (
+		fees sdk.Coins
+		err  error
+	)
+
+	account := ak.GetAccount(ctx, simAccount.Address)
+	spendable := bk.SpendableCoins(ctx, account.GetAddress())
+
+	coins, hasNeg := spendable.SafeSub(spent)
+	if !hasNeg {
+		fees, err = simtypes.RandomFees(r, ctx, coins)
+		if err != nil {
+			return err
+		}
+	}
+
+	txGen := simappparams.MakeTestEncodingConfig().TxConfig
+	tx, err := helpers.GenTx(
+		txGen,
+		msgs,
+		fees,
+		helpers.DefaultGenTxGas,
+		chainID,
+		[]uint64{account.GetAccountNumber()},
+		[]uint64{account.GetSequence()},
+		simAccount.PrivKey,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = app.Deliver(txGen.TxEncoder(), tx)
+	return err
+}
+
+// channelOperationMsg returns the operation message of a delivered channel
+// msg, whose sign bytes the IBC msgs do not support outside of proto JSON.
+func channelOperationMsg(cdc codec.JSONMarshaler, msg sdk.Msg) simtypes.OperationMsg {
+	return simtypes.NewOperationMsgBasic(msg.Route(), msg.Type(), "", true, cdc.MustMarshalJSON(msg))
+}
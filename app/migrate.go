@@ -1,28 +1,20 @@
 package gaia
 
-//This file implements a genesis migration from cosmoshub-3 to cosmoshub-4. It migrates state from the modules in cosmoshub-3.
+//This file implements the genesis migration command, e.g. from cosmoshub-3 to cosmoshub-4. The versions it migrates through are registered in migrations.go.
 //This file also implements setting an initial height from an upgrade.
 
 import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/flags"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/version"
-	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
-	captypes "github.com/cosmos/cosmos-sdk/x/capability/types"
-	evtypes "github.com/cosmos/cosmos-sdk/x/evidence/types"
-	"github.com/cosmos/cosmos-sdk/x/genutil/client/cli"
 	"github.com/cosmos/cosmos-sdk/x/genutil/types"
-	ibcxfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
-	host "github.com/cosmos/cosmos-sdk/x/ibc/core/24-host"
-	"github.com/cosmos/cosmos-sdk/x/ibc/core/exported"
-	ibccoretypes "github.com/cosmos/cosmos-sdk/x/ibc/core/types"
-	staking "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	tmjson "github.com/tendermint/tendermint/libs/json"
@@ -33,7 +25,14 @@ const (
 	flagGenesisTime     = "genesis-time"
 	flagInitialHeight   = "initial-height"
 	flagReplacementKeys = "replacement-cons-keys"
+	flagFromVersion     = "from"
+	flagToVersion       = "to"
 	flagNoProp29        = "no-prop-29"
+	flagProp29Recovery  = "prop-29-recoveries"
+	flagProp29AuditLog  = "prop-29-audit-log"
+	flagDenomMetadata   = "denom-metadata"
+	flagReport          = "report"
+	flagDryRun          = "dry-run"
 )
 
 // MigrateGenesisCmd returns a command to execute genesis state migration.
@@ -43,28 +42,38 @@ func MigrateGenesisCmd() *cobra.Command {
 		Short: "Migrate genesis to a specified target version",
 		Long: fmt.Sprintf(`Migrate the source genesis into the target version and print to STDOUT.
 
+The genesis is migrated through every version between --from and --to, running the
+SDK migration of each version followed by its Gaia post-migration steps. Supported
+versions: %s.
+
 Example:
 $ %s migrate /path/to/genesis.json --chain-id=cosmoshub-4 --genesis-time=2019-04-22T17:00:00Z --initial-height=5000
-`, version.AppName),
+`, strings.Join(genesisVersionNames(), ", "), version.AppName),
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			clientCtx := client.GetClientContextFromCmd(cmd)
 
 			var err error
 
-			firstMigration := "v0.38"
 			importGenesis := args[0]
 
+			from, _ := cmd.Flags().GetString(flagFromVersion)
+			to, _ := cmd.Flags().GetString(flagToVersion)
+			migrationPath, err := GetMigrationPath(from, to)
+			if err != nil {
+				return err
+			}
+
 			jsonBlob, err := ioutil.ReadFile(importGenesis)
 
 			if err != nil {
 				return errors.Wrap(err, "failed to read provided genesis file")
 			}
 
-			jsonBlob, err = migrateTendermintGenesis(jsonBlob)
+			jsonBlob, err = MigrateTendermint(jsonBlob, migrationPath)
 
 			if err != nil {
-				return errors.Wrap(err, "failed to migration from 0.32 Tendermint params to 0.34 parms")
+				return err
 			}
 
 			genDoc, err := tmtypes.GenesisDocFromJSON(jsonBlob)
@@ -72,77 +81,55 @@ $ %s migrate /path/to/genesis.json --chain-id=cosmoshub-4 --genesis-time=2019-04
 				return errors.Wrapf(err, "failed to read genesis document from file %s", importGenesis)
 			}
 
-			var initialState types.AppMap
-			if err := json.Unmarshal(genDoc.AppState, &initialState); err != nil {
-				return errors.Wrap(err, "failed to JSON unmarshal initial genesis state")
+			// the app state of a sharded genesis is read from its shards
+			initialState, err := GenesisAppState(genDoc, GenesisShardsDir(importGenesis))
+			if err != nil {
+				return errors.Wrap(err, "failed to read initial genesis state")
 			}
 
-			migrationFunc := cli.GetMigrationCallback(firstMigration)
-			if migrationFunc == nil {
-				return fmt.Errorf("unknown migration function for version: %s", firstMigration)
+			opts := MigrationOptions{
+				ClientCtx: clientCtx,
+				AuditOut:  cmd.ErrOrStderr(),
 			}
-
-			// TODO: handler error from migrationFunc call
-			newGenState := migrationFunc(initialState, clientCtx)
-
-			secondMigration := "v0.39"
-
-			migrationFunc = cli.GetMigrationCallback(secondMigration)
-			if migrationFunc == nil {
-				return fmt.Errorf("unknown migration function for version: %s", secondMigration)
+			opts.DenomMetadata, _ = cmd.Flags().GetString(flagDenomMetadata)
+			opts.NoProp29, _ = cmd.Flags().GetBool(flagNoProp29)
+			opts.Prop29Recoveries, _ = cmd.Flags().GetString(flagProp29Recovery)
+			opts.Prop29AuditLog, _ = cmd.Flags().GetString(flagProp29AuditLog)
+			opts.DryRun, _ = cmd.Flags().GetBool(flagDryRun)
+
+			report, _ := cmd.Flags().GetBool(flagReport)
+
+			// the migrations may modify the initial state in place
+			stateBefore := make(types.AppMap, len(initialState))
+			for module, bz := range initialState {
+				stateBefore[module] = bz
 			}
 
-			// TODO: handler error from migrationFunc call
-			newGenState = migrationFunc(newGenState, clientCtx)
-
-			thirdMigration := "v0.40"
-
-			migrationFunc = cli.GetMigrationCallback(thirdMigration)
-			if migrationFunc == nil {
-				return fmt.Errorf("unknown migration function for version: %s", thirdMigration)
+			newGenState, err := MigrateAppState(initialState, migrationPath, opts)
+			if err != nil {
+				return err
 			}
 
-			// TODO: handler error from migrationFunc call
-			newGenState = migrationFunc(newGenState, clientCtx)
+			if report || opts.DryRun {
+				migrationReport, err := NewMigrationReport(stateBefore, newGenState)
+				if err != nil {
+					return errors.Wrap(err, "failed to build migration report")
+				}
 
-			var bankGenesis bank.GenesisState
+				reportOut := cmd.ErrOrStderr()
+				if opts.DryRun {
+					reportOut = cmd.OutOrStdout()
+				}
+				fmt.Fprint(reportOut, migrationReport)
 
-			clientCtx.JSONMarshaler.MustUnmarshalJSON(newGenState[bank.ModuleName], &bankGenesis)
+				if err := migrationReport.Validate(); err != nil {
+					return err
+				}
 
-			bankGenesis.DenomMetadata = []bank.Metadata{
-				{
-					Description: "The native staking token of the Cosmos Hub.",
-					DenomUnits: []*bank.DenomUnit{
-						{Denom: "uatom", Exponent: uint32(0), Aliases: []string{"microatom"}},
-						{Denom: "matom", Exponent: uint32(3), Aliases: []string{"milliatom"}},
-						{Denom: "atom", Exponent: uint32(6), Aliases: []string{}},
-					},
-					Base:    "uatom",
-					Display: "atom",
-				},
+				if opts.DryRun {
+					return nil
+				}
 			}
-			newGenState[bank.ModuleName] = clientCtx.JSONMarshaler.MustMarshalJSON(&bankGenesis)
-
-			var stakingGenesis staking.GenesisState
-
-			clientCtx.JSONMarshaler.MustUnmarshalJSON(newGenState[staking.ModuleName], &stakingGenesis)
-
-			ibcTransferGenesis := ibcxfertypes.DefaultGenesisState()
-			ibcCoreGenesis := ibccoretypes.DefaultGenesisState()
-			capGenesis := captypes.DefaultGenesis()
-			evGenesis := evtypes.DefaultGenesisState()
-
-			ibcTransferGenesis.Params.ReceiveEnabled = false
-			ibcTransferGenesis.Params.SendEnabled = false
-
-			ibcCoreGenesis.ClientGenesis.Params.AllowedClients = []string{exported.Tendermint}
-			stakingGenesis.Params.HistoricalEntries = 10000
-
-			newGenState[ibcxfertypes.ModuleName] = clientCtx.JSONMarshaler.MustMarshalJSON(ibcTransferGenesis)
-			newGenState[host.ModuleName] = clientCtx.JSONMarshaler.MustMarshalJSON(ibcCoreGenesis)
-			newGenState[captypes.ModuleName] = clientCtx.JSONMarshaler.MustMarshalJSON(capGenesis)
-			newGenState[evtypes.ModuleName] = clientCtx.JSONMarshaler.MustMarshalJSON(evGenesis)
-			newGenState[staking.ModuleName] = clientCtx.JSONMarshaler.MustMarshalJSON(&stakingGenesis)
 
 			genDoc.AppState, err = json.Marshal(newGenState)
 			if err != nil {
@@ -173,7 +160,19 @@ $ %s migrate /path/to/genesis.json --chain-id=cosmoshub-4 --genesis-time=2019-04
 			replacementKeys, _ := cmd.Flags().GetString(flagReplacementKeys)
 
 			if replacementKeys != "" {
-				genDoc = loadKeydataFromFile(clientCtx, replacementKeys, genDoc)
+				replacements, err := ReadConsKeyReplacements(replacementKeys)
+				if err != nil {
+					return err
+				}
+
+				changes, err := ReplaceConsensusKeys(clientCtx.JSONMarshaler, genDoc, replacements)
+				if err != nil {
+					return errors.Wrap(err, "failed to replace consensus keys")
+				}
+
+				if err := PrintConsKeyChanges(cmd.ErrOrStderr(), changes); err != nil {
+					return err
+				}
 			}
 
 			bz, err := tmjson.Marshal(genDoc)
@@ -191,15 +190,55 @@ $ %s migrate /path/to/genesis.json --chain-id=cosmoshub-4 --genesis-time=2019-04
 		},
 	}
 
+	cmd.Flags().String(flagFromVersion, DefaultMigrationSource, "Genesis version to migrate from")
+	cmd.Flags().String(flagToVersion, DefaultMigrationTarget, "Genesis version to migrate to")
 	cmd.Flags().String(flagGenesisTime, "", "override genesis_time with this flag")
 	cmd.Flags().Int(flagInitialHeight, 0, "Set the starting height for the chain")
 	cmd.Flags().String(flagReplacementKeys, "", "Proviide a JSON file to replace the consensus keys of validators")
 	cmd.Flags().String(flags.FlagChainID, "", "override chain_id with this flag")
+	cmd.Flags().String(flagDenomMetadata, "", "JSON file of the bank denom metadata; defaults to the metadata of uatom")
 	cmd.Flags().Bool(flagNoProp29, false, "Do not implement fund recovery from prop29")
+	cmd.Flags().String(flagProp29Recovery, "", "JSON file listing the prop29 fund recoveries to apply; required unless --no-prop-29 is given")
+	cmd.Flags().String(flagProp29AuditLog, "", "Write the prop29 audit log to this file instead of STDERR; ignored with --dry-run")
+	cmd.Flags().Bool(flagReport, false, "Print a report of the changes to STDERR and fail if the total supply or bonded tokens changed")
+	cmd.Flags().Bool(flagDryRun, false, "Only print the report of the changes to STDOUT, without the migrated genesis")
 
 	return cmd
 }
 
+// migrateProp29 applies the prop29 fund recoveries of the recoveries file to
+// the migrated genesis state and writes the audit log.
+func migrateProp29(genState types.AppMap, opts MigrationOptions) error {
+	if opts.NoProp29 {
+		return nil
+	}
+
+	if opts.Prop29Recoveries == "" {
+		return fmt.Errorf("the prop29 recoveries are not shipped with %s: give them with --%s, or skip the recovery with --%s", version.AppName, flagProp29Recovery, flagNoProp29)
+	}
+	recoveries, err := ReadProp29Recoveries(opts.Prop29Recoveries)
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := Prop29Migration(opts.ClientCtx.JSONMarshaler, genState, recoveries)
+	if err != nil {
+		return err
+	}
+
+	bz, err := json.MarshalIndent(auditLog, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal prop29 audit log")
+	}
+
+	if opts.Prop29AuditLog == "" || opts.DryRun {
+		_, err = fmt.Fprintln(opts.AuditOut, string(bz))
+		return err
+	}
+
+	return ioutil.WriteFile(opts.Prop29AuditLog, bz, 0600)
+}
+
 // MigrateTendermintGenesis makes sure a later version of Tendermint can parse
 // a JSON blob exported by an older version of Tendermint.
 func migrateTendermintGenesis(jsonBlob []byte) ([]byte, error) {
@@ -0,0 +1,377 @@
+package gaia
+
+// This file implements the change report of the migrate command. The report
+// compares the app state before and after the migration using plain JSON, as
+// the module genesis formats differ between versions.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/genutil/types"
+	"github.com/pkg/errors"
+)
+
+// ParamChange is a module parameter added, removed or changed by a migration.
+// Before is empty for added parameters and After is empty for removed ones.
+type ParamChange struct {
+	Module string
+	Key    string
+	Before string
+	After  string
+}
+
+// MigrationReport summarizes the changes of a genesis migration.
+type MigrationReport struct {
+	// AddedModules are the modules without genesis state before the
+	// migration, i.e. initialized by it.
+	AddedModules []string
+	// RemovedModules are the modules without genesis state after the
+	// migration, usually merged into another module.
+	RemovedModules []string
+	// ChangedModules are the modules whose genesis state changed.
+	ChangedModules []string
+
+	// AccountsBefore and AccountsAfter count the accounts per type.
+	AccountsBefore map[string]int
+	AccountsAfter  map[string]int
+
+	ParamChanges []ParamChange
+
+	SupplyBefore sdk.Coins
+	SupplyAfter  sdk.Coins
+	BondedBefore sdk.Int
+	BondedAfter  sdk.Int
+}
+
+// NewMigrationReport compares the app state before and after a migration.
+func NewMigrationReport(before, after types.AppMap) (MigrationReport, error) {
+	report := MigrationReport{}
+
+	for module, bz := range after {
+		beforeBz, ok := before[module]
+		switch {
+		case !ok:
+			report.AddedModules = append(report.AddedModules, module)
+		case !jsonEqual(beforeBz, bz):
+			report.ChangedModules = append(report.ChangedModules, module)
+		}
+	}
+	for module := range before {
+		if _, ok := after[module]; !ok {
+			report.RemovedModules = append(report.RemovedModules, module)
+		}
+	}
+	sort.Strings(report.AddedModules)
+	sort.Strings(report.RemovedModules)
+	sort.Strings(report.ChangedModules)
+
+	var err error
+	if report.AccountsBefore, err = countAccounts(before); err != nil {
+		return report, errors.Wrap(err, "failed to count accounts before migration")
+	}
+	if report.AccountsAfter, err = countAccounts(after); err != nil {
+		return report, errors.Wrap(err, "failed to count accounts after migration")
+	}
+
+	report.ParamChanges = diffParams(before, after)
+
+	if report.SupplyBefore, err = totalSupply(before); err != nil {
+		return report, errors.Wrap(err, "failed to read supply before migration")
+	}
+	if report.SupplyAfter, err = totalSupply(after); err != nil {
+		return report, errors.Wrap(err, "failed to read supply after migration")
+	}
+	if report.BondedBefore, err = bondedTokens(before); err != nil {
+		return report, errors.Wrap(err, "failed to read bonded tokens before migration")
+	}
+	if report.BondedAfter, err = bondedTokens(after); err != nil {
+		return report, errors.Wrap(err, "failed to read bonded tokens after migration")
+	}
+
+	return report, nil
+}
+
+// Validate fails if the migration changed the total supply or the total
+// amount of bonded tokens, none of the Gaia migrations being expected to.
+func (r MigrationReport) Validate() error {
+	if r.SupplyBefore.String() != r.SupplyAfter.String() {
+		return fmt.Errorf("migration changed the total supply from %s to %s", r.SupplyBefore, r.SupplyAfter)
+	}
+	if !r.BondedBefore.Equal(r.BondedAfter) {
+		return fmt.Errorf("migration changed the bonded tokens from %s to %s", r.BondedBefore, r.BondedAfter)
+	}
+
+	return nil
+}
+
+// String returns a human-readable report.
+func (r MigrationReport) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintln(&sb, "MODULES")
+	fmt.Fprintf(&sb, "  initialized: %s\n", joinOrNone(r.AddedModules))
+	fmt.Fprintf(&sb, "  removed:     %s\n", joinOrNone(r.RemovedModules))
+	fmt.Fprintf(&sb, "  changed:     %s\n", joinOrNone(r.ChangedModules))
+
+	fmt.Fprintln(&sb, "\nACCOUNTS")
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  TYPE\tBEFORE\tAFTER")
+	for _, accType := range sortedKeys(r.AccountsBefore, r.AccountsAfter) {
+		fmt.Fprintf(tw, "  %s\t%d\t%d\n", accType, r.AccountsBefore[accType], r.AccountsAfter[accType])
+	}
+	tw.Flush()
+
+	fmt.Fprintln(&sb, "\nPARAMS")
+	if len(r.ParamChanges) == 0 {
+		fmt.Fprintln(&sb, "  none")
+	}
+	tw = tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	for _, c := range r.ParamChanges {
+		switch {
+		case c.Before == "":
+			fmt.Fprintf(tw, "  + %s.%s\t%s\n", c.Module, c.Key, c.After)
+		case c.After == "":
+			fmt.Fprintf(tw, "  - %s.%s\t%s\n", c.Module, c.Key, c.Before)
+		default:
+			fmt.Fprintf(tw, "  ~ %s.%s\t%s -> %s\n", c.Module, c.Key, c.Before, c.After)
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintln(&sb, "\nTOTALS")
+	fmt.Fprintf(&sb, "  supply before: %s\n", r.SupplyBefore)
+	fmt.Fprintf(&sb, "  supply after:  %s\n", r.SupplyAfter)
+	fmt.Fprintf(&sb, "  bonded before: %s\n", r.BondedBefore)
+	fmt.Fprintf(&sb, "  bonded after:  %s\n", r.BondedAfter)
+
+	return sb.String()
+}
+
+func joinOrNone(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+
+	return strings.Join(list, ", ")
+}
+
+func sortedKeys(maps ...map[string]int) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return string(a) == string(b)
+	}
+
+	na, _ := json.Marshal(va)
+	nb, _ := json.Marshal(vb)
+	return string(na) == string(nb)
+}
+
+// moduleJSON decodes the genesis state of a module into generic JSON. It
+// returns nil if the module has no genesis state.
+func moduleJSON(genState types.AppMap, module string) (map[string]interface{}, error) {
+	bz, ok := genState[module]
+	if !ok || string(bz) == "null" {
+		return nil, nil
+	}
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(bz, &v); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s genesis state", module)
+	}
+
+	return v, nil
+}
+
+// countAccounts counts the accounts of the auth genesis state per type. The
+// accounts of v0.36 genesis files, kept by the genaccounts module, are
+// classified by their vesting fields.
+func countAccounts(genState types.AppMap) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	auth, err := moduleJSON(genState, "auth")
+	if err != nil {
+		return nil, err
+	}
+	if accounts, ok := auth["accounts"].([]interface{}); ok {
+		for _, acc := range accounts {
+			obj, _ := acc.(map[string]interface{})
+			switch {
+			case obj["@type"] != nil:
+				counts[strings.TrimPrefix(fmt.Sprint(obj["@type"]), "/")]++
+			case obj["type"] != nil:
+				counts[fmt.Sprint(obj["type"])]++
+			default:
+				counts["unknown"]++
+			}
+		}
+	}
+
+	if bz, ok := genState["accounts"]; ok {
+		var accounts []map[string]interface{}
+		if err := json.Unmarshal(bz, &accounts); err != nil {
+			return nil, errors.Wrap(err, "failed to decode accounts genesis state")
+		}
+
+		for _, acc := range accounts {
+			vesting, _ := acc["original_vesting"].([]interface{})
+			switch {
+			case acc["module_name"] != nil && acc["module_name"] != "":
+				counts["genaccounts/module"]++
+			case len(vesting) > 0 && acc["start_time"] != nil && fmt.Sprint(acc["start_time"]) != "0":
+				counts["genaccounts/continuous_vesting"]++
+			case len(vesting) > 0:
+				counts["genaccounts/delayed_vesting"]++
+			default:
+				counts["genaccounts/base"]++
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+// diffParams compares the params of every module.
+func diffParams(before, after types.AppMap) []ParamChange {
+	var modules []string
+	for module := range before {
+		modules = append(modules, module)
+	}
+	for module := range after {
+		if _, ok := before[module]; !ok {
+			modules = append(modules, module)
+		}
+	}
+	sort.Strings(modules)
+
+	var changes []ParamChange
+	for _, module := range modules {
+		beforeParams := moduleParams(before, module)
+		afterParams := moduleParams(after, module)
+
+		keys := make(map[string]int)
+		for k := range beforeParams {
+			keys[k] = 0
+		}
+		for k := range afterParams {
+			keys[k] = 0
+		}
+
+		for _, key := range sortedKeys(keys) {
+			if beforeParams[key] != afterParams[key] {
+				changes = append(changes, ParamChange{Module: module, Key: key, Before: beforeParams[key], After: afterParams[key]})
+			}
+		}
+	}
+
+	return changes
+}
+
+// moduleParams returns the flattened params of a module genesis state.
+// Modules whose genesis state is not a JSON object have no params.
+func moduleParams(genState types.AppMap, module string) map[string]string {
+	params := make(map[string]string)
+
+	v, _ := moduleJSON(genState, module)
+	flattenJSON("", v["params"], params)
+
+	return params
+}
+
+func flattenJSON(prefix string, v interface{}, out map[string]string) {
+	if obj, ok := v.(map[string]interface{}); ok {
+		for k, child := range obj {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenJSON(key, child, out)
+		}
+		return
+	}
+
+	if v == nil || prefix == "" {
+		return
+	}
+
+	bz, _ := json.Marshal(v)
+	out[prefix] = string(bz)
+}
+
+// totalSupply returns the total supply, kept by the supply module before v0.40
+// and by the bank module since.
+func totalSupply(genState types.AppMap) (sdk.Coins, error) {
+	for _, module := range []string{"bank", "supply"} {
+		v, err := moduleJSON(genState, module)
+		if err != nil {
+			return nil, err
+		}
+
+		supply, ok := v["supply"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		coins := sdk.Coins{}
+		for _, c := range supply {
+			obj, _ := c.(map[string]interface{})
+			amount, ok := sdk.NewIntFromString(fmt.Sprint(obj["amount"]))
+			if !ok {
+				return nil, fmt.Errorf("invalid %s supply amount %v", module, obj["amount"])
+			}
+			coins = append(coins, sdk.Coin{Denom: fmt.Sprint(obj["denom"]), Amount: amount})
+		}
+
+		return coins.Sort(), nil
+	}
+
+	return sdk.Coins{}, nil
+}
+
+// bondedTokens returns the tokens of the bonded validators.
+func bondedTokens(genState types.AppMap) (sdk.Int, error) {
+	total := sdk.ZeroInt()
+
+	v, err := moduleJSON(genState, "staking")
+	if err != nil {
+		return total, err
+	}
+
+	validators, _ := v["validators"].([]interface{})
+	for _, val := range validators {
+		obj, _ := val.(map[string]interface{})
+		switch fmt.Sprint(obj["status"]) {
+		case "2", "Bonded", "BOND_STATUS_BONDED":
+		default:
+			continue
+		}
+
+		tokens, ok := sdk.NewIntFromString(fmt.Sprint(obj["tokens"]))
+		if !ok {
+			return total, fmt.Errorf("invalid validator tokens %v", obj["tokens"])
+		}
+		total = total.Add(tokens)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,80 @@
+package gaia_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/genutil/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestMigrationReport(t *testing.T) {
+	before := types.AppMap{
+		"accounts": json.RawMessage(`[
+			{"address": "a", "original_vesting": []},
+			{"address": "b", "original_vesting": [{"denom": "uatom", "amount": "10"}], "start_time": "0", "end_time": "10"},
+			{"address": "c", "module_name": "bonded_tokens_pool"}
+		]`),
+		"supply": json.RawMessage(`{"supply": [{"denom": "uatom", "amount": "100"}]}`),
+		"staking": json.RawMessage(`{
+			"params": {"unbonding_time": "1814400000000000", "max_validators": 125},
+			"validators": [{"status": 2, "tokens": "50"}, {"status": 0, "tokens": "20"}]
+		}`),
+		"mint": json.RawMessage(`{"params": {"mint_denom": "uatom"}}`),
+	}
+	after := types.AppMap{
+		"auth": json.RawMessage(`{"accounts": [
+			{"@type": "/cosmos.auth.v1beta1.BaseAccount"},
+			{"@type": "/cosmos.vesting.v1beta1.DelayedVestingAccount"},
+			{"@type": "/cosmos.auth.v1beta1.ModuleAccount"}
+		]}`),
+		"bank": json.RawMessage(`{"supply": [{"denom": "uatom", "amount": "100"}]}`),
+		"staking": json.RawMessage(`{
+			"params": {"unbonding_time": "1814400s", "max_validators": 125, "historical_entries": 10000},
+			"validators": [{"status": "BOND_STATUS_BONDED", "tokens": "50"}, {"status": "BOND_STATUS_UNBONDED", "tokens": "20"}]
+		}`),
+		"mint":     json.RawMessage(`{ "params": { "mint_denom": "uatom" } }`),
+		"evidence": json.RawMessage(`{"evidence": []}`),
+	}
+
+	report, err := gaia.NewMigrationReport(before, after)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"auth", "bank", "evidence"}, report.AddedModules)
+	require.Equal(t, []string{"accounts", "supply"}, report.RemovedModules)
+	require.Equal(t, []string{"staking"}, report.ChangedModules)
+	require.Equal(t, map[string]int{
+		"genaccounts/base":            1,
+		"genaccounts/delayed_vesting": 1,
+		"genaccounts/module":          1,
+	}, report.AccountsBefore)
+	require.Equal(t, map[string]int{
+		"cosmos.auth.v1beta1.BaseAccount":              1,
+		"cosmos.vesting.v1beta1.DelayedVestingAccount": 1,
+		"cosmos.auth.v1beta1.ModuleAccount":            1,
+	}, report.AccountsAfter)
+	require.Equal(t, []gaia.ParamChange{
+		{Module: "staking", Key: "historical_entries", After: "10000"},
+		{Module: "staking", Key: "unbonding_time", Before: `"1814400000000000"`, After: `"1814400s"`},
+	}, report.ParamChanges)
+	require.Equal(t, "100uatom", report.SupplyBefore.String())
+	require.Equal(t, sdk.NewInt(50), report.BondedBefore)
+	require.Equal(t, sdk.NewInt(50), report.BondedAfter)
+	require.NoError(t, report.Validate())
+	require.Contains(t, report.String(), "initialized: auth, bank, evidence")
+
+	after["bank"] = json.RawMessage(`{"supply": [{"denom": "uatom", "amount": "101"}]}`)
+	report, err = gaia.NewMigrationReport(before, after)
+	require.NoError(t, err)
+	require.Error(t, report.Validate())
+
+	after["bank"] = json.RawMessage(`{"supply": [{"denom": "uatom", "amount": "100"}]}`)
+	after["staking"] = json.RawMessage(`{"validators": [{"status": "BOND_STATUS_BONDED", "tokens": "70"}]}`)
+	report, err = gaia.NewMigrationReport(before, after)
+	require.NoError(t, err)
+	require.Error(t, report.Validate())
+}
@@ -0,0 +1,268 @@
+package gaia
+
+// This file implements the registry of genesis versions used by the migrate
+// command: the SDK migration callback bringing the genesis to each version,
+// followed by the Gaia-specific post-migration steps of that version.
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
+	captypes "github.com/cosmos/cosmos-sdk/x/capability/types"
+	evtypes "github.com/cosmos/cosmos-sdk/x/evidence/types"
+	"github.com/cosmos/cosmos-sdk/x/genutil/client/cli"
+	"github.com/cosmos/cosmos-sdk/x/genutil/types"
+	ibcxfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	host "github.com/cosmos/cosmos-sdk/x/ibc/core/24-host"
+	"github.com/cosmos/cosmos-sdk/x/ibc/core/exported"
+	ibccoretypes "github.com/cosmos/cosmos-sdk/x/ibc/core/types"
+	staking "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// MigrationOptions holds the command line options of the post-migration steps.
+type MigrationOptions struct {
+	ClientCtx client.Context
+
+	// DenomMetadata is the JSON file of the bank denom metadata. The metadata
+	// of the Cosmos Hub staking token is used if empty.
+	DenomMetadata string
+
+	// NoProp29 skips the prop29 fund recovery.
+	NoProp29 bool
+	// Prop29Recoveries is the JSON file listing the prop29 recoveries. It is
+	// required unless NoProp29 is set.
+	Prop29Recoveries string
+	// Prop29AuditLog is the file the prop29 audit log is written to. The log
+	// is written to AuditOut if empty, or on a dry run.
+	Prop29AuditLog string
+	// AuditOut receives the audit logs not written to a file.
+	AuditOut io.Writer
+	// DryRun writes the audit logs to AuditOut instead of their files, as the
+	// migrated genesis is discarded.
+	DryRun bool
+}
+
+// MigrationStep is a named Gaia-specific change applied to the genesis state
+// once the SDK migration of its version has run.
+type MigrationStep struct {
+	Name    string
+	Migrate func(genState types.AppMap, opts MigrationOptions) error
+}
+
+// GenesisVersion is a genesis format the migrate command can migrate to.
+type GenesisVersion struct {
+	// Version is the SDK version of the genesis format, e.g. v0.40.
+	Version string
+
+	// TendermintMigration, if set, is applied to the raw genesis document
+	// when migrating to this version, before it is parsed.
+	TendermintMigration func(jsonBlob []byte) ([]byte, error)
+
+	// SDKMigration migrates the app state from the previous version.
+	SDKMigration types.MigrationCallback
+
+	// PostSteps are applied in order after the SDK migration.
+	PostSteps []MigrationStep
+}
+
+// GenesisVersions lists the supported genesis versions, oldest first. The
+// first version is the oldest format that can be migrated from.
+var GenesisVersions = []GenesisVersion{
+	{
+		Version: "v0.34",
+	},
+	{
+		Version:      "v0.36",
+		SDKMigration: cli.GetMigrationCallback("v0.36"),
+	},
+	{
+		// NOTE: v0.37 and v0.38 are genesis compatible, cosmoshub-3 exports
+		// are migrated from v0.36.
+		Version:      "v0.38",
+		SDKMigration: cli.GetMigrationCallback("v0.38"),
+	},
+	{
+		Version:      "v0.39",
+		SDKMigration: cli.GetMigrationCallback("v0.39"),
+	},
+	{
+		// cosmoshub-4
+		Version:             "v0.40",
+		TendermintMigration: migrateTendermintGenesis,
+		SDKMigration:        cli.GetMigrationCallback("v0.40"),
+		PostSteps: []MigrationStep{
+			{Name: "prop-29-fund-recovery", Migrate: migrateProp29},
+			{Name: "bank-denom-metadata", Migrate: migrateDenomMetadata},
+			{Name: "ibc-genesis", Migrate: migrateIBCGenesis},
+			{Name: "capability-genesis", Migrate: migrateCapabilityGenesis},
+			{Name: "evidence-genesis", Migrate: migrateEvidenceGenesis},
+			{Name: "staking-historical-entries", Migrate: migrateStakingHistoricalEntries},
+		},
+	},
+}
+
+// DefaultMigrationSource and DefaultMigrationTarget are the versions of the
+// cosmoshub-3 to cosmoshub-4 migration.
+const (
+	DefaultMigrationSource = "v0.36"
+	DefaultMigrationTarget = "v0.40"
+)
+
+// GetMigrationPath returns the versions to migrate through, in order, to
+// migrate a genesis from version from to version to.
+func GetMigrationPath(from, to string) ([]GenesisVersion, error) {
+	fromIdx, toIdx := -1, -1
+	for i, v := range GenesisVersions {
+		switch v.Version {
+		case from:
+			fromIdx = i
+		case to:
+			toIdx = i
+		}
+	}
+
+	switch {
+	case fromIdx < 0:
+		return nil, fmt.Errorf("unknown source genesis version %s; supported versions are %v", from, genesisVersionNames())
+	case toIdx < 0:
+		return nil, fmt.Errorf("unknown target genesis version %s; supported versions are %v", to, genesisVersionNames())
+	case toIdx <= fromIdx:
+		return nil, fmt.Errorf("target genesis version %s must be after source version %s", to, from)
+	}
+
+	return GenesisVersions[fromIdx+1 : toIdx+1], nil
+}
+
+func genesisVersionNames() []string {
+	names := make([]string, len(GenesisVersions))
+	for i, v := range GenesisVersions {
+		names[i] = v.Version
+	}
+
+	return names
+}
+
+// MigrateTendermint applies the Tendermint migrations of the versions to the
+// raw genesis document.
+func MigrateTendermint(jsonBlob []byte, path []GenesisVersion) ([]byte, error) {
+	for _, v := range path {
+		if v.TendermintMigration == nil {
+			continue
+		}
+
+		var err error
+		if jsonBlob, err = v.TendermintMigration(jsonBlob); err != nil {
+			return nil, errors.Wrapf(err, "%s tendermint migration failed", v.Version)
+		}
+	}
+
+	return jsonBlob, nil
+}
+
+// MigrateAppState runs the SDK migration and the post-migration steps of
+// every version of the path on the app state.
+func MigrateAppState(genState types.AppMap, path []GenesisVersion, opts MigrationOptions) (types.AppMap, error) {
+	for _, v := range path {
+		var err error
+		if genState, err = runSDKMigration(v, genState, opts.ClientCtx); err != nil {
+			return nil, err
+		}
+
+		for _, step := range v.PostSteps {
+			if err := step.Migrate(genState, opts); err != nil {
+				return nil, errors.Wrapf(err, "%s post-migration step %s failed", v.Version, step.Name)
+			}
+		}
+	}
+
+	return genState, nil
+}
+
+// runSDKMigration runs the SDK migration callback of the version, turning the
+// panics of the SDK migrations into errors.
+func runSDKMigration(v GenesisVersion, genState types.AppMap, clientCtx client.Context) (newGenState types.AppMap, err error) {
+	if v.SDKMigration == nil {
+		return genState, nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			newGenState, err = nil, fmt.Errorf("%s migration failed: %v", v.Version, r)
+		}
+	}()
+
+	return v.SDKMigration(genState, clientCtx), nil
+}
+
+func migrateDenomMetadata(genState types.AppMap, opts MigrationOptions) error {
+	var bankGenesis bank.GenesisState
+	if err := opts.ClientCtx.JSONMarshaler.UnmarshalJSON(genState[bank.ModuleName], &bankGenesis); err != nil {
+		return errors.Wrap(err, "failed to unmarshal bank genesis state")
+	}
+
+	bankGenesis.DenomMetadata = DefaultDenomMetadata()
+	if opts.DenomMetadata != "" {
+		metadata, err := ReadDenomMetadata(opts.ClientCtx.JSONMarshaler, opts.DenomMetadata)
+		if err != nil {
+			return err
+		}
+
+		bankGenesis.DenomMetadata = metadata
+	}
+
+	if err := ValidateDenomMetadata(bankGenesis.DenomMetadata); err != nil {
+		return err
+	}
+
+	return setModuleGenesis(genState, bank.ModuleName, &bankGenesis, opts)
+}
+
+func migrateIBCGenesis(genState types.AppMap, opts MigrationOptions) error {
+	ibcTransferGenesis := ibcxfertypes.DefaultGenesisState()
+	ibcCoreGenesis := ibccoretypes.DefaultGenesisState()
+
+	ibcTransferGenesis.Params.ReceiveEnabled = false
+	ibcTransferGenesis.Params.SendEnabled = false
+
+	ibcCoreGenesis.ClientGenesis.Params.AllowedClients = []string{exported.Tendermint}
+
+	if err := setModuleGenesis(genState, ibcxfertypes.ModuleName, ibcTransferGenesis, opts); err != nil {
+		return err
+	}
+
+	return setModuleGenesis(genState, host.ModuleName, ibcCoreGenesis, opts)
+}
+
+func migrateCapabilityGenesis(genState types.AppMap, opts MigrationOptions) error {
+	return setModuleGenesis(genState, captypes.ModuleName, captypes.DefaultGenesis(), opts)
+}
+
+func migrateEvidenceGenesis(genState types.AppMap, opts MigrationOptions) error {
+	return setModuleGenesis(genState, evtypes.ModuleName, evtypes.DefaultGenesisState(), opts)
+}
+
+func migrateStakingHistoricalEntries(genState types.AppMap, opts MigrationOptions) error {
+	var stakingGenesis staking.GenesisState
+	if err := opts.ClientCtx.JSONMarshaler.UnmarshalJSON(genState[staking.ModuleName], &stakingGenesis); err != nil {
+		return errors.Wrap(err, "failed to unmarshal staking genesis state")
+	}
+
+	stakingGenesis.Params.HistoricalEntries = 10000
+
+	return setModuleGenesis(genState, staking.ModuleName, &stakingGenesis, opts)
+}
+
+// setModuleGenesis sets the genesis state of a module.
+func setModuleGenesis(genState types.AppMap, moduleName string, moduleGenesis proto.Message, opts MigrationOptions) error {
+	bz, err := opts.ClientCtx.JSONMarshaler.MarshalJSON(moduleGenesis)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s genesis state", moduleName)
+	}
+
+	genState[moduleName] = bz
+	return nil
+}
@@ -0,0 +1,120 @@
+package gaia_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/cosmos/cosmos-sdk/x/genutil/types"
+	ibcxfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	staking "github.com/cosmos/cosmos-sdk/x/staking/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestGetMigrationPath(t *testing.T) {
+	path, err := gaia.GetMigrationPath(gaia.DefaultMigrationSource, gaia.DefaultMigrationTarget)
+	require.NoError(t, err)
+
+	versions := make([]string, len(path))
+	for i, v := range path {
+		versions[i] = v.Version
+	}
+	require.Equal(t, []string{"v0.38", "v0.39", "v0.40"}, versions)
+
+	_, err = gaia.GetMigrationPath("v0.40", "v0.38")
+	require.Error(t, err)
+	_, err = gaia.GetMigrationPath("v0.40", "v0.40")
+	require.Error(t, err)
+	_, err = gaia.GetMigrationPath("v0.30", "v0.40")
+	require.Error(t, err)
+	_, err = gaia.GetMigrationPath("v0.36", "v1.0")
+	require.Error(t, err)
+}
+
+func TestMigrateAppState(t *testing.T) {
+	var calls []string
+	step := func(name string, err error) gaia.MigrationStep {
+		return gaia.MigrationStep{Name: name, Migrate: func(types.AppMap, gaia.MigrationOptions) error {
+			calls = append(calls, name)
+			return err
+		}}
+	}
+	sdkMigration := func(name string) types.MigrationCallback {
+		return func(genState types.AppMap, _ client.Context) types.AppMap {
+			calls = append(calls, name)
+			return genState
+		}
+	}
+
+	path := []gaia.GenesisVersion{
+		{Version: "v1", SDKMigration: sdkMigration("sdk-v1"), PostSteps: []gaia.MigrationStep{step("a", nil), step("b", nil)}},
+		{Version: "v2", PostSteps: []gaia.MigrationStep{step("c", nil)}},
+	}
+	_, err := gaia.MigrateAppState(types.AppMap{}, path, gaia.MigrationOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"sdk-v1", "a", "b", "c"}, calls)
+
+	// failing steps stop the migration
+	calls = nil
+	path[0].PostSteps[0] = step("a", errors.New("failure"))
+	_, err = gaia.MigrateAppState(types.AppMap{}, path, gaia.MigrationOptions{})
+	require.Error(t, err)
+	require.Equal(t, []string{"sdk-v1", "a"}, calls)
+
+	// panicking SDK migrations are turned into errors
+	path[0].SDKMigration = func(types.AppMap, client.Context) types.AppMap { panic("invalid genesis") }
+	_, err = gaia.MigrateAppState(types.AppMap{}, path, gaia.MigrationOptions{})
+	require.EqualError(t, err, "v1 migration failed: invalid genesis")
+}
+
+func TestCosmosHub4PostSteps(t *testing.T) {
+	encCfg := gaia.MakeEncodingConfig()
+	clientCtx := client.Context{}.WithJSONMarshaler(encCfg.Marshaler)
+	genState := types.AppMap(gaia.NewDefaultGenesisState())
+
+	v040 := gaia.GenesisVersions[len(gaia.GenesisVersions)-1]
+	require.Equal(t, "v0.40", v040.Version)
+	path := []gaia.GenesisVersion{{Version: v040.Version, PostSteps: v040.PostSteps}}
+
+	// the prop29 recoveries file is required
+	_, err := gaia.MigrateAppState(genState, path, gaia.MigrationOptions{ClientCtx: clientCtx, AuditOut: ioutil.Discard})
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	recoveries := filepath.Join(dir, "recoveries.json")
+	require.NoError(t, ioutil.WriteFile(recoveries, []byte(`[{"from": "cosmos1a", "to": "cosmos1b", "amount": []}]`), 0600))
+	_, err = gaia.MigrateAppState(genState, path, gaia.MigrationOptions{ClientCtx: clientCtx, Prop29Recoveries: recoveries})
+	require.Error(t, err)
+
+	// the audit log is not written to its file on a dry run
+	require.NoError(t, ioutil.WriteFile(recoveries, []byte(`[]`), 0600))
+	auditLog := filepath.Join(dir, "audit.json")
+	opts := gaia.MigrationOptions{ClientCtx: clientCtx, Prop29Recoveries: recoveries, Prop29AuditLog: auditLog, AuditOut: ioutil.Discard, DryRun: true}
+	_, err = gaia.MigrateAppState(genState, path, opts)
+	require.NoError(t, err)
+	require.NoFileExists(t, auditLog)
+
+	// and the recovery is skipped with NoProp29
+	genState, err = gaia.MigrateAppState(genState, path, gaia.MigrationOptions{ClientCtx: clientCtx, NoProp29: true})
+	require.NoError(t, err)
+
+	var bankGenesis bank.GenesisState
+	encCfg.Marshaler.MustUnmarshalJSON(genState[bank.ModuleName], &bankGenesis)
+	require.Len(t, bankGenesis.DenomMetadata, 1)
+	require.Equal(t, "uatom", bankGenesis.DenomMetadata[0].Base)
+
+	var stakingGenesis staking.GenesisState
+	encCfg.Marshaler.MustUnmarshalJSON(genState[staking.ModuleName], &stakingGenesis)
+	require.Equal(t, uint32(10000), stakingGenesis.Params.HistoricalEntries)
+
+	var transferGenesis ibcxfertypes.GenesisState
+	encCfg.Marshaler.MustUnmarshalJSON(genState[ibcxfertypes.ModuleName], &transferGenesis)
+	require.False(t, transferGenesis.Params.SendEnabled)
+	require.False(t, transferGenesis.Params.ReceiveEnabled)
+}
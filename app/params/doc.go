@@ -5,15 +5,21 @@ It contains the default weights used for each transaction used on the module's
 simulation. These weights define the chance for a transaction to be simulated at
 any gived operation.
 
-You can repace the default values for the weights by providing a params.json
-file with the weights defined for each of the transaction operations:
+You can repace the default values for the weights by providing a weights JSON
+file to the Gaia simulator with -Weights, with the weights defined for each of
+the transaction operations:
 
 	{
 		"op_weight_msg_send": 60,
 		"op_weight_msg_delegate": 100,
 	}
 
-In the example above, the `MsgSend` has 60% chance to be simulated, while the
-`MsgDelegate` will always be simulated.
+In the example above, the `MsgSend` is simulated 60% as often as the
+`MsgDelegate`. Unknown weight keys are rejected. The weights can also be set in
+the params.json file given with -Params, which the weights file overrides.
+
+At the end of each run, the simulator prints how many operations of each
+message were attempted, succeeded, were skipped and failed, with the reasons
+of the skipped ones.
 */
 package params
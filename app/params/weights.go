@@ -16,6 +16,10 @@ const (
 	DefaultWeightMsgDelegate                    int = 100
 	DefaultWeightMsgUndelegate                  int = 100
 	DefaultWeightMsgBeginRedelegate             int = 100
+	DefaultWeightMsgTransfer                    int = 50
+	DefaultWeightMsgRecvPacket                  int = 50
+	DefaultWeightMsgAcknowledgement             int = 40
+	DefaultWeightMsgTimeout                     int = 10
 
 	DefaultWeightCommunitySpendProposal int = 5
 	DefaultWeightTextProposal           int = 5
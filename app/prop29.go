@@ -0,0 +1,204 @@
+package gaia
+
+// This file implements the fund recovery approved by cosmoshub-3 governance
+// proposal 29. The recoveries given with --prop-29-recoveries are applied to
+// the migrated genesis unless --no-prop-29 is set.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingexported "github.com/cosmos/cosmos-sdk/x/auth/vesting/exported"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/cosmos/cosmos-sdk/x/genutil/types"
+	"github.com/pkg/errors"
+)
+
+// Prop29Recovery moves Amount from the From account to the To account.
+type Prop29Recovery struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Amount sdk.Coins `json:"amount"`
+}
+
+// Validate performs a basic validation of the recovery.
+func (r Prop29Recovery) Validate() error {
+	if _, err := sdk.AccAddressFromBech32(r.From); err != nil {
+		return errors.Wrapf(err, "invalid from address %s", r.From)
+	}
+	if _, err := sdk.AccAddressFromBech32(r.To); err != nil {
+		return errors.Wrapf(err, "invalid to address %s", r.To)
+	}
+	if r.From == r.To {
+		return fmt.Errorf("cannot recover funds of %s to itself", r.From)
+	}
+	for name := range maccPerms {
+		if r.From == authtypes.NewModuleAddress(name).String() {
+			return fmt.Errorf("cannot recover funds from the %s module account %s", name, r.From)
+		}
+	}
+	if !r.Amount.IsValid() || r.Amount.IsZero() {
+		return fmt.Errorf("invalid recovery amount %s", r.Amount)
+	}
+
+	return nil
+}
+
+// Prop29AuditEntry records the balance of an account touched by the recovery.
+type Prop29AuditEntry struct {
+	Address       string    `json:"address"`
+	BalanceBefore sdk.Coins `json:"balance_before"`
+	BalanceAfter  sdk.Coins `json:"balance_after"`
+	// Created is true if the account did not exist before the recovery.
+	Created bool `json:"created"`
+}
+
+// Prop29AuditLog lists the applied recoveries and every account they touched,
+// in the order the accounts were first touched.
+type Prop29AuditLog struct {
+	Recoveries []Prop29Recovery   `json:"recoveries"`
+	Accounts   []Prop29AuditEntry `json:"accounts"`
+}
+
+// ReadProp29Recoveries reads a JSON list of recoveries from a file.
+func ReadProp29Recoveries(path string) ([]Prop29Recovery, error) {
+	bz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read prop-29 recoveries file")
+	}
+
+	var recoveries []Prop29Recovery
+	if err := json.Unmarshal(bz, &recoveries); err != nil {
+		return nil, errors.Wrap(err, "failed to parse prop-29 recoveries file")
+	}
+
+	return recoveries, nil
+}
+
+// Prop29Migration applies the recoveries to the auth and bank genesis states
+// of appState. Recovered funds must be spendable: recovering from vesting or
+// module accounts is not supported. Accounts receiving funds are created if they do
+// not exist. The total supply is unchanged.
+func Prop29Migration(cdc codec.JSONMarshaler, appState types.AppMap, recoveries []Prop29Recovery) (Prop29AuditLog, error) {
+	auditLog := Prop29AuditLog{Recoveries: recoveries}
+
+	var authGenState authtypes.GenesisState
+	if err := cdc.UnmarshalJSON(appState[authtypes.ModuleName], &authGenState); err != nil {
+		return auditLog, errors.Wrap(err, "failed to unmarshal auth genesis state")
+	}
+
+	accs, err := authtypes.UnpackAccounts(authGenState.Accounts)
+	if err != nil {
+		return auditLog, errors.Wrap(err, "failed to get accounts from any")
+	}
+
+	accounts := make(map[string]authtypes.GenesisAccount, len(accs))
+	for _, acc := range accs {
+		accounts[acc.GetAddress().String()] = acc
+	}
+
+	var bankGenState banktypes.GenesisState
+	if err := cdc.UnmarshalJSON(appState[banktypes.ModuleName], &bankGenState); err != nil {
+		return auditLog, errors.Wrap(err, "failed to unmarshal bank genesis state")
+	}
+
+	balances := make(map[string]sdk.Coins, len(bankGenState.Balances))
+	for _, balance := range bankGenState.Balances {
+		balances[balance.Address] = balance.Coins
+	}
+
+	touched := make(map[string]int)
+	touch := func(addr string, created bool) *Prop29AuditEntry {
+		i, ok := touched[addr]
+		if !ok {
+			i = len(auditLog.Accounts)
+			touched[addr] = i
+			auditLog.Accounts = append(auditLog.Accounts, Prop29AuditEntry{
+				Address:       addr,
+				BalanceBefore: nonNilCoins(balances[addr]),
+				Created:       created,
+			})
+		}
+
+		return &auditLog.Accounts[i]
+	}
+
+	for i, r := range recoveries {
+		if err := r.Validate(); err != nil {
+			return auditLog, errors.Wrapf(err, "recovery %d", i)
+		}
+
+		from, ok := accounts[r.From]
+		if !ok {
+			return auditLog, fmt.Errorf("recovery %d: account %s does not exist", i, r.From)
+		}
+		if _, ok := from.(vestingexported.VestingAccount); ok {
+			return auditLog, fmt.Errorf("recovery %d: cannot recover funds from vesting account %s", i, r.From)
+		}
+		if _, ok := from.(authtypes.ModuleAccountI); ok {
+			return auditLog, fmt.Errorf("recovery %d: cannot recover funds from module account %s", i, r.From)
+		}
+
+		fromBalance, negative := balances[r.From].SafeSub(r.Amount)
+		if negative {
+			return auditLog, fmt.Errorf("recovery %d: balance %s of %s is smaller than %s", i, balances[r.From], r.From, r.Amount)
+		}
+
+		_, exists := accounts[r.To]
+		if !exists {
+			to, _ := sdk.AccAddressFromBech32(r.To)
+			accounts[r.To] = authtypes.NewBaseAccount(to, nil, 0, 0)
+			accs = append(accs, accounts[r.To])
+		}
+
+		touch(r.From, false)
+		touch(r.To, !exists)
+
+		balances[r.From] = fromBalance
+		balances[r.To] = balances[r.To].Add(r.Amount...)
+	}
+
+	for i := range auditLog.Accounts {
+		auditLog.Accounts[i].BalanceAfter = nonNilCoins(balances[auditLog.Accounts[i].Address])
+	}
+
+	genAccs, err := authtypes.PackAccounts(authtypes.SanitizeGenesisAccounts(accs))
+	if err != nil {
+		return auditLog, errors.Wrap(err, "failed to convert accounts into any's")
+	}
+	authGenState.Accounts = genAccs
+
+	for i, balance := range bankGenState.Balances {
+		if _, ok := touched[balance.Address]; ok {
+			bankGenState.Balances[i].Coins = balances[balance.Address]
+			delete(touched, balance.Address)
+		}
+	}
+	for addr := range touched {
+		bankGenState.Balances = append(bankGenState.Balances, banktypes.Balance{Address: addr, Coins: balances[addr]})
+	}
+	bankGenState.Balances = banktypes.SanitizeGenesisBalances(bankGenState.Balances)
+
+	if appState[authtypes.ModuleName], err = cdc.MarshalJSON(&authGenState); err != nil {
+		return auditLog, errors.Wrap(err, "failed to marshal auth genesis state")
+	}
+	if appState[banktypes.ModuleName], err = cdc.MarshalJSON(&bankGenState); err != nil {
+		return auditLog, errors.Wrap(err, "failed to marshal bank genesis state")
+	}
+
+	return auditLog, nil
+}
+
+// nonNilCoins returns an empty set of coins instead of nil, so that empty
+// balances are written as [] in the audit log.
+func nonNilCoins(coins sdk.Coins) sdk.Coins {
+	if coins == nil {
+		return sdk.Coins{}
+	}
+
+	return coins
+}
@@ -0,0 +1,111 @@
+package gaia_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	authvesting "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/cosmos/cosmos-sdk/x/genutil/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestProp29Migration(t *testing.T) {
+	cdc := gaia.MakeEncodingConfig().Marshaler
+
+	from := sdk.AccAddress([]byte("from________________"))
+	to := sdk.AccAddress([]byte("to__________________"))
+	newAcc := sdk.AccAddress([]byte("new_________________"))
+	vesting := sdk.AccAddress([]byte("vesting_____________"))
+	module := authtypes.NewModuleAddress("prop29")
+
+	newAppState := func() types.AppMap {
+		appState := gaia.ModuleBasics.DefaultGenesis(cdc)
+
+		vestingAcc := authvesting.NewDelayedVestingAccount(authtypes.NewBaseAccount(vesting, nil, 0, 0), sdk.NewCoins(sdk.NewInt64Coin("uatom", 100)), 1000)
+		accs, err := authtypes.PackAccounts(authtypes.GenesisAccounts{
+			authtypes.NewBaseAccount(from, nil, 0, 0),
+			authtypes.NewBaseAccount(to, nil, 1, 0),
+			vestingAcc,
+			authtypes.NewEmptyModuleAccount("prop29"),
+		})
+		require.NoError(t, err)
+		authGenState := authtypes.GetGenesisStateFromAppState(cdc, appState)
+		authGenState.Accounts = accs
+		appState[authtypes.ModuleName] = cdc.MustMarshalJSON(&authGenState)
+
+		bankGenState := banktypes.GetGenesisStateFromAppState(cdc, appState)
+		bankGenState.Balances = []banktypes.Balance{
+			{Address: from.String(), Coins: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000), sdk.NewInt64Coin("stake", 10))},
+			{Address: to.String(), Coins: sdk.NewCoins(sdk.NewInt64Coin("uatom", 5))},
+			{Address: vesting.String(), Coins: sdk.NewCoins(sdk.NewInt64Coin("uatom", 100))},
+			{Address: module.String(), Coins: sdk.NewCoins(sdk.NewInt64Coin("uatom", 100))},
+		}
+		bankGenState.Supply = sdk.NewCoins(sdk.NewInt64Coin("uatom", 1205), sdk.NewInt64Coin("stake", 10))
+		appState[banktypes.ModuleName] = cdc.MustMarshalJSON(bankGenState)
+
+		return appState
+	}
+
+	appState := newAppState()
+	auditLog, err := gaia.Prop29Migration(cdc, appState, []gaia.Prop29Recovery{
+		{From: from.String(), To: to.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 600))},
+		{From: from.String(), To: newAcc.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 400), sdk.NewInt64Coin("stake", 10))},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []gaia.Prop29AuditEntry{
+		{
+			Address:       from.String(),
+			BalanceBefore: sdk.NewCoins(sdk.NewInt64Coin("stake", 10), sdk.NewInt64Coin("uatom", 1000)),
+			BalanceAfter:  sdk.Coins{},
+		},
+		{
+			Address:       to.String(),
+			BalanceBefore: sdk.NewCoins(sdk.NewInt64Coin("uatom", 5)),
+			BalanceAfter:  sdk.NewCoins(sdk.NewInt64Coin("uatom", 605)),
+		},
+		{
+			Address:       newAcc.String(),
+			BalanceBefore: sdk.Coins{},
+			BalanceAfter:  sdk.NewCoins(sdk.NewInt64Coin("stake", 10), sdk.NewInt64Coin("uatom", 400)),
+			Created:       true,
+		},
+	}, auditLog.Accounts)
+
+	authGenState := authtypes.GetGenesisStateFromAppState(cdc, appState)
+	accs, err := authtypes.UnpackAccounts(authGenState.Accounts)
+	require.NoError(t, err)
+	require.True(t, accs.Contains(newAcc))
+	require.Len(t, accs, 5)
+
+	bankGenState := banktypes.GetGenesisStateFromAppState(cdc, appState)
+	require.NoError(t, bankGenState.Validate())
+	total := sdk.NewCoins()
+	for _, balance := range bankGenState.Balances {
+		total = total.Add(balance.Coins...)
+	}
+	require.Equal(t, bankGenState.Supply, total)
+
+	invalid := []gaia.Prop29Recovery{
+		{From: from.String(), To: to.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1001))},
+		{From: vesting.String(), To: to.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1))},
+		{From: module.String(), To: to.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1))},
+		{From: newAcc.String(), To: to.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1))},
+		{From: from.String(), To: from.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1))},
+		{From: from.String(), To: to.String()},
+		{From: "cosmos1invalid", To: to.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1))},
+	}
+	for _, r := range invalid {
+		_, err := gaia.Prop29Migration(cdc, newAppState(), []gaia.Prop29Recovery{r})
+		require.Error(t, err, r)
+	}
+
+	// the module accounts of the app are rejected before looking them up
+	distribution := gaia.Prop29Recovery{From: authtypes.NewModuleAddress("distribution").String(), To: to.String(), Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1))}
+	require.EqualError(t, distribution.Validate(), "cannot recover funds from the distribution module account "+distribution.From)
+}
@@ -1,121 +1,199 @@
 package gaia
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
+	"text/tabwriter"
 
-	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/codec"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptocodec "github.com/cosmos/cosmos-sdk/crypto/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/genutil/types"
 	slashing "github.com/cosmos/cosmos-sdk/x/slashing/types"
 	staking "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/pkg/errors"
-	cryptocodec "github.com/tendermint/tendermint/crypto/encoding"
+	tmcrypto "github.com/tendermint/tendermint/crypto"
 	tmtypes "github.com/tendermint/tendermint/types"
 )
 
-type replacementConfigs []replacementConfig
-
-func (r *replacementConfigs) isReplacedValidator(validatorAddress string) (int, replacementConfig) {
-
-	for i, replacement := range *r {
-		if replacement.ValidatorAddress == validatorAddress {
-			return i, replacement
-		}
-	}
-
-	return -1, replacementConfig{}
-}
-
-type replacementConfig struct {
+// ConsKeyReplacement replaces the consensus key of a validator.
+type ConsKeyReplacement struct {
 	Name             string `json:"validator_name"`
 	ValidatorAddress string `json:"validator_address"`
 	ConsensusPubkey  string `json:"stargate_consensus_public_key"`
 }
 
-func loadKeydataFromFile(clientCtx client.Context, replacementrJSON string, genDoc *tmtypes.GenesisDoc) *tmtypes.GenesisDoc {
-	jsonReplacementBlob, err := ioutil.ReadFile(replacementrJSON)
+// ConsKeyChange records the consensus key replacement of a validator.
+type ConsKeyChange struct {
+	Name             string
+	ValidatorAddress string
+	OldConsAddress   sdk.ConsAddress
+	NewConsAddress   sdk.ConsAddress
+	// SigningInfos and MissedBlocks are the number of slashing entries moved
+	// to the new consensus address.
+	SigningInfos int
+	MissedBlocks int
+	// TendermintValidator is true if the validator of the genesis document
+	// was replaced too.
+	TendermintValidator bool
+}
+
+// ReadConsKeyReplacements reads a JSON list of consensus key replacements.
+func ReadConsKeyReplacements(path string) ([]ConsKeyReplacement, error) {
+	bz, err := ioutil.ReadFile(path)
 	if err != nil {
-		log.Fatal(errors.Wrapf(err, "failed to read replacement keys from file %s", replacementrJSON))
+		return nil, errors.Wrapf(err, "failed to read replacement keys from file %s", path)
 	}
 
-	var replacementKeys replacementConfigs
-
-	err = json.Unmarshal(jsonReplacementBlob, &replacementKeys)
-
-	if err != nil {
-		log.Fatal("Could not unmarshal replacement keys ")
+	var replacements []ConsKeyReplacement
+	if err := json.Unmarshal(bz, &replacements); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal replacement keys from file %s", path)
 	}
 
+	return replacements, nil
+}
+
+// ReplaceConsensusKeys replaces the consensus keys of the validators in the
+// staking genesis state, and moves their slashing signing infos and missed
+// blocks, as well as their genesis document validator entries, to the new
+// consensus addresses. Before changing anything, it fails if a validator does
+// not exist or is listed twice, or if a new key is listed twice or is already
+// used by another validator.
+func ReplaceConsensusKeys(cdc codec.JSONMarshaler, genDoc *tmtypes.GenesisDoc, replacements []ConsKeyReplacement) ([]ConsKeyChange, error) {
 	var state types.AppMap
 	if err := json.Unmarshal(genDoc.AppState, &state); err != nil {
-		log.Fatal(errors.Wrap(err, "failed to JSON unmarshal initial genesis state"))
+		return nil, errors.Wrap(err, "failed to JSON unmarshal genesis state")
 	}
 
 	var stakingGenesis staking.GenesisState
-	var slashingGenesis slashing.GenesisState
+	if err := cdc.UnmarshalJSON(state[staking.ModuleName], &stakingGenesis); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal staking genesis state")
+	}
 
-	clientCtx.JSONMarshaler.MustUnmarshalJSON(state[staking.ModuleName], &stakingGenesis)
-	clientCtx.JSONMarshaler.MustUnmarshalJSON(state[slashing.ModuleName], &slashingGenesis)
+	var slashingGenesis slashing.GenesisState
+	if err := cdc.UnmarshalJSON(state[slashing.ModuleName], &slashingGenesis); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal slashing genesis state")
+	}
 
+	validators := make(map[string]int, len(stakingGenesis.Validators))
+	consAddrs := make(map[string]string, len(stakingGenesis.Validators))
 	for i, val := range stakingGenesis.Validators {
-		idx, replacement := replacementKeys.isReplacedValidator(val.OperatorAddress)
+		consAddr, err := val.GetConsAddr()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get consensus address of validator %s", val.OperatorAddress)
+		}
 
-		if idx != -1 {
+		validators[val.OperatorAddress] = i
+		consAddrs[consAddr.String()] = val.OperatorAddress
+	}
 
-			toReplaceValConsAddress, _ := val.GetConsAddr()
+	changes := make([]ConsKeyChange, len(replacements))
+	pkAnys := make([]*codectypes.Any, len(replacements))
+	tmPubKeys := make([]tmcrypto.PubKey, len(replacements))
+	replaced := make(map[string]bool, len(replacements))
+	newConsAddrs := make(map[string]string, len(replacements))
 
-			consPubKey, err := sdk.GetPubKeyFromBech32(sdk.Bech32PubKeyTypeConsPub, replacement.ConsensusPubkey)
+	for i, r := range replacements {
+		if replaced[r.ValidatorAddress] {
+			return nil, fmt.Errorf("validator %s is listed twice", r.ValidatorAddress)
+		}
+		replaced[r.ValidatorAddress] = true
 
-			if err != nil {
-				log.Fatal(fmt.Errorf("failed to decode key:%s %w", consPubKey, err))
-			}
+		idx, ok := validators[r.ValidatorAddress]
+		if !ok {
+			return nil, fmt.Errorf("validator %s (%s) not found in staking genesis", r.ValidatorAddress, r.Name)
+		}
 
-			val.ConsensusPubkey, err = codectypes.NewAnyWithValue(consPubKey)
-			if err != nil {
-				log.Fatal(fmt.Errorf("failed to decode key:%s %w", consPubKey, err))
-			}
+		pubKey, err := sdk.GetPubKeyFromBech32(sdk.Bech32PubKeyTypeConsPub, r.ConsensusPubkey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode consensus key %s of validator %s", r.ConsensusPubkey, r.ValidatorAddress)
+		}
 
-			replaceValConsAddress, _ := val.GetConsAddr()
-			protoReplaceValConsPubKey, _ := val.TmConsPublicKey()
-			replaceValConsPubKey, _ := cryptocodec.PubKeyFromProto(protoReplaceValConsPubKey)
+		newConsAddr := sdk.ConsAddress(pubKey.Address())
+		if other, ok := newConsAddrs[newConsAddr.String()]; ok {
+			return nil, fmt.Errorf("validators %s and %s are given the same consensus key %s", other, r.ValidatorAddress, r.ConsensusPubkey)
+		}
+		newConsAddrs[newConsAddr.String()] = r.ValidatorAddress
 
-			for i, signingInfo := range slashingGenesis.SigningInfos {
-				if signingInfo.Address == toReplaceValConsAddress.String() {
-					slashingGenesis.SigningInfos[i].Address = replaceValConsAddress.String()
-					slashingGenesis.SigningInfos[i].ValidatorSigningInfo.Address = replaceValConsAddress.String()
-				}
-			}
+		if other, ok := consAddrs[newConsAddr.String()]; ok && other == r.ValidatorAddress {
+			return nil, fmt.Errorf("consensus key %s is already the key of validator %s", r.ConsensusPubkey, r.ValidatorAddress)
+		} else if ok {
+			return nil, fmt.Errorf("consensus key %s of validator %s is already used by validator %s", r.ConsensusPubkey, r.ValidatorAddress, other)
+		}
 
-			for i, missedInfo := range slashingGenesis.MissedBlocks {
-				if missedInfo.Address == toReplaceValConsAddress.String() {
-					slashingGenesis.MissedBlocks[i].Address = replaceValConsAddress.String()
-				}
-			}
+		if pkAnys[i], err = codectypes.NewAnyWithValue(pubKey); err != nil {
+			return nil, errors.Wrapf(err, "failed to pack consensus key of validator %s", r.ValidatorAddress)
+		}
+		if tmPubKeys[i], err = cryptocodec.ToTmPubKeyInterface(pubKey); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert consensus key of validator %s", r.ValidatorAddress)
+		}
+
+		oldConsAddr, _ := stakingGenesis.Validators[idx].GetConsAddr()
+		changes[i] = ConsKeyChange{
+			Name:             r.Name,
+			ValidatorAddress: r.ValidatorAddress,
+			OldConsAddress:   oldConsAddr,
+			NewConsAddress:   newConsAddr,
+		}
+	}
+
+	for i, change := range changes {
+		stakingGenesis.Validators[validators[change.ValidatorAddress]].ConsensusPubkey = pkAnys[i]
 
-			for tmIdx, tmval := range genDoc.Validators {
-				if tmval.Address.String() == replaceValConsAddress.String() {
-					genDoc.Validators[tmIdx].Address = replaceValConsAddress.Bytes()
-					genDoc.Validators[tmIdx].PubKey = replaceValConsPubKey
+		oldAddr, newAddr := change.OldConsAddress.String(), change.NewConsAddress.String()
 
-				}
+		for j, signingInfo := range slashingGenesis.SigningInfos {
+			if signingInfo.Address == oldAddr {
+				slashingGenesis.SigningInfos[j].Address = newAddr
+				slashingGenesis.SigningInfos[j].ValidatorSigningInfo.Address = newAddr
+				changes[i].SigningInfos++
 			}
-			stakingGenesis.Validators[i] = val
+		}
 
+		for j, missedInfo := range slashingGenesis.MissedBlocks {
+			if missedInfo.Address == oldAddr {
+				slashingGenesis.MissedBlocks[j].Address = newAddr
+				changes[i].MissedBlocks++
+			}
 		}
 
+		for j, tmVal := range genDoc.Validators {
+			if bytes.Equal(tmVal.Address, change.OldConsAddress) {
+				genDoc.Validators[j].Address = tmPubKeys[i].Address()
+				genDoc.Validators[j].PubKey = tmPubKeys[i]
+				changes[i].TendermintValidator = true
+			}
+		}
 	}
-	state[staking.ModuleName] = clientCtx.JSONMarshaler.MustMarshalJSON(&stakingGenesis)
-	state[slashing.ModuleName] = clientCtx.JSONMarshaler.MustMarshalJSON(&slashingGenesis)
 
-	genDoc.AppState, err = json.Marshal(state)
+	var err error
+	if state[staking.ModuleName], err = cdc.MarshalJSON(&stakingGenesis); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal staking genesis state")
+	}
+	if state[slashing.ModuleName], err = cdc.MarshalJSON(&slashingGenesis); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal slashing genesis state")
+	}
 
-	if err != nil {
-		log.Fatal("Could not marshal App State")
+	if genDoc.AppState, err = json.Marshal(state); err != nil {
+		return nil, errors.Wrap(err, "failed to JSON marshal genesis state")
+	}
+
+	return changes, nil
+}
+
+// PrintConsKeyChanges prints a before/after table of the consensus key
+// replacements.
+func PrintConsKeyChanges(w io.Writer, changes []ConsKeyChange) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VALIDATOR\tOPERATOR ADDRESS\tOLD CONSENSUS ADDRESS\tNEW CONSENSUS ADDRESS\tSIGNING INFOS\tMISSED BLOCKS\tTM VALIDATOR")
+	for _, c := range changes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%t\n",
+			c.Name, c.ValidatorAddress, c.OldConsAddress, c.NewConsAddress, c.SigningInfos, c.MissedBlocks, c.TendermintValidator)
 	}
-	return genDoc
 
+	return tw.Flush()
 }
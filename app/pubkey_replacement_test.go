@@ -0,0 +1,169 @@
+package gaia_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptocodec "github.com/cosmos/cosmos-sdk/crypto/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestReplaceConsensusKeys(t *testing.T) {
+	cdc := gaia.MakeEncodingConfig().Marshaler
+
+	valAddrs := []sdk.ValAddress{
+		sdk.ValAddress([]byte("val0________________")),
+		sdk.ValAddress([]byte("val1________________")),
+	}
+	oldKeys := []cryptotypes.PubKey{ed25519.GenPrivKey().PubKey(), ed25519.GenPrivKey().PubKey()}
+	newKey := ed25519.GenPrivKey().PubKey()
+
+	newGenDoc := func() *tmtypes.GenesisDoc {
+		appState := gaia.ModuleBasics.DefaultGenesis(cdc)
+		genDoc := &tmtypes.GenesisDoc{ChainID: "test"}
+
+		stakingGenState := stakingtypes.DefaultGenesisState()
+		slashingGenState := slashingtypes.DefaultGenesisState()
+		for i, valAddr := range valAddrs {
+			pkAny, err := codectypes.NewAnyWithValue(oldKeys[i])
+			require.NoError(t, err)
+			stakingGenState.Validators = append(stakingGenState.Validators, stakingtypes.Validator{
+				OperatorAddress: valAddr.String(),
+				ConsensusPubkey: pkAny,
+				Status:          stakingtypes.Bonded,
+				Tokens:          sdk.NewInt(100),
+				DelegatorShares: sdk.NewDec(100),
+			})
+
+			consAddr := sdk.ConsAddress(oldKeys[i].Address()).String()
+			slashingGenState.SigningInfos = append(slashingGenState.SigningInfos, slashingtypes.SigningInfo{
+				Address:              consAddr,
+				ValidatorSigningInfo: slashingtypes.NewValidatorSigningInfo(sdk.ConsAddress(oldKeys[i].Address()), 0, 0, genDoc.GenesisTime, false, 1),
+			})
+			slashingGenState.MissedBlocks = append(slashingGenState.MissedBlocks, slashingtypes.ValidatorMissedBlocks{
+				Address:      consAddr,
+				MissedBlocks: []slashingtypes.MissedBlock{{Index: 0, Missed: true}},
+			})
+
+			tmPubKey, err := cryptocodec.ToTmPubKeyInterface(oldKeys[i])
+			require.NoError(t, err)
+			genDoc.Validators = append(genDoc.Validators, tmtypes.GenesisValidator{
+				Address: tmPubKey.Address(),
+				PubKey:  tmPubKey,
+				Power:   100,
+			})
+		}
+		appState[stakingtypes.ModuleName] = cdc.MustMarshalJSON(stakingGenState)
+		appState[slashingtypes.ModuleName] = cdc.MustMarshalJSON(slashingGenState)
+
+		var err error
+		genDoc.AppState, err = json.Marshal(appState)
+		require.NoError(t, err)
+
+		return genDoc
+	}
+
+	bech32Key := func(pk cryptotypes.PubKey) string {
+		return sdk.MustBech32ifyPubKey(sdk.Bech32PubKeyTypeConsPub, pk)
+	}
+
+	genDoc := newGenDoc()
+	changes, err := gaia.ReplaceConsensusKeys(cdc, genDoc, []gaia.ConsKeyReplacement{
+		{Name: "val1", ValidatorAddress: valAddrs[1].String(), ConsensusPubkey: bech32Key(newKey)},
+	})
+	require.NoError(t, err)
+
+	oldConsAddr := sdk.ConsAddress(oldKeys[1].Address())
+	newConsAddr := sdk.ConsAddress(newKey.Address())
+	require.Equal(t, []gaia.ConsKeyChange{{
+		Name:                "val1",
+		ValidatorAddress:    valAddrs[1].String(),
+		OldConsAddress:      oldConsAddr,
+		NewConsAddress:      newConsAddr,
+		SigningInfos:        1,
+		MissedBlocks:        1,
+		TendermintValidator: true,
+	}}, changes)
+
+	var appState map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(genDoc.AppState, &appState))
+
+	var stakingGenState stakingtypes.GenesisState
+	cdc.MustUnmarshalJSON(appState[stakingtypes.ModuleName], &stakingGenState)
+	require.NoError(t, stakingGenState.UnpackInterfaces(cdc))
+	consAddr, err := stakingGenState.Validators[1].GetConsAddr()
+	require.NoError(t, err)
+	require.Equal(t, newConsAddr, consAddr)
+	consAddr, err = stakingGenState.Validators[0].GetConsAddr()
+	require.NoError(t, err)
+	require.Equal(t, sdk.ConsAddress(oldKeys[0].Address()), consAddr)
+
+	var slashingGenState slashingtypes.GenesisState
+	cdc.MustUnmarshalJSON(appState[slashingtypes.ModuleName], &slashingGenState)
+	require.Equal(t, newConsAddr.String(), slashingGenState.SigningInfos[1].Address)
+	require.Equal(t, newConsAddr.String(), slashingGenState.SigningInfos[1].ValidatorSigningInfo.Address)
+	require.Equal(t, newConsAddr.String(), slashingGenState.MissedBlocks[1].Address)
+	require.Equal(t, sdk.ConsAddress(oldKeys[0].Address()).String(), slashingGenState.SigningInfos[0].Address)
+
+	require.Equal(t, newKey.Address().Bytes(), genDoc.Validators[1].Address.Bytes())
+	require.Equal(t, oldKeys[0].Address().Bytes(), genDoc.Validators[0].Address.Bytes())
+
+	testCases := []struct {
+		name         string
+		replacements []gaia.ConsKeyReplacement
+	}{
+		{"validator listed twice", []gaia.ConsKeyReplacement{
+			{ValidatorAddress: valAddrs[0].String(), ConsensusPubkey: bech32Key(newKey)},
+			{ValidatorAddress: valAddrs[0].String(), ConsensusPubkey: bech32Key(ed25519.GenPrivKey().PubKey())},
+		}},
+		{"unknown validator", []gaia.ConsKeyReplacement{
+			{ValidatorAddress: sdk.ValAddress([]byte("unknown_____________")).String(), ConsensusPubkey: bech32Key(newKey)},
+		}},
+		{"invalid key", []gaia.ConsKeyReplacement{
+			{ValidatorAddress: valAddrs[0].String(), ConsensusPubkey: "cosmosvalconspub1invalid"},
+		}},
+		{"same new key twice", []gaia.ConsKeyReplacement{
+			{ValidatorAddress: valAddrs[0].String(), ConsensusPubkey: bech32Key(newKey)},
+			{ValidatorAddress: valAddrs[1].String(), ConsensusPubkey: bech32Key(newKey)},
+		}},
+		{"key of another validator", []gaia.ConsKeyReplacement{
+			{ValidatorAddress: valAddrs[0].String(), ConsensusPubkey: bech32Key(oldKeys[1])},
+		}},
+		{"own key", []gaia.ConsKeyReplacement{
+			{ValidatorAddress: valAddrs[0].String(), ConsensusPubkey: bech32Key(oldKeys[0])},
+		}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			genDoc := newGenDoc()
+			before, err := json.Marshal(genDoc)
+			require.NoError(t, err)
+
+			_, err = gaia.ReplaceConsensusKeys(cdc, genDoc, tc.replacements)
+			require.Error(t, err)
+
+			after, err := json.Marshal(genDoc)
+			require.NoError(t, err)
+			require.Equal(t, string(before), string(after))
+		})
+	}
+
+	// replacing a key by itself is not reported as a collision
+	_, err = gaia.ReplaceConsensusKeys(cdc, newGenDoc(), []gaia.ConsKeyReplacement{
+		{ValidatorAddress: valAddrs[0].String(), ConsensusPubkey: bech32Key(oldKeys[0])},
+	})
+	require.EqualError(t, err, fmt.Sprintf("consensus key %s is already the key of validator %s", bech32Key(oldKeys[0]), valAddrs[0]))
+}
@@ -2,27 +2,72 @@ package gaia_test
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	mathrand "math/rand"
 	"os"
 	"testing"
+	"time"
 
 	gaia "github.com/cosmos/gaia/v4/app"
+	ratelimittypes "github.com/cosmos/gaia/v4/x/ratelimit/types"
 
 	"github.com/cosmos/gaia/v4/app/helpers"
 	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/rand"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 	dbm "github.com/tendermint/tm-db"
 
 	"github.com/cosmos/cosmos-sdk/baseapp"
 	"github.com/cosmos/cosmos-sdk/simapp"
 	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/kv"
 	simulation2 "github.com/cosmos/cosmos-sdk/types/simulation"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	capabilitytypes "github.com/cosmos/cosmos-sdk/x/capability/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	evidencetypes "github.com/cosmos/cosmos-sdk/x/evidence/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	ibctransfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	ibcclienttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/02-client/types"
+	ibcconnectiontypes "github.com/cosmos/cosmos-sdk/x/ibc/core/03-connection/types"
+	ibcchanneltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	ibchost "github.com/cosmos/cosmos-sdk/x/ibc/core/24-host"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
 	"github.com/cosmos/cosmos-sdk/x/simulation"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
 )
 
+// flagWeightsFile is the path of a JSON file of simulation operation weights
+// overriding the Gaia default weights, e.g. {"op_weight_msg_send": 60}.
+var flagWeightsFile string
+
 func init() {
 	simapp.GetSimulatorFlags()
+	flag.StringVar(&flagWeightsFile, "Weights", "", "custom simulation operation weights JSON file path, overriding the Gaia default weights")
+}
+
+// simulationOperations returns the simulation operations of the app, weighted
+// by the weights file if any, and counted by the report.
+func simulationOperations(tb testing.TB, app *gaia.GaiaApp, config simulation2.Config, report *gaia.SimulationReport) []simulation2.WeightedOperation {
+	var weights map[string]int
+	if flagWeightsFile != "" {
+		var err error
+		weights, err = gaia.ReadSimulationWeights(flagWeightsFile)
+		require.NoError(tb, err)
+	}
+
+	ops, err := app.SimulationOperations(config, weights)
+	require.NoError(tb, err)
+
+	return report.WrapOperations(ops)
 }
 
 // Profile with:
@@ -44,17 +89,19 @@ func BenchmarkFullAppSimulation(b *testing.B) {
 	app := gaia.NewGaiaApp(logger, db, nil, true, map[int64]bool{}, gaia.DefaultNodeHome, simapp.FlagPeriodValue, gaia.MakeEncodingConfig(), simapp.EmptyAppOptions{}, interBlockCacheOpt())
 
 	// Run randomized simulation:w
+	report := gaia.NewSimulationReport()
 	_, simParams, simErr := simulation.SimulateFromSeed(
 		b,
 		os.Stdout,
 		app.BaseApp,
-		simapp.AppStateFn(app.AppCodec(), app.SimulationManager()),
+		appStateFn(app),
 		simulation2.RandomAccounts, // Replace with own random account function if using keys other than secp256k1
-		simapp.SimulationOperations(app, app.AppCodec(), config),
+		simulationOperations(b, app, config, report),
 		app.ModuleAccountAddrs(),
 		config,
 		app.AppCodec(),
 	)
+	fmt.Print(report)
 
 	// export state and simParams before the simulation error is checked
 	if err = simapp.CheckExportSimulation(app, config, simParams); err != nil {
@@ -76,6 +123,292 @@ func interBlockCacheOpt() func(*baseapp.BaseApp) {
 	return baseapp.SetInterBlockCache(store.NewCommitKVStoreCacheManager())
 }
 
+// fauxMerkleModeOpt returns a BaseApp option to use a dbStoreAdapter instead of
+// an IAVLStore for faster simulation speed.
+func fauxMerkleModeOpt(bapp *baseapp.BaseApp) {
+	bapp.SetFauxMerkleMode()
+}
+
+// appStateFn returns the simulation app state generator of the app, which adds
+// the default genesis state of the modules without simulation, e.g. globalfee,
+// to the randomized genesis state, so that their params are set as on a real
+// chain and survive an export.
+func appStateFn(app *gaia.GaiaApp) simulation2.AppStateFn {
+	randomAppStateFn := simapp.AppStateFn(app.AppCodec(), app.SimulationManager())
+
+	return func(r *mathrand.Rand, accs []simulation2.Account, config simulation2.Config) (json.RawMessage, []simulation2.Account, string, time.Time) {
+		appState, simAccs, chainID, genesisTime := randomAppStateFn(r, accs, config)
+
+		var genesisState gaia.GenesisState
+		if err := json.Unmarshal(appState, &genesisState); err != nil {
+			panic(err)
+		}
+
+		for module, state := range gaia.ModuleBasics.DefaultGenesis(app.AppCodec()) {
+			if _, ok := genesisState[module]; !ok {
+				genesisState[module] = state
+			}
+		}
+
+		appState, err := json.Marshal(genesisState)
+		if err != nil {
+			panic(err)
+		}
+
+		return appState, simAccs, chainID, genesisTime
+	}
+}
+
+// storeKeysPrefixes are the stores compared after an import, with the prefixes
+// of the keys which are not compared.
+var storeKeysPrefixes = []struct {
+	Store    string
+	Prefixes [][]byte
+}{
+	{authtypes.StoreKey, nil},
+	{banktypes.StoreKey, [][]byte{banktypes.BalancesPrefix}},
+	{stakingtypes.StoreKey, [][]byte{
+		stakingtypes.UnbondingQueueKey, stakingtypes.RedelegationQueueKey, stakingtypes.ValidatorQueueKey,
+		stakingtypes.HistoricalInfoKey,
+	}}, // ordering may change but it doesn't matter
+	{minttypes.StoreKey, nil},
+	{distrtypes.StoreKey, nil},
+	{slashingtypes.StoreKey, nil},
+	{govtypes.StoreKey, nil},
+	{paramstypes.StoreKey, nil},
+	{ibchost.StoreKey, [][]byte{
+		[]byte(ibcclienttypes.KeyNextClientSequence), []byte(ibcconnectiontypes.KeyNextConnectionSequence),
+		[]byte(ibcchanneltypes.KeyNextChannelSequence),
+	}}, // the next identifier sequences are not exported
+	{upgradetypes.StoreKey, nil},
+	{evidencetypes.StoreKey, nil},
+	{ibctransfertypes.StoreKey, nil},
+	{capabilitytypes.StoreKey, nil},
+	{ratelimittypes.StoreKey, nil},
+}
+
+// simulationLog returns the mismatched key/value pairs of a store, decoded by
+// the store decoder of the module if it can decode them.
+func simulationLog(storeName string, decoders sdk.StoreDecoderRegistry, kvAs, kvBs []kv.Pair) string {
+	var out string
+	for i := range kvAs {
+		out += fmt.Sprintf("store %s, key %X:\n", storeName, kvAs[i].Key)
+		if decoded, ok := gaia.DecodeKVPair(decoders[storeName], kvAs[i], kvBs[i]); ok {
+			out += decoded + "\n"
+		} else {
+			out += fmt.Sprintf("%X\n%X\n", kvAs[i].Value, kvBs[i].Value)
+		}
+	}
+
+	return out
+}
+
+func TestFullAppSimulation(t *testing.T) {
+	config, db, dir, logger, skip, err := simapp.SetupSimulation("leveldb-app-sim", "Simulation")
+	if skip {
+		t.Skip("skipping application simulation")
+	}
+	require.NoError(t, err, "simulation setup failed")
+
+	defer func() {
+		db.Close()
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	app := gaia.NewGaiaApp(logger, db, nil, true, map[int64]bool{}, gaia.DefaultNodeHome, simapp.FlagPeriodValue, gaia.MakeEncodingConfig(), simapp.EmptyAppOptions{}, fauxMerkleModeOpt)
+	require.Equal(t, "GaiaApp", app.Name())
+
+	// run randomized simulation
+	report := gaia.NewSimulationReport()
+	_, simParams, simErr := simulation.SimulateFromSeed(
+		t,
+		os.Stdout,
+		app.BaseApp,
+		appStateFn(app),
+		simulation2.RandomAccounts, // Replace with own random account function if using keys other than secp256k1
+		simulationOperations(t, app, config, report),
+		app.ModuleAccountAddrs(),
+		config,
+		app.AppCodec(),
+	)
+	fmt.Print(report)
+
+	// export state and simParams before the simulation error is checked
+	err = simapp.CheckExportSimulation(app, config, simParams)
+	require.NoError(t, err)
+	require.NoError(t, simErr)
+
+	if config.Commit {
+		simapp.PrintStats(db)
+	}
+}
+
+func TestAppImportExport(t *testing.T) {
+	config, db, dir, logger, skip, err := simapp.SetupSimulation("leveldb-app-sim", "Simulation")
+	if skip {
+		t.Skip("skipping application import/export simulation")
+	}
+	require.NoError(t, err, "simulation setup failed")
+
+	defer func() {
+		db.Close()
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	app := gaia.NewGaiaApp(logger, db, nil, true, map[int64]bool{}, gaia.DefaultNodeHome, simapp.FlagPeriodValue, gaia.MakeEncodingConfig(), simapp.EmptyAppOptions{}, fauxMerkleModeOpt)
+	require.Equal(t, "GaiaApp", app.Name())
+
+	// Run randomized simulation
+	report := gaia.NewSimulationReport()
+	_, simParams, simErr := simulation.SimulateFromSeed(
+		t,
+		os.Stdout,
+		app.BaseApp,
+		appStateFn(app),
+		simulation2.RandomAccounts, // Replace with own random account function if using keys other than secp256k1
+		simulationOperations(t, app, config, report),
+		app.ModuleAccountAddrs(),
+		config,
+		app.AppCodec(),
+	)
+	fmt.Print(report)
+
+	// export state and simParams before the simulation error is checked
+	err = simapp.CheckExportSimulation(app, config, simParams)
+	require.NoError(t, err)
+	require.NoError(t, simErr)
+
+	if config.Commit {
+		simapp.PrintStats(db)
+	}
+
+	fmt.Printf("exporting genesis...\n")
+
+	exported, err := app.ExportAppStateAndValidators(false, []string{})
+	require.NoError(t, err)
+
+	fmt.Printf("importing genesis...\n")
+
+	_, newDB, newDir, _, _, err := simapp.SetupSimulation("leveldb-app-sim-2", "Simulation-2")
+	require.NoError(t, err, "simulation setup failed")
+
+	defer func() {
+		newDB.Close()
+		require.NoError(t, os.RemoveAll(newDir))
+	}()
+
+	newApp := gaia.NewGaiaApp(log.NewNopLogger(), newDB, nil, true, map[int64]bool{}, gaia.DefaultNodeHome, simapp.FlagPeriodValue, gaia.MakeEncodingConfig(), simapp.EmptyAppOptions{}, fauxMerkleModeOpt)
+	require.Equal(t, "GaiaApp", newApp.Name())
+
+	// the genesis state is imported by InitChain and committed, as when a chain
+	// is restarted from the exported genesis file
+	newApp.InitChain(abci.RequestInitChain{
+		ChainId:         config.ChainID,
+		InitialHeight:   exported.Height,
+		AppStateBytes:   exported.AppState,
+		ConsensusParams: exported.ConsensusParams,
+	})
+	newApp.Commit()
+
+	ctxA := app.NewContext(true, tmproto.Header{Height: app.LastBlockHeight()})
+	ctxB := newApp.NewContext(true, tmproto.Header{Height: app.LastBlockHeight()})
+
+	fmt.Printf("comparing stores...\n")
+
+	for _, skp := range storeKeysPrefixes {
+		storeA := ctxA.KVStore(app.GetKey(skp.Store))
+		storeB := ctxB.KVStore(newApp.GetKey(skp.Store))
+
+		failedKVAs, failedKVBs := sdk.DiffKVStores(storeA, storeB, skp.Prefixes)
+		require.Equal(t, len(failedKVAs), len(failedKVBs), "unequal sets of key-values to compare")
+
+		fmt.Printf("compared %d different key/value pairs of store %s\n", len(failedKVAs), skp.Store)
+		require.Equal(t, len(failedKVAs), 0, simulationLog(skp.Store, app.SimulationManager().StoreDecoders, failedKVAs, failedKVBs))
+	}
+}
+
+func TestAppSimulationAfterImport(t *testing.T) {
+	config, db, dir, logger, skip, err := simapp.SetupSimulation("leveldb-app-sim", "Simulation")
+	if skip {
+		t.Skip("skipping application simulation after import")
+	}
+	require.NoError(t, err, "simulation setup failed")
+
+	defer func() {
+		db.Close()
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	app := gaia.NewGaiaApp(logger, db, nil, true, map[int64]bool{}, gaia.DefaultNodeHome, simapp.FlagPeriodValue, gaia.MakeEncodingConfig(), simapp.EmptyAppOptions{}, fauxMerkleModeOpt)
+	require.Equal(t, "GaiaApp", app.Name())
+
+	// Run randomized simulation
+	report := gaia.NewSimulationReport()
+	stopEarly, simParams, simErr := simulation.SimulateFromSeed(
+		t,
+		os.Stdout,
+		app.BaseApp,
+		appStateFn(app),
+		simulation2.RandomAccounts, // Replace with own random account function if using keys other than secp256k1
+		simulationOperations(t, app, config, report),
+		app.ModuleAccountAddrs(),
+		config,
+		app.AppCodec(),
+	)
+	fmt.Print(report)
+
+	// export state and simParams before the simulation error is checked
+	err = simapp.CheckExportSimulation(app, config, simParams)
+	require.NoError(t, err)
+	require.NoError(t, simErr)
+
+	if config.Commit {
+		simapp.PrintStats(db)
+	}
+
+	if stopEarly {
+		fmt.Println("can't export or import a zero-validator genesis, exiting test...")
+		return
+	}
+
+	fmt.Printf("exporting genesis...\n")
+
+	exported, err := app.ExportAppStateAndValidators(true, []string{})
+	require.NoError(t, err)
+
+	fmt.Printf("importing genesis...\n")
+
+	_, newDB, newDir, _, _, err := simapp.SetupSimulation("leveldb-app-sim-2", "Simulation-2")
+	require.NoError(t, err, "simulation setup failed")
+
+	defer func() {
+		newDB.Close()
+		require.NoError(t, os.RemoveAll(newDir))
+	}()
+
+	newApp := gaia.NewGaiaApp(log.NewNopLogger(), newDB, nil, true, map[int64]bool{}, gaia.DefaultNodeHome, simapp.FlagPeriodValue, gaia.MakeEncodingConfig(), simapp.EmptyAppOptions{}, fauxMerkleModeOpt)
+	require.Equal(t, "GaiaApp", newApp.Name())
+
+	newApp.InitChain(abci.RequestInitChain{
+		AppStateBytes: exported.AppState,
+	})
+
+	report = gaia.NewSimulationReport()
+	_, _, err = simulation.SimulateFromSeed(
+		t,
+		os.Stdout,
+		newApp.BaseApp,
+		appStateFn(app),
+		simulation2.RandomAccounts, // Replace with own random account function if using keys other than secp256k1
+		simulationOperations(t, newApp, config, report),
+		app.ModuleAccountAddrs(),
+		config,
+		app.AppCodec(),
+	)
+	fmt.Print(report)
+	require.NoError(t, err)
+}
+
 //// TODO: Make another test for the fuzzer itself, which just has noOp txs
 //// and doesn't depend on the application.
 func TestAppStateDeterminism(t *testing.T) {
@@ -113,17 +446,19 @@ func TestAppStateDeterminism(t *testing.T) {
 				config.Seed, i+1, numSeeds, j+1, numTimesToRunPerSeed,
 			)
 
+			report := gaia.NewSimulationReport()
 			_, _, err := simulation.SimulateFromSeed(
 				t,
 				os.Stdout,
 				app.BaseApp,
-				simapp.AppStateFn(app.AppCodec(), app.SimulationManager()),
+				appStateFn(app),
 				simulation2.RandomAccounts, // Replace with own random account function if using keys other than secp256k1
-				simapp.SimulationOperations(app, app.AppCodec(), config),
+				simulationOperations(t, app, config, report),
 				app.ModuleAccountAddrs(),
 				config,
 				app.AppCodec(),
 			)
+			fmt.Print(report)
 			require.NoError(t, err)
 
 			if config.Commit {
@@ -0,0 +1,278 @@
+package gaia
+
+// This file implements the Gaia simulation operations, whose weights default
+// to the ones of app/params rather than to the ones of the SDK simulation app,
+// and the report of the operations of a simulation run.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
+	banksim "github.com/cosmos/cosmos-sdk/x/bank/simulation"
+	distrsim "github.com/cosmos/cosmos-sdk/x/distribution/simulation"
+	govsim "github.com/cosmos/cosmos-sdk/x/gov/simulation"
+	paramsim "github.com/cosmos/cosmos-sdk/x/params/simulation"
+	"github.com/cosmos/cosmos-sdk/x/simulation"
+	slashingsim "github.com/cosmos/cosmos-sdk/x/slashing/simulation"
+	stakingsim "github.com/cosmos/cosmos-sdk/x/staking/simulation"
+	"github.com/pkg/errors"
+
+	"github.com/cosmos/gaia/v4/app/ibcsim"
+	"github.com/cosmos/gaia/v4/app/params"
+)
+
+// DefaultSimulationWeights returns the default weights of the simulation
+// operations, by the key of their weight in the simulation params.
+func DefaultSimulationWeights() map[string]int {
+	return map[string]int{
+		banksim.OpWeightMsgSend:                         params.DefaultWeightMsgSend,
+		banksim.OpWeightMsgMultiSend:                    params.DefaultWeightMsgMultiSend,
+		distrsim.OpWeightMsgSetWithdrawAddress:          params.DefaultWeightMsgSetWithdrawAddress,
+		distrsim.OpWeightMsgWithdrawDelegationReward:    params.DefaultWeightMsgWithdrawDelegationReward,
+		distrsim.OpWeightMsgWithdrawValidatorCommission: params.DefaultWeightMsgWithdrawValidatorCommission,
+		distrsim.OpWeightMsgFundCommunityPool:           params.DefaultWeightMsgFundCommunityPool,
+		govsim.OpWeightMsgDeposit:                       params.DefaultWeightMsgDeposit,
+		govsim.OpWeightMsgVote:                          params.DefaultWeightMsgVote,
+		slashingsim.OpWeightMsgUnjail:                   params.DefaultWeightMsgUnjail,
+		stakingsim.OpWeightMsgCreateValidator:           params.DefaultWeightMsgCreateValidator,
+		stakingsim.OpWeightMsgEditValidator:             params.DefaultWeightMsgEditValidator,
+		stakingsim.OpWeightMsgDelegate:                  params.DefaultWeightMsgDelegate,
+		stakingsim.OpWeightMsgUndelegate:                params.DefaultWeightMsgUndelegate,
+		stakingsim.OpWeightMsgBeginRedelegate:           params.DefaultWeightMsgBeginRedelegate,
+		ibcsim.OpWeightMsgTransfer:                      params.DefaultWeightMsgTransfer,
+		ibcsim.OpWeightMsgRecvPacket:                    params.DefaultWeightMsgRecvPacket,
+		ibcsim.OpWeightMsgAcknowledgement:               params.DefaultWeightMsgAcknowledgement,
+		ibcsim.OpWeightMsgTimeout:                       params.DefaultWeightMsgTimeout,
+
+		distrsim.OpWeightSubmitCommunitySpendProposal: params.DefaultWeightCommunitySpendProposal,
+		govsim.OpWeightSubmitTextProposal:             params.DefaultWeightTextProposal,
+		paramsim.OpWeightSubmitParamChangeProposal:    params.DefaultWeightParamChangeProposal,
+	}
+}
+
+// ReadSimulationWeights reads a JSON file of simulation operation weights by
+// weight key, e.g. {"op_weight_msg_send": 60}. Every key must be one of the
+// default weights, so that a misspelled key does not go unnoticed.
+func ReadSimulationWeights(path string) (map[string]int, error) {
+	bz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var weights map[string]int
+	if err := json.Unmarshal(bz, &weights); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode simulation weights file %s", path)
+	}
+
+	defaults := DefaultSimulationWeights()
+	for key, weight := range weights {
+		if _, ok := defaults[key]; !ok {
+			return nil, fmt.Errorf("unknown simulation operation weight %s in %s", key, path)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("negative simulation operation weight %s in %s", key, path)
+		}
+	}
+
+	return weights, nil
+}
+
+// SimulationOperations returns the weighted operations of the simulation
+// manager of the app. Their weights are the Gaia defaults, overridden by the
+// simulation params file of the config, as with the SDK simulator, and then by
+// the weights.
+func (app *GaiaApp) SimulationOperations(config simtypes.Config, weights map[string]int) ([]simtypes.WeightedOperation, error) {
+	simState := module.SimulationState{
+		AppParams: make(simtypes.AppParams),
+		Cdc:       app.AppCodec(),
+	}
+
+	for key, weight := range DefaultSimulationWeights() {
+		simState.AppParams[key] = json.RawMessage(strconv.Itoa(weight))
+	}
+
+	if config.ParamsFile != "" {
+		bz, err := ioutil.ReadFile(config.ParamsFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(bz, &simState.AppParams); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode simulation params file %s", config.ParamsFile)
+		}
+	}
+
+	for key, weight := range weights {
+		simState.AppParams[key] = json.RawMessage(strconv.Itoa(weight))
+	}
+
+	simState.ParamChanges = app.SimulationManager().GenerateParamChanges(config.Seed)
+	simState.Contents = app.SimulationManager().GetProposalContents(simState)
+
+	return app.SimulationManager().WeightedOperations(simState), nil
+}
+
+// OperationStats counts the simulated operations of a message.
+type OperationStats struct {
+	Route string `json:"route"`
+	Msg   string `json:"msg"`
+
+	// Attempted counts all the operations, which either succeeded, were
+	// skipped or failed with an error, failing the simulation.
+	Attempted int `json:"attempted"`
+	Succeeded int `json:"succeeded"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+
+	// SkipReasons counts the skipped operations by the comment of the
+	// operation, e.g. "no spendable coins".
+	SkipReasons map[string]int `json:"skip_reasons,omitempty"`
+}
+
+// SimulationReport counts the operations of a simulation run by message, to
+// tune the simulation weights towards realistic traffic. The operations are
+// counted by wrapping them with WrapOperations.
+type SimulationReport struct {
+	stats map[string]*OperationStats
+}
+
+// NewSimulationReport returns an empty simulation report.
+func NewSimulationReport() *SimulationReport {
+	return &SimulationReport{stats: make(map[string]*OperationStats)}
+}
+
+// WrapOperations returns the operations, and the future operations they
+// schedule, counted by the report.
+func (r *SimulationReport) WrapOperations(ops []simtypes.WeightedOperation) []simtypes.WeightedOperation {
+	wrapped := make([]simtypes.WeightedOperation, len(ops))
+	for i, op := range ops {
+		wrapped[i] = simulation.NewWeightedOperation(op.Weight(), r.wrap(op.Op()))
+	}
+
+	return wrapped
+}
+
+func (r *SimulationReport) wrap(op simtypes.Operation) simtypes.Operation {
+	return func(
+		rnd *rand.Rand, app *baseapp.BaseApp, ctx sdk.Context, accs []simtypes.Account, chainID string,
+	) (simtypes.OperationMsg, []simtypes.FutureOperation, error) {
+		opMsg, futureOps, err := op(rnd, app, ctx, accs, chainID)
+		r.Record(opMsg, err)
+
+		for i := range futureOps {
+			futureOps[i].Op = r.wrap(futureOps[i].Op)
+		}
+
+		return opMsg, futureOps, err
+	}
+}
+
+// Record counts an operation by the message it returned: it succeeded if the
+// message is OK, failed if it returned an error and was skipped otherwise.
+func (r *SimulationReport) Record(opMsg simtypes.OperationMsg, err error) {
+	key := opMsg.Route + "/" + opMsg.Name
+	stats, ok := r.stats[key]
+	if !ok {
+		stats = &OperationStats{Route: opMsg.Route, Msg: opMsg.Name, SkipReasons: make(map[string]int)}
+		r.stats[key] = stats
+	}
+
+	stats.Attempted++
+	switch {
+	case err != nil:
+		stats.Failed++
+	case opMsg.OK:
+		stats.Succeeded++
+	default:
+		stats.Skipped++
+		reason := opMsg.Comment
+		if reason == "" {
+			reason = "no reason given"
+		}
+		stats.SkipReasons[reason]++
+	}
+}
+
+// Stats returns the counts of the operations by message, sorted by route and
+// message.
+func (r *SimulationReport) Stats() []OperationStats {
+	stats := make([]OperationStats, 0, len(r.stats))
+	for _, s := range r.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Route != stats[j].Route {
+			return stats[i].Route < stats[j].Route
+		}
+		return stats[i].Msg < stats[j].Msg
+	})
+
+	return stats
+}
+
+// String returns a human-readable report, with the share of each message in
+// the attempted operations and the skip reasons by decreasing count.
+func (r *SimulationReport) String() string {
+	var sb strings.Builder
+	stats := r.Stats()
+
+	total := OperationStats{}
+	for _, s := range stats {
+		total.Attempted += s.Attempted
+		total.Succeeded += s.Succeeded
+		total.Skipped += s.Skipped
+		total.Failed += s.Failed
+	}
+
+	fmt.Fprintln(&sb, "OPERATIONS")
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  MSG\tATTEMPTED\tSHARE\tSUCCEEDED\tSKIPPED\tFAILED")
+	for _, s := range append(stats, total) {
+		name := s.Route + "/" + s.Msg
+		if s.Route == "" && s.Msg == "" {
+			name = "total"
+		}
+		share := 0.0
+		if total.Attempted > 0 {
+			share = 100 * float64(s.Attempted) / float64(total.Attempted)
+		}
+		fmt.Fprintf(tw, "  %s\t%d\t%.1f%%\t%d\t%d\t%d\n", name, s.Attempted, share, s.Succeeded, s.Skipped, s.Failed)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(&sb, "\nSKIPPED")
+	if total.Skipped == 0 {
+		fmt.Fprintln(&sb, "  none")
+	}
+	for _, s := range stats {
+		if s.Skipped == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s/%s\n", s.Route, s.Msg)
+
+		reasons := make([]string, 0, len(s.SkipReasons))
+		for reason := range s.SkipReasons {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool {
+			if s.SkipReasons[reasons[i]] != s.SkipReasons[reasons[j]] {
+				return s.SkipReasons[reasons[i]] > s.SkipReasons[reasons[j]]
+			}
+			return reasons[i] < reasons[j]
+		})
+		for _, reason := range reasons {
+			fmt.Fprintf(&sb, "    %6d  %s\n", s.SkipReasons[reason], reason)
+		}
+	}
+
+	return sb.String()
+}
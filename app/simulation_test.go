@@ -0,0 +1,83 @@
+package gaia_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
+	"github.com/cosmos/cosmos-sdk/x/simulation"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+	"github.com/cosmos/gaia/v4/app/params"
+)
+
+func TestReadSimulationWeights(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "weights.json")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"op_weight_msg_send": 60, "op_weight_msg_transfer": 0}`), 0644))
+	weights, err := gaia.ReadSimulationWeights(path)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"op_weight_msg_send": 60, "op_weight_msg_transfer": 0}, weights)
+	require.Equal(t, params.DefaultWeightMsgSend, gaia.DefaultSimulationWeights()["op_weight_msg_send"])
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"op_weight_msg_sned": 60}`), 0644))
+	_, err = gaia.ReadSimulationWeights(path)
+	require.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"op_weight_msg_send": -1}`), 0644))
+	_, err = gaia.ReadSimulationWeights(path)
+	require.Error(t, err)
+
+	_, err = gaia.ReadSimulationWeights(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+}
+
+func TestSimulationReport(t *testing.T) {
+	report := gaia.NewSimulationReport()
+
+	// the send operation schedules a vote, which is skipped
+	send := func(*rand.Rand, *baseapp.BaseApp, sdk.Context, []simtypes.Account, string) (simtypes.OperationMsg, []simtypes.FutureOperation, error) {
+		vote := func(*rand.Rand, *baseapp.BaseApp, sdk.Context, []simtypes.Account, string) (simtypes.OperationMsg, []simtypes.FutureOperation, error) {
+			return simtypes.NoOpMsg("gov", "vote", "no proposal"), nil, nil
+		}
+		return simtypes.NewOperationMsgBasic("bank", "send", "", true, nil), []simtypes.FutureOperation{{BlockHeight: 2, Op: vote}}, nil
+	}
+
+	ops := report.WrapOperations([]simtypes.WeightedOperation{simulation.NewWeightedOperation(10, send)})
+	require.Len(t, ops, 1)
+	require.Equal(t, 10, ops[0].Weight())
+
+	_, futureOps, err := ops[0].Op()(nil, nil, sdk.Context{}, nil, "")
+	require.NoError(t, err)
+	_, _, err = futureOps[0].Op(nil, nil, sdk.Context{}, nil, "")
+	require.NoError(t, err)
+
+	report.Record(simtypes.NoOpMsg("bank", "send", "no spendable coins"), nil)
+	report.Record(simtypes.NoOpMsg("bank", "send", "no spendable coins"), nil)
+	report.Record(simtypes.NoOpMsg("bank", "send", ""), nil)
+	report.Record(simtypes.NoOpMsg("bank", "send", "unable to deliver tx"), errors.New("out of gas"))
+
+	require.Equal(t, []gaia.OperationStats{
+		{
+			Route: "bank", Msg: "send", Attempted: 5, Succeeded: 1, Skipped: 3, Failed: 1,
+			SkipReasons: map[string]int{"no spendable coins": 2, "no reason given": 1},
+		},
+		{
+			Route: "gov", Msg: "vote", Attempted: 1, Skipped: 1,
+			SkipReasons: map[string]int{"no proposal": 1},
+		},
+	}, report.Stats())
+
+	out := report.String()
+	require.Regexp(t, `bank/send\s+5\s+83.3%\s+1\s+3\s+1`, out)
+	require.Regexp(t, `total\s+6\s+100.0%\s+1\s+4\s+1`, out)
+	require.Regexp(t, `2  no spendable coins\n\s+1  no reason given`, out)
+}
@@ -0,0 +1,362 @@
+package gaia
+
+// This file implements the state diff of the debug state-diff command, which
+// compares two heights of the application DB store by store, or two genesis
+// files module by module.
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/cosmos/cosmos-sdk/store/iavl"
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/kv"
+)
+
+// State diff entry changes.
+const (
+	StateDiffAdded   = "added"
+	StateDiffRemoved = "removed"
+	StateDiffChanged = "changed"
+)
+
+// StateDiffEntry is a key added, removed or changed between two states. Before
+// is empty for added keys and After is empty for removed ones.
+type StateDiffEntry struct {
+	// Key is the hex-encoded store key, or the JSON path of a genesis value.
+	Key string `json:"key"`
+	// Change is one of StateDiffAdded, StateDiffRemoved and StateDiffChanged.
+	Change string `json:"change"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	// Decoded is the value change as decoded by the store decoder of the
+	// module, if any.
+	Decoded string `json:"decoded,omitempty"`
+}
+
+// ModuleStateDiff is the diff of a store, or of the genesis state of a module.
+type ModuleStateDiff struct {
+	Module  string `json:"module"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Changed int    `json:"changed"`
+	// Entries lists the differences, up to the limit of the diff.
+	Entries []StateDiffEntry `json:"entries"`
+}
+
+// add adds the entry of a key present in the first state if inA, and in the
+// second one if inB.
+func (d *ModuleStateDiff) add(entry StateDiffEntry, inA, inB bool, limit int) {
+	switch {
+	case !inA:
+		entry.Change = StateDiffAdded
+		d.Added++
+	case !inB:
+		entry.Change = StateDiffRemoved
+		d.Removed++
+	default:
+		entry.Change = StateDiffChanged
+		d.Changed++
+	}
+
+	if limit <= 0 || len(d.Entries) < limit {
+		d.Entries = append(d.Entries, entry)
+	}
+}
+
+// DiffHeights compares the stores of the application DB at two heights and
+// returns the diff of every store that differs, sorted by store name. Up to
+// limit entries are listed per store, all of them if limit is not positive.
+// Changed values are decoded with the store decoders of the simulation
+// manager.
+func (app *GaiaApp) DiffHeights(db dbm.DB, heightA, heightB int64, limit int) ([]ModuleStateDiff, error) {
+	cms := rootmulti.NewStore(db)
+	for _, key := range app.keys {
+		cms.MountStoreWithDB(key, sdk.StoreTypeIAVL, nil)
+	}
+	if err := cms.LoadLatestVersion(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(app.keys))
+	for name := range app.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	storesA, err := storesAtHeight(cms, app.keys, heightA)
+	if err != nil {
+		return nil, err
+	}
+	storesB, err := storesAtHeight(cms, app.keys, heightB)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []ModuleStateDiff
+	for _, name := range names {
+		diff := diffKVStores(name, storesA[name], storesB[name], app.sm.StoreDecoders[name], limit)
+		if diff.Added+diff.Removed+diff.Changed > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs, nil
+}
+
+// storesAtHeight returns the stores at a height, by name. Stores without the
+// height, e.g. added by a later upgrade, are left out, but the height must
+// exist in at least one store.
+func storesAtHeight(cms *rootmulti.Store, keys map[string]*sdk.KVStoreKey, height int64) (map[string]sdk.KVStore, error) {
+	stores := make(map[string]sdk.KVStore, len(keys))
+	for name, key := range keys {
+		store := cms.GetCommitKVStore(key).(*iavl.Store)
+		if !store.VersionExists(height) {
+			continue
+		}
+
+		immutable, err := store.GetImmutable(height)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load store %s at height %d: %w", name, height, err)
+		}
+		stores[name] = immutable
+	}
+
+	if len(stores) == 0 {
+		return nil, fmt.Errorf("height %d not found in the application DB; it may have been pruned", height)
+	}
+
+	return stores, nil
+}
+
+// diffKVStores compares two stores, either of which may be nil, by iterating
+// both in key order.
+func diffKVStores(name string, a, b sdk.KVStore, decoder func(kvA, kvB kv.Pair) string, limit int) ModuleStateDiff {
+	diff := ModuleStateDiff{Module: name}
+
+	iterA, iterB := kvIterator(a), kvIterator(b)
+	defer iterA.Close()
+	defer iterB.Close()
+
+	for iterA.Valid() || iterB.Valid() {
+		var (
+			pairA, pairB kv.Pair
+			inA, inB     = true, true
+		)
+		switch {
+		case !iterB.Valid() || (iterA.Valid() && bytes.Compare(iterA.Key(), iterB.Key()) < 0):
+			pairA = kv.Pair{Key: iterA.Key(), Value: iterA.Value()}
+			pairB.Key = pairA.Key
+			inB = false
+			iterA.Next()
+		case !iterA.Valid() || bytes.Compare(iterA.Key(), iterB.Key()) > 0:
+			pairB = kv.Pair{Key: iterB.Key(), Value: iterB.Value()}
+			pairA.Key = pairB.Key
+			inA = false
+			iterB.Next()
+		default:
+			pairA = kv.Pair{Key: iterA.Key(), Value: iterA.Value()}
+			pairB = kv.Pair{Key: iterB.Key(), Value: iterB.Value()}
+			iterA.Next()
+			iterB.Next()
+			if bytes.Equal(pairA.Value, pairB.Value) {
+				continue
+			}
+		}
+
+		decoded, _ := DecodeKVPair(decoder, pairA, pairB)
+		diff.add(StateDiffEntry{
+			Key:     hex.EncodeToString(pairA.Key),
+			Before:  hex.EncodeToString(pairA.Value),
+			After:   hex.EncodeToString(pairB.Value),
+			Decoded: decoded,
+		}, inA, inB, limit)
+	}
+
+	return diff
+}
+
+// emptyIterator is the iterator of a missing store.
+type emptyIterator struct{ dbm.Iterator }
+
+func (emptyIterator) Valid() bool  { return false }
+func (emptyIterator) Close() error { return nil }
+
+func kvIterator(store sdk.KVStore) sdk.Iterator {
+	if store == nil {
+		return emptyIterator{}
+	}
+
+	return store.Iterator(nil, nil)
+}
+
+// DecodeKVPair decodes a value change with the store decoder of a module, which
+// panics on keys it does not know. It returns false if there is no decoder or
+// if the change cannot be decoded.
+func DecodeKVPair(decoder func(kvA, kvB kv.Pair) string, kvA, kvB kv.Pair) (decoded string, ok bool) {
+	if decoder == nil {
+		return "", false
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			decoded, ok = "", false
+		}
+	}()
+
+	return decoder(kvA, kvB), true
+}
+
+// DiffGenesisStates compares two genesis states module by module, by the JSON
+// paths of their values, and returns the diff of every module that differs,
+// sorted by module name. Up to limit entries are listed per module, all of
+// them if limit is not positive.
+func DiffGenesisStates(a, b map[string]json.RawMessage, limit int) ([]ModuleStateDiff, error) {
+	seen := make(map[string]bool)
+	var modules []string
+	for _, state := range []map[string]json.RawMessage{a, b} {
+		for module := range state {
+			if !seen[module] {
+				seen[module] = true
+				modules = append(modules, module)
+			}
+		}
+	}
+	sort.Strings(modules)
+
+	var diffs []ModuleStateDiff
+	for _, module := range modules {
+		valuesA, err := jsonPaths(a[module])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s genesis state: %w", module, err)
+		}
+		valuesB, err := jsonPaths(b[module])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s genesis state: %w", module, err)
+		}
+
+		paths := make([]string, 0, len(valuesA)+len(valuesB))
+		for path := range valuesA {
+			paths = append(paths, path)
+		}
+		for path := range valuesB {
+			if _, ok := valuesA[path]; !ok {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+
+		diff := ModuleStateDiff{Module: module}
+		for _, path := range paths {
+			valueA, inA := valuesA[path]
+			valueB, inB := valuesB[path]
+			if !inA || !inB || valueA != valueB {
+				diff.add(StateDiffEntry{Key: path, Before: valueA, After: valueB}, inA, inB, limit)
+			}
+		}
+
+		if diff.Added+diff.Removed+diff.Changed > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs, nil
+}
+
+// jsonPaths returns the JSON-encoded leaf values of a JSON document by path,
+// array elements being indexed by position. Empty objects and arrays and nulls
+// are leaves too, so that e.g. {"a":[]} and {} differ, except for the document
+// itself.
+func jsonPaths(bz json.RawMessage) (map[string]string, error) {
+	paths := make(map[string]string)
+	if len(bz) == 0 {
+		return paths, nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(bz, &v); err != nil {
+		return nil, err
+	}
+
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if len(v) == 0 && prefix != "" {
+				paths[prefix] = "{}"
+			}
+			for k, child := range v {
+				walk(joinPath(prefix, k), child)
+			}
+		case []interface{}:
+			if len(v) == 0 && prefix != "" {
+				paths[prefix] = "[]"
+			}
+			for i, child := range v {
+				walk(joinPath(prefix, strconv.Itoa(i)), child)
+			}
+		case nil:
+			if prefix != "" {
+				paths[prefix] = "null"
+			}
+		default:
+			value, _ := json.Marshal(v)
+			paths[prefix] = string(value)
+		}
+	}
+	walk("", v)
+
+	return paths, nil
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+// PrintStateDiff prints a per-module report of the diffs.
+func PrintStateDiff(w io.Writer, diffs []ModuleStateDiff) error {
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintln(w, "no differences")
+		return err
+	}
+
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s: %d added, %d removed, %d changed\n", d.Module, d.Added, d.Removed, d.Changed)
+		for _, e := range d.Entries {
+			marker, value := "~", e.Before+" -> "+e.After
+			switch e.Change {
+			case StateDiffAdded:
+				marker, value = "+", e.After
+			case StateDiffRemoved:
+				marker, value = "-", e.Before
+			}
+
+			if e.Decoded != "" {
+				fmt.Fprintf(w, "  %s %s\n    %s\n", marker, e.Key, indent(e.Decoded))
+			} else {
+				fmt.Fprintf(w, "  %s %s: %s\n", marker, e.Key, value)
+			}
+		}
+		if shown := len(d.Entries); shown < d.Added+d.Removed+d.Changed {
+			fmt.Fprintf(w, "  ... %d more\n", d.Added+d.Removed+d.Changed-shown)
+		}
+	}
+
+	return nil
+}
+
+func indent(s string) string {
+	return string(bytes.ReplaceAll(bytes.TrimSpace([]byte(s)), []byte("\n"), []byte("\n    ")))
+}
@@ -0,0 +1,143 @@
+package gaia_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/cosmos/cosmos-sdk/simapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/kv"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestDiffHeights(t *testing.T) {
+	db := dbm.NewMemDB()
+	app := gaia.NewGaiaApp(log.NewNopLogger(), db, nil, true, map[int64]bool{}, t.TempDir(), 0, gaia.MakeEncodingConfig(), simapp.EmptyAppOptions{})
+
+	appState, err := json.Marshal(gaia.NewDefaultGenesisState())
+	require.NoError(t, err)
+
+	app.InitChain(abci.RequestInitChain{
+		Validators:      []abci.ValidatorUpdate{},
+		AppStateBytes:   appState,
+		ConsensusParams: simapp.DefaultConsensusParams,
+	})
+	app.Commit()
+
+	header := tmproto.Header{Height: app.LastBlockHeight() + 1}
+	app.BeginBlock(abci.RequestBeginBlock{Header: header})
+	addr := sdk.AccAddress([]byte("addr________________"))
+	require.NoError(t, app.BankKeeper.SetBalances(app.NewContext(false, header), addr, sdk.NewCoins(sdk.NewInt64Coin("uatom", 100))))
+	app.EndBlock(abci.RequestEndBlock{Height: header.Height})
+	app.Commit()
+
+	diffs, err := app.DiffHeights(db, 1, 2, 0)
+	require.NoError(t, err)
+
+	var bank *gaia.ModuleStateDiff
+	for i := range diffs {
+		if diffs[i].Module == banktypes.StoreKey {
+			bank = &diffs[i]
+		}
+	}
+	require.NotNil(t, bank)
+	require.Equal(t, 1, bank.Added)
+	require.Len(t, bank.Entries, 1)
+	require.Equal(t, gaia.StateDiffAdded, bank.Entries[0].Change)
+	require.Empty(t, bank.Entries[0].Before)
+	require.NotEmpty(t, bank.Entries[0].After)
+	// the bank decoder only decodes the supply, balances are left hex-encoded
+	require.Empty(t, bank.Entries[0].Decoded)
+
+	diffs, err = app.DiffHeights(db, 2, 2, 0)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+
+	_, err = app.DiffHeights(db, 1, 10, 0)
+	require.Error(t, err)
+}
+
+func TestDiffGenesisStates(t *testing.T) {
+	a := map[string]json.RawMessage{
+		"bank": json.RawMessage(`{"balances":[{"address":"a","amount":"1"}],"params":{"enabled":true}}`),
+		"gov":  json.RawMessage(`{"starting_proposal_id":"1"}`),
+		"mint": json.RawMessage(`{"minter":{"inflation":"0.1"}}`),
+	}
+	b := map[string]json.RawMessage{
+		"bank":     json.RawMessage(`{"balances":[{"address":"a","amount":"2"},{"address":"b","amount":"3"}],"params":{}}`),
+		"gov":      json.RawMessage(`{"starting_proposal_id":"1"}`),
+		"slashing": json.RawMessage(`{"params":{"signed_blocks_window":"100"}}`),
+	}
+
+	diffs, err := gaia.DiffGenesisStates(a, b, 0)
+	require.NoError(t, err)
+	require.Len(t, diffs, 3)
+
+	require.Equal(t, "bank", diffs[0].Module)
+	require.Equal(t, 3, diffs[0].Added)
+	require.Equal(t, 1, diffs[0].Removed)
+	require.Equal(t, 1, diffs[0].Changed)
+	require.Equal(t, []gaia.StateDiffEntry{
+		{Key: "balances.0.amount", Change: gaia.StateDiffChanged, Before: `"1"`, After: `"2"`},
+		{Key: "balances.1.address", Change: gaia.StateDiffAdded, After: `"b"`},
+		{Key: "balances.1.amount", Change: gaia.StateDiffAdded, After: `"3"`},
+		{Key: "params", Change: gaia.StateDiffAdded, After: "{}"},
+		{Key: "params.enabled", Change: gaia.StateDiffRemoved, Before: "true"},
+	}, diffs[0].Entries)
+
+	require.Equal(t, "mint", diffs[1].Module)
+	require.Equal(t, 1, diffs[1].Removed)
+	require.Equal(t, "slashing", diffs[2].Module)
+	require.Equal(t, 1, diffs[2].Added)
+
+	diffs, err = gaia.DiffGenesisStates(a, b, 1)
+	require.NoError(t, err)
+	require.Len(t, diffs[0].Entries, 1)
+	require.Equal(t, 5, diffs[0].Added+diffs[0].Removed+diffs[0].Changed)
+
+	var buf bytes.Buffer
+	require.NoError(t, gaia.PrintStateDiff(&buf, diffs))
+	require.Contains(t, buf.String(), "bank: 3 added, 1 removed, 1 changed\n  ~ balances.0.amount: \"1\" -> \"2\"\n  ... 4 more\n")
+
+	diffs, err = gaia.DiffGenesisStates(a, a, 0)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+
+	// empty objects and arrays, nulls and empty strings are values
+	diffs, err = gaia.DiffGenesisStates(
+		map[string]json.RawMessage{"gov": json.RawMessage(`{"a":[],"b":null,"c":""}`)},
+		map[string]json.RawMessage{"gov": json.RawMessage(`{"b":{},"c":null}`)},
+		0,
+	)
+	require.NoError(t, err)
+	require.Equal(t, []gaia.StateDiffEntry{
+		{Key: "a", Change: gaia.StateDiffRemoved, Before: "[]"},
+		{Key: "b", Change: gaia.StateDiffChanged, Before: "null", After: "{}"},
+		{Key: "c", Change: gaia.StateDiffChanged, Before: `""`, After: "null"},
+	}, diffs[0].Entries)
+}
+
+func TestDecodeKVPair(t *testing.T) {
+	kvA, kvB := kv.Pair{Key: []byte{1}, Value: []byte{2}}, kv.Pair{Key: []byte{1}, Value: []byte{3}}
+
+	_, ok := gaia.DecodeKVPair(nil, kvA, kvB)
+	require.False(t, ok)
+
+	// store decoders panic on unknown keys
+	_, ok = gaia.DecodeKVPair(func(kv.Pair, kv.Pair) string { panic("invalid key") }, kvA, kvB)
+	require.False(t, ok)
+
+	decoded, ok := gaia.DecodeKVPair(func(kvA, kvB kv.Pair) string { return fmt.Sprintf("%X -> %X", kvA.Value, kvB.Value) }, kvA, kvB)
+	require.True(t, ok)
+	require.Equal(t, "02 -> 03", decoded)
+}
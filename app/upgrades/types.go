@@ -0,0 +1,156 @@
+package upgrades
+
+import (
+	"fmt"
+
+	store "github.com/cosmos/cosmos-sdk/store/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	authkeeper "github.com/cosmos/cosmos-sdk/x/auth/keeper"
+	bankkeeper "github.com/cosmos/cosmos-sdk/x/bank/keeper"
+	capabilitykeeper "github.com/cosmos/cosmos-sdk/x/capability/keeper"
+	crisiskeeper "github.com/cosmos/cosmos-sdk/x/crisis/keeper"
+	distrkeeper "github.com/cosmos/cosmos-sdk/x/distribution/keeper"
+	evidencekeeper "github.com/cosmos/cosmos-sdk/x/evidence/keeper"
+	govkeeper "github.com/cosmos/cosmos-sdk/x/gov/keeper"
+	ibctransferkeeper "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/keeper"
+	ibckeeper "github.com/cosmos/cosmos-sdk/x/ibc/core/keeper"
+	mintkeeper "github.com/cosmos/cosmos-sdk/x/mint/keeper"
+	paramskeeper "github.com/cosmos/cosmos-sdk/x/params/keeper"
+	slashingkeeper "github.com/cosmos/cosmos-sdk/x/slashing/keeper"
+	stakingkeeper "github.com/cosmos/cosmos-sdk/x/staking/keeper"
+	upgradekeeper "github.com/cosmos/cosmos-sdk/x/upgrade/keeper"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+
+	globalfeekeeper "github.com/cosmos/gaia/v4/x/globalfee/keeper"
+	ratelimitkeeper "github.com/cosmos/gaia/v4/x/ratelimit/keeper"
+)
+
+// AppKeepers holds the keepers an upgrade handler may need to migrate state.
+// It is populated by GaiaApp once all keepers have been constructed.
+type AppKeepers struct {
+	AccountKeeper    authkeeper.AccountKeeper
+	BankKeeper       bankkeeper.Keeper
+	CapabilityKeeper *capabilitykeeper.Keeper
+	StakingKeeper    stakingkeeper.Keeper
+	SlashingKeeper   slashingkeeper.Keeper
+	MintKeeper       mintkeeper.Keeper
+	DistrKeeper      distrkeeper.Keeper
+	GovKeeper        govkeeper.Keeper
+	CrisisKeeper     crisiskeeper.Keeper
+	UpgradeKeeper    upgradekeeper.Keeper
+	ParamsKeeper     paramskeeper.Keeper
+	IBCKeeper        *ibckeeper.Keeper
+	EvidenceKeeper   evidencekeeper.Keeper
+	TransferKeeper   ibctransferkeeper.Keeper
+	GlobalFeeKeeper  globalfeekeeper.Keeper
+	RateLimitKeeper  ratelimitkeeper.Keeper
+}
+
+// Upgrade defines a self-contained chain upgrade: the name of the upgrade plan
+// it handles, the state migration to run at the plan height and the stores
+// that have to be added, renamed or deleted when the new binary starts.
+type Upgrade struct {
+	// UpgradeName is the name of the software upgrade plan passed by governance.
+	UpgradeName string
+
+	// CreateUpgradeHandler builds the handler executed at the upgrade height.
+	CreateUpgradeHandler func(*module.Manager, *AppKeepers) upgradetypes.UpgradeHandler
+
+	// StoreUpgrades lists the store changes applied when loading the upgraded
+	// multistore at the upgrade height.
+	StoreUpgrades store.StoreUpgrades
+
+	// PreUpgradeCheck is an optional pre-flight check run right before the
+	// handler. A failing check halts the chain instead of migrating state.
+	PreUpgradeCheck func(sdk.Context, upgradetypes.Plan, *AppKeepers) error
+}
+
+// Validate performs a basic sanity check of the upgrade definition.
+func (u Upgrade) Validate() error {
+	if u.UpgradeName == "" {
+		return fmt.Errorf("upgrade name cannot be empty")
+	}
+	if u.CreateUpgradeHandler == nil {
+		return fmt.Errorf("upgrade %s: missing upgrade handler", u.UpgradeName)
+	}
+
+	seen := make(map[string]string)
+	mark := func(name, kind string) error {
+		if name == "" {
+			return fmt.Errorf("upgrade %s: empty store name in %s stores", u.UpgradeName, kind)
+		}
+		if prev, ok := seen[name]; ok {
+			return fmt.Errorf("upgrade %s: store %s is both %s and %s", u.UpgradeName, name, prev, kind)
+		}
+		seen[name] = kind
+		return nil
+	}
+
+	for _, name := range u.StoreUpgrades.Added {
+		if err := mark(name, "added"); err != nil {
+			return err
+		}
+	}
+	for _, name := range u.StoreUpgrades.Deleted {
+		if err := mark(name, "deleted"); err != nil {
+			return err
+		}
+	}
+	for _, rename := range u.StoreUpgrades.Renamed {
+		if err := mark(rename.OldKey, "renamed"); err != nil {
+			return err
+		}
+		if err := mark(rename.NewKey, "renamed"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler returns the upgrade handler to register with the upgrade keeper. The
+// pre-flight check, if any, runs first and panics on failure so that the node
+// halts at the upgrade height without touching state.
+func (u Upgrade) Handler(mm *module.Manager, keepers *AppKeepers) upgradetypes.UpgradeHandler {
+	handler := u.CreateUpgradeHandler(mm, keepers)
+
+	return func(ctx sdk.Context, plan upgradetypes.Plan) {
+		if u.PreUpgradeCheck != nil {
+			if err := u.PreUpgradeCheck(ctx, plan, keepers); err != nil {
+				panic(fmt.Sprintf("pre-upgrade check for %s failed: %s", u.UpgradeName, err))
+			}
+		}
+
+		ctx.Logger().Info(fmt.Sprintf("running upgrade handler for %s", u.UpgradeName))
+		handler(ctx, plan)
+	}
+}
+
+// ValidateUpgrades validates every upgrade and ensures no two upgrades share
+// the same name.
+func ValidateUpgrades(upgrades []Upgrade) error {
+	names := make(map[string]bool)
+	for _, u := range upgrades {
+		if err := u.Validate(); err != nil {
+			return err
+		}
+		if names[u.UpgradeName] {
+			return fmt.Errorf("duplicate upgrade name %s", u.UpgradeName)
+		}
+		names[u.UpgradeName] = true
+	}
+
+	return nil
+}
+
+// FindUpgrade returns the upgrade registered under the given name.
+func FindUpgrade(upgrades []Upgrade, name string) (Upgrade, bool) {
+	for _, u := range upgrades {
+		if u.UpgradeName == name {
+			return u, true
+		}
+	}
+
+	return Upgrade{}, false
+}
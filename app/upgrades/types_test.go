@@ -0,0 +1,120 @@
+package upgrades_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+
+	store "github.com/cosmos/cosmos-sdk/store/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+
+	"github.com/cosmos/gaia/v4/app/upgrades"
+)
+
+func noopHandler(_ *module.Manager, _ *upgrades.AppKeepers) upgradetypes.UpgradeHandler {
+	return func(sdk.Context, upgradetypes.Plan) {}
+}
+
+func TestUpgradeValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		upgrade upgrades.Upgrade
+		expErr  bool
+	}{
+		{"valid", upgrades.Upgrade{UpgradeName: "v5", CreateUpgradeHandler: noopHandler}, false},
+		{"valid with store upgrades", upgrades.Upgrade{
+			UpgradeName:          "v5",
+			CreateUpgradeHandler: noopHandler,
+			StoreUpgrades: store.StoreUpgrades{
+				Added:   []string{"foo"},
+				Renamed: []store.StoreRename{{OldKey: "bar", NewKey: "baz"}},
+				Deleted: []string{"qux"},
+			},
+		}, false},
+		{"empty name", upgrades.Upgrade{CreateUpgradeHandler: noopHandler}, true},
+		{"missing handler", upgrades.Upgrade{UpgradeName: "v5"}, true},
+		{"empty store name", upgrades.Upgrade{
+			UpgradeName:          "v5",
+			CreateUpgradeHandler: noopHandler,
+			StoreUpgrades:        store.StoreUpgrades{Added: []string{""}},
+		}, true},
+		{"added and deleted", upgrades.Upgrade{
+			UpgradeName:          "v5",
+			CreateUpgradeHandler: noopHandler,
+			StoreUpgrades:        store.StoreUpgrades{Added: []string{"foo"}, Deleted: []string{"foo"}},
+		}, true},
+		{"renamed onto added store", upgrades.Upgrade{
+			UpgradeName:          "v5",
+			CreateUpgradeHandler: noopHandler,
+			StoreUpgrades: store.StoreUpgrades{
+				Added:   []string{"foo"},
+				Renamed: []store.StoreRename{{OldKey: "bar", NewKey: "foo"}},
+			},
+		}, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.upgrade.Validate()
+			if tc.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateUpgradesDuplicateName(t *testing.T) {
+	u := upgrades.Upgrade{UpgradeName: "v5", CreateUpgradeHandler: noopHandler}
+	require.NoError(t, upgrades.ValidateUpgrades([]upgrades.Upgrade{u}))
+	require.Error(t, upgrades.ValidateUpgrades([]upgrades.Upgrade{u, u}))
+}
+
+func TestFindUpgrade(t *testing.T) {
+	list := []upgrades.Upgrade{
+		{UpgradeName: "v5", CreateUpgradeHandler: noopHandler},
+		{UpgradeName: "v6", CreateUpgradeHandler: noopHandler},
+	}
+
+	u, found := upgrades.FindUpgrade(list, "v6")
+	require.True(t, found)
+	require.Equal(t, "v6", u.UpgradeName)
+
+	_, found = upgrades.FindUpgrade(list, "v7")
+	require.False(t, found)
+}
+
+func TestUpgradeHandlerRunsPreUpgradeCheck(t *testing.T) {
+	ctx := sdk.NewContext(nil, tmproto.Header{}, false, log.NewNopLogger())
+	plan := upgradetypes.Plan{Name: "v5", Height: 10}
+
+	ran := false
+	u := upgrades.Upgrade{
+		UpgradeName: "v5",
+		CreateUpgradeHandler: func(_ *module.Manager, _ *upgrades.AppKeepers) upgradetypes.UpgradeHandler {
+			return func(sdk.Context, upgradetypes.Plan) { ran = true }
+		},
+		PreUpgradeCheck: func(_ sdk.Context, p upgradetypes.Plan, _ *upgrades.AppKeepers) error {
+			require.Equal(t, plan, p)
+			return nil
+		},
+	}
+
+	u.Handler(nil, &upgrades.AppKeepers{})(ctx, plan)
+	require.True(t, ran)
+
+	ran = false
+	u.PreUpgradeCheck = func(sdk.Context, upgradetypes.Plan, *upgrades.AppKeepers) error {
+		return errors.New("supply mismatch")
+	}
+
+	require.Panics(t, func() { u.Handler(nil, &upgrades.AppKeepers{})(ctx, plan) })
+	require.False(t, ran, "handler must not run when the pre-upgrade check fails")
+}
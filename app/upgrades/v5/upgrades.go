@@ -0,0 +1,32 @@
+package v5
+
+import (
+	store "github.com/cosmos/cosmos-sdk/store/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+
+	"github.com/cosmos/gaia/v4/app/upgrades"
+	ratelimittypes "github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+// UpgradeName is the name of the upgrade plan adding the IBC transfer rate
+// limit module.
+const UpgradeName = "v5"
+
+// Upgrade adds the rate limit store and initializes the module without any
+// quota; quotas are then set through parameter change proposals.
+var Upgrade = upgrades.Upgrade{
+	UpgradeName:          UpgradeName,
+	CreateUpgradeHandler: CreateUpgradeHandler,
+	StoreUpgrades: store.StoreUpgrades{
+		Added: []string{ratelimittypes.StoreKey},
+	},
+}
+
+// CreateUpgradeHandler returns the v5 upgrade handler.
+func CreateUpgradeHandler(_ *module.Manager, keepers *upgrades.AppKeepers) upgradetypes.UpgradeHandler {
+	return func(ctx sdk.Context, _ upgradetypes.Plan) {
+		keepers.RateLimitKeeper.SetParams(ctx, ratelimittypes.DefaultParams())
+	}
+}
@@ -0,0 +1,87 @@
+package gaia
+
+import (
+	"io"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ZeroHeightOptions are the options of a zero height export.
+type ZeroHeightOptions struct {
+	// JailAllowedAddrs, if not empty, lists the operator addresses of the only
+	// validators not jailed by the export.
+	JailAllowedAddrs []string
+
+	// KeepCreationHeights keeps the creation heights of the unbonding
+	// delegation and redelegation entries instead of resetting them to 0.
+	KeepCreationHeights bool
+
+	// SkipRewardWithdrawal keeps the commission and rewards, instead of
+	// withdrawing them, donating the outstanding reward remainders to the
+	// community pool and reinitializing the validator and delegation rewards.
+	// Only the heights of the delegator starting infos are reset to 0, and the
+	// export fails if a validator was slashed.
+	SkipRewardWithdrawal bool
+
+	// AuditOut, if set, receives the JSON ZeroHeightAudit of the export.
+	AuditOut io.Writer
+}
+
+// ZeroHeightAudit lists the changes made to the state by a zero height export.
+type ZeroHeightAudit struct {
+	// Height is the height of the exported state.
+	Height int64 `json:"height"`
+
+	CommissionWithdrawals  []CommissionWithdrawal  `json:"commission_withdrawals"`
+	RewardWithdrawals      []RewardWithdrawal      `json:"reward_withdrawals"`
+	CommunityPoolDonations []CommunityPoolDonation `json:"community_pool_donations"`
+
+	// JailedValidators are the operator addresses of the validators jailed by
+	// the export, i.e. not jailed already and not allowed.
+	JailedValidators []string `json:"jailed_validators"`
+
+	// HeightResets are the non-zero heights reset to 0.
+	HeightResets []HeightReset `json:"height_resets"`
+}
+
+// CommissionWithdrawal is the commission withdrawn from a validator.
+type CommissionWithdrawal struct {
+	Validator string    `json:"validator"`
+	Amount    sdk.Coins `json:"amount"`
+}
+
+// RewardWithdrawal is the reward withdrawn by a delegator.
+type RewardWithdrawal struct {
+	Delegator string    `json:"delegator"`
+	Validator string    `json:"validator"`
+	Amount    sdk.Coins `json:"amount"`
+}
+
+// CommunityPoolDonation is the outstanding reward remainder of a validator
+// donated to the community pool.
+type CommunityPoolDonation struct {
+	Validator string       `json:"validator"`
+	Amount    sdk.DecCoins `json:"amount"`
+}
+
+// Height reset fields.
+const (
+	ResetRedelegationCreationHeight        = "redelegation.creation_height"
+	ResetUnbondingDelegationCreationHeight = "unbonding_delegation.creation_height"
+	ResetValidatorUnbondingHeight          = "validator.unbonding_height"
+	ResetSigningInfoStartHeight            = "signing_info.start_height"
+	ResetDelegatorStartingInfoHeight       = "delegator_starting_info.height"
+)
+
+// HeightReset is a height reset to 0. The addresses identify the record of
+// the field: the delegator and validators of a delegation entry or starting
+// info, the validator of a validator, and the consensus address of a signing
+// info.
+type HeightReset struct {
+	Field        string `json:"field"`
+	Delegator    string `json:"delegator,omitempty"`
+	Validator    string `json:"validator,omitempty"`
+	ValidatorDst string `json:"validator_dst,omitempty"`
+	ConsAddress  string `json:"cons_address,omitempty"`
+	Before       int64  `json:"before"`
+}
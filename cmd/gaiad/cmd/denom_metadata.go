@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/codec"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+const flagReplace = "replace"
+
+// DenomMetadataCmd returns the denom-metadata cobra Command.
+func DenomMetadataCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                        "denom-metadata",
+		Short:                      "Manage the bank denom metadata of genesis.json",
+		DisableFlagParsing:         true,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		AddDenomMetadataCmd(defaultNodeHome),
+		RemoveDenomMetadataCmd(defaultNodeHome),
+		ListDenomMetadataCmd(defaultNodeHome),
+	)
+
+	return cmd
+}
+
+// AddDenomMetadataCmd returns the denom-metadata add cobra Command.
+func AddDenomMetadataCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add [metadata-file]",
+		Short: "Add the denom metadata of a JSON file to genesis.json",
+		Long: `Add the denom metadata of a JSON file, holding a single metadata or a list, to
+genesis.json. The units must be sorted by increasing exponent, starting with the base
+unit of exponent 0 and ending with the display unit. The metadata is validated together
+with the metadata already in genesis.json before the file is written.
+
+Example metadata:
+
+{
+  "description": "The native staking token of the Cosmos Hub.",
+  "denom_units": [
+    {"denom": "uatom", "exponent": 0, "aliases": ["microatom"]},
+    {"denom": "matom", "exponent": 3, "aliases": ["milliatom"]},
+    {"denom": "atom", "exponent": 6}
+  ],
+  "base": "uatom",
+  "display": "atom"
+}
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cdc := client.GetClientContextFromCmd(cmd).JSONMarshaler
+
+			replace, err := cmd.Flags().GetBool(flagReplace)
+			if err != nil {
+				return err
+			}
+
+			metadata, err := gaia.ReadDenomMetadata(cdc, args[0])
+			if err != nil {
+				return err
+			}
+
+			return updateDenomMetadata(cmd, func(existing []banktypes.Metadata) ([]banktypes.Metadata, error) {
+				return addDenomMetadata(existing, metadata, replace)
+			})
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+	cmd.Flags().Bool(flagReplace, false, "Replace the existing metadata of the same base denoms")
+
+	return cmd
+}
+
+// RemoveDenomMetadataCmd returns the denom-metadata remove cobra Command.
+func RemoveDenomMetadataCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove [base-denom]...",
+		Short: "Remove the denom metadata of the base denoms from genesis.json",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateDenomMetadata(cmd, func(existing []banktypes.Metadata) ([]banktypes.Metadata, error) {
+				return removeDenomMetadata(existing, args)
+			})
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+
+	return cmd
+}
+
+// ListDenomMetadataCmd returns the denom-metadata list cobra Command.
+func ListDenomMetadataCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the denom metadata of genesis.json",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cdc := client.GetClientContextFromCmd(cmd).JSONMarshaler
+
+			_, appState, _, err := readGenesisFile(cmd)
+			if err != nil {
+				return err
+			}
+
+			bankGenState := banktypes.GetGenesisStateFromAppState(cdc, appState)
+
+			bz, err := marshalDenomMetadata(cdc, bankGenState.DenomMetadata)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), string(bz))
+			return err
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+
+	return cmd
+}
+
+// updateDenomMetadata applies update to the denom metadata of the bank genesis
+// state, and writes genesis.json if the updated metadata is valid.
+func updateDenomMetadata(cmd *cobra.Command, update func([]banktypes.Metadata) ([]banktypes.Metadata, error)) error {
+	cdc := client.GetClientContextFromCmd(cmd).JSONMarshaler
+
+	genFile, appState, genDoc, err := readGenesisFile(cmd)
+	if err != nil {
+		return err
+	}
+
+	bankGenState := banktypes.GetGenesisStateFromAppState(cdc, appState)
+
+	bankGenState.DenomMetadata, err = update(bankGenState.DenomMetadata)
+	if err != nil {
+		return err
+	}
+
+	if err := gaia.ValidateDenomMetadata(bankGenState.DenomMetadata); err != nil {
+		return err
+	}
+
+	bankGenStateBz, err := cdc.MarshalJSON(bankGenState)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bank genesis state: %w", err)
+	}
+
+	appState[banktypes.ModuleName] = bankGenStateBz
+
+	return writeGenesisFile(genFile, appState, genDoc)
+}
+
+// addDenomMetadata adds the metadata to the existing one. Metadata of a base
+// denom already present is rejected unless replace is set.
+func addDenomMetadata(existing, metadata []banktypes.Metadata, replace bool) ([]banktypes.Metadata, error) {
+	indexes := make(map[string]int, len(existing))
+	for i, m := range existing {
+		indexes[m.Base] = i
+	}
+
+	for _, m := range metadata {
+		i, ok := indexes[m.Base]
+		switch {
+		case !ok:
+			indexes[m.Base] = len(existing)
+			existing = append(existing, m)
+
+		case replace:
+			existing[i] = m
+
+		default:
+			return nil, fmt.Errorf("genesis already has denom metadata for %s; use --%s to replace it", m.Base, flagReplace)
+		}
+	}
+
+	return existing, nil
+}
+
+// removeDenomMetadata removes the metadata of the base denoms.
+func removeDenomMetadata(existing []banktypes.Metadata, bases []string) ([]banktypes.Metadata, error) {
+	remove := make(map[string]bool, len(bases))
+	for _, base := range bases {
+		remove[base] = true
+	}
+
+	kept := make([]banktypes.Metadata, 0, len(existing))
+	for _, m := range existing {
+		if remove[m.Base] {
+			delete(remove, m.Base)
+			continue
+		}
+
+		kept = append(kept, m)
+	}
+
+	if len(remove) > 0 {
+		missing := make([]string, 0, len(remove))
+		for _, base := range bases {
+			if remove[base] {
+				missing = append(missing, base)
+			}
+		}
+
+		return nil, fmt.Errorf("genesis has no denom metadata for %s", strings.Join(missing, ", "))
+	}
+
+	return kept, nil
+}
+
+// marshalDenomMetadata returns the indented JSON list of the metadata.
+func marshalDenomMetadata(cdc codec.JSONMarshaler, metadata []banktypes.Metadata) ([]byte, error) {
+	list := make([]json.RawMessage, len(metadata))
+	for i := range metadata {
+		bz, err := cdc.MarshalJSON(&metadata[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal denom metadata: %w", err)
+		}
+
+		list[i] = bz
+	}
+
+	return json.MarshalIndent(list, "", "  ")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+func TestAddRemoveDenomMetadata(t *testing.T) {
+	atom := banktypes.Metadata{Base: "uatom", Display: "atom"}
+	stake := banktypes.Metadata{Base: "ustake", Display: "stake"}
+	newStake := banktypes.Metadata{Base: "ustake", Display: "mstake"}
+
+	metadata, err := addDenomMetadata(nil, []banktypes.Metadata{atom, stake}, false)
+	require.NoError(t, err)
+	require.Equal(t, []banktypes.Metadata{atom, stake}, metadata)
+
+	_, err = addDenomMetadata(metadata, []banktypes.Metadata{newStake}, false)
+	require.Error(t, err)
+
+	metadata, err = addDenomMetadata(metadata, []banktypes.Metadata{newStake}, true)
+	require.NoError(t, err)
+	require.Equal(t, []banktypes.Metadata{atom, newStake}, metadata)
+
+	_, err = removeDenomMetadata(metadata, []string{"uatom", "uosmo"})
+	require.EqualError(t, err, "genesis has no denom metadata for uosmo")
+
+	metadata, err = removeDenomMetadata(metadata, []string{"uatom"})
+	require.NoError(t, err)
+	require.Equal(t, []banktypes.Metadata{newStake}, metadata)
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server"
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/version"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+const (
+	flagModules    = "modules"
+	flagSharded    = "sharded"
+	flagTraceStore = "trace-store"
+
+	flagZeroHeightAudit     = "zero-height-audit"
+	flagKeepCreationHeights = "keep-creation-heights"
+	flagSkipRewardWithdraw  = "skip-reward-withdrawal"
+)
+
+// ExportCmd returns the export cobra Command. Unlike the SDK export command,
+// it writes the app state module by module instead of building it in memory,
+// and can export a subset of the modules.
+func ExportCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export state to JSON",
+		Long: `Export the application state to a genesis file, written to STDERR, as by the
+SDK export command, or to the --output-document file. The genesis state of each module
+is written as soon as it is exported, so that memory usage is bounded by the largest
+module.
+
+--modules restricts the export to the listed modules, e.g. to export balances and
+validators for analytics. Such a genesis file lacks the other modules and cannot be
+used to start a chain.
+
+--sharded writes the genesis state of each module, with a manifest of their checksums,
+to the genesis directory next to the --output-document file, which holds the genesis
+document without app state. Copied to the config directory of a node, the sharded
+genesis is read by InitChain.
+
+--for-zero-height withdraws all commission and rewards, donates the outstanding reward
+remainders to the community pool, resets the heights of the state to 0 and jails the
+validators not listed by --jail-allowed-addrs, if any. --zero-height-audit writes every
+amount moved, validator jailed and height reset to a JSON file. --keep-creation-heights
+keeps the creation heights of the unbonding delegation and redelegation entries, and
+--skip-reward-withdrawal keeps the commission and rewards, only resetting the starting
+heights of the delegation rewards. It fails if a validator was ever slashed.
+`,
+		Example: fmt.Sprintf("$ %s export --modules bank,staking --output-document state.json", version.AppName),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			serverCtx := server.GetServerContextFromCmd(cmd)
+			config := serverCtx.Config
+
+			homeDir, _ := cmd.Flags().GetString(flags.FlagHome)
+			config.SetRoot(homeDir)
+
+			height, _ := cmd.Flags().GetInt64(server.FlagHeight)
+			forZeroHeight, _ := cmd.Flags().GetBool(server.FlagForZeroHeight)
+			zeroHeightOpts := gaia.ZeroHeightOptions{}
+			zeroHeightOpts.JailAllowedAddrs, _ = cmd.Flags().GetStringSlice(server.FlagJailAllowedAddrs)
+			zeroHeightOpts.KeepCreationHeights, _ = cmd.Flags().GetBool(flagKeepCreationHeights)
+			zeroHeightOpts.SkipRewardWithdrawal, _ = cmd.Flags().GetBool(flagSkipRewardWithdraw)
+			zeroHeightAudit, _ := cmd.Flags().GetString(flagZeroHeightAudit)
+			modules, _ := cmd.Flags().GetStringSlice(flagModules)
+			outputDocument, _ := cmd.Flags().GetString(flags.FlagOutputDocument)
+			sharded, _ := cmd.Flags().GetBool(flagSharded)
+			traceStore, _ := cmd.Flags().GetString(flagTraceStore)
+
+			if sharded && outputDocument == "" {
+				return fmt.Errorf("--%s requires --%s", flagSharded, flags.FlagOutputDocument)
+			}
+			for _, flag := range []string{flagZeroHeightAudit, flagKeepCreationHeights, flagSkipRewardWithdraw} {
+				if cmd.Flags().Changed(flag) && !forZeroHeight {
+					return fmt.Errorf("--%s requires --%s", flag, server.FlagForZeroHeight)
+				}
+			}
+
+			doc, err := tmtypes.GenesisDocFromFile(config.GenesisFile())
+			if err != nil {
+				return err
+			}
+
+			db, err := sdk.NewLevelDB("application", filepath.Join(config.RootDir, "data"))
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			// the trace file is opened as by the SDK export command
+			var traceWriter io.Writer
+			if traceStore != "" {
+				f, err := os.OpenFile(traceStore, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				traceWriter = f
+			}
+
+			gaiaApp, err := newExportApp(serverCtx.Logger, db, traceWriter, height, serverCtx.Viper)
+			if err != nil {
+				return fmt.Errorf("error exporting state: %w", err)
+			}
+
+			if zeroHeightAudit != "" {
+				f, err := os.Create(zeroHeightAudit)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				zeroHeightOpts.AuditOut = f
+			}
+
+			// STDERR, as with the export command of the SDK
+			out := cmd.ErrOrStderr()
+			if outputDocument != "" {
+				f, err := os.Create(outputDocument)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				out = f
+			}
+
+			w := bufio.NewWriter(out)
+			if sharded {
+				exported, err := gaiaApp.ExportShardedAppStateAndValidators(gaia.GenesisShardsDir(outputDocument), forZeroHeight, zeroHeightOpts, modules)
+				if err != nil {
+					return fmt.Errorf("error exporting state: %w", err)
+				}
+
+				encoded, err := encodeExportedGenesis(doc, exported)
+				if err != nil {
+					return err
+				}
+				if _, err := fmt.Fprintf(w, "%s\n", encoded); err != nil {
+					return err
+				}
+			} else if err := writeExportedGenesis(w, doc, func(w io.Writer) (servertypes.ExportedApp, error) {
+				return gaiaApp.StreamAppStateAndValidators(w, forZeroHeight, zeroHeightOpts, modules)
+			}); err != nil {
+				return fmt.Errorf("error exporting state: %w", err)
+			}
+
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+	cmd.Flags().Int64(server.FlagHeight, -1, "Export state from a particular height (-1 means latest height)")
+	cmd.Flags().Bool(server.FlagForZeroHeight, false, "Export state to start at height zero (perform preproccessing)")
+	cmd.Flags().StringSlice(server.FlagJailAllowedAddrs, []string{}, "Comma-separated list of operator addresses of jailed validators to unjail")
+	cmd.Flags().StringSlice(flagModules, []string{}, "Comma-separated list of the modules to export (all modules if empty)")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "Write the exported genesis to the given file instead of STDERR")
+	cmd.Flags().Bool(flagSharded, false, "Export a sharded genesis, with one file per module")
+	cmd.Flags().String(flagTraceStore, "", "Enable KVStore tracing to an output file")
+	cmd.Flags().String(flagZeroHeightAudit, "", "Write the audit of the state changes of a zero height export to the given JSON file")
+	cmd.Flags().Bool(flagKeepCreationHeights, false, "Keep the creation heights of unbonding delegations and redelegations in a zero height export")
+	cmd.Flags().Bool(flagSkipRewardWithdraw, false, "Keep the commission and rewards in a zero height export instead of withdrawing them; fails if a validator was slashed")
+
+	return cmd
+}
+
+// writeExportedGenesis writes the genesis document doc, with the app state
+// streamed by export. The app state is written first, as the validators,
+// initial height and consensus params of the document are only known once
+// the state is exported.
+func writeExportedGenesis(w io.Writer, doc *tmtypes.GenesisDoc, export func(io.Writer) (servertypes.ExportedApp, error)) error {
+	if _, err := io.WriteString(w, `{"app_state":`); err != nil {
+		return err
+	}
+
+	exported, err := export(w)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := encodeExportedGenesis(doc, exported)
+	if err != nil {
+		return err
+	}
+
+	// the document is a JSON object, whose opening brace was written with the
+	// app state
+	if _, err := fmt.Fprintf(w, ",%s\n", encoded[1:]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// encodeExportedGenesis returns the sorted genesis document doc, updated with
+// the validators, initial height and consensus params of exported, without
+// app state.
+func encodeExportedGenesis(doc *tmtypes.GenesisDoc, exported servertypes.ExportedApp) ([]byte, error) {
+	doc.AppState = nil
+	doc.Validators = exported.Validators
+	doc.InitialHeight = exported.Height
+	doc.ConsensusParams = &tmproto.ConsensusParams{
+		Block: tmproto.BlockParams{
+			MaxBytes:   exported.ConsensusParams.Block.MaxBytes,
+			MaxGas:     exported.ConsensusParams.Block.MaxGas,
+			TimeIotaMs: doc.ConsensusParams.Block.TimeIotaMs,
+		},
+		Evidence: tmproto.EvidenceParams{
+			MaxAgeNumBlocks: exported.ConsensusParams.Evidence.MaxAgeNumBlocks,
+			MaxAgeDuration:  exported.ConsensusParams.Evidence.MaxAgeDuration,
+			MaxBytes:        exported.ConsensusParams.Evidence.MaxBytes,
+		},
+		Validator: tmproto.ValidatorParams{
+			PubKeyTypes: exported.ConsensusParams.Validator.PubKeyTypes,
+		},
+	}
+
+	// NOTE: Tendermint uses a custom JSON decoder for GenesisDoc, the app state
+	// being left out of it.
+	encoded, err := tmjson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdk.SortJSON(encoded)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	tmstore "github.com/tendermint/tendermint/store"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/version"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+const flagBalancesFormat = "format"
+
+const (
+	balancesFormatCSV  = "csv"
+	balancesFormatJSON = "json"
+)
+
+// ExportBalancesCmd returns the export-balances cobra Command.
+func ExportBalancesCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-balances",
+		Short: "Export the balance breakdown of every address at a height",
+		Long: `Export the liquid, vesting locked, staked, unbonding, unclaimed reward and unclaimed
+commission balances of every address at a height, as CSV or JSON. Module accounts and
+vesting accounts are labelled by their type. The node must be stopped.
+
+The vesting locked amounts are computed at the time of the block of the height, read
+from the block store of the node.
+`,
+		Example: fmt.Sprintf("$ %s export-balances --height 5000000 --format json --output-document balances.json", version.AppName),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			serverCtx := server.GetServerContextFromCmd(cmd)
+			config := serverCtx.Config
+
+			homeDir, _ := cmd.Flags().GetString(flags.FlagHome)
+			config.SetRoot(homeDir)
+
+			height, _ := cmd.Flags().GetInt64(server.FlagHeight)
+			format, _ := cmd.Flags().GetString(flagBalancesFormat)
+			outputDocument, _ := cmd.Flags().GetString(flags.FlagOutputDocument)
+
+			if format != balancesFormatCSV && format != balancesFormatJSON {
+				return fmt.Errorf("unknown balances format %q; must be %s or %s", format, balancesFormatCSV, balancesFormatJSON)
+			}
+
+			db, err := sdk.NewLevelDB("application", filepath.Join(config.RootDir, "data"))
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			gaiaApp, err := newExportApp(serverCtx.Logger, db, nil, height, serverCtx.Viper)
+			if err != nil {
+				return fmt.Errorf("error loading state: %w", err)
+			}
+
+			blockTime, err := loadBlockTime(config.DBBackend, config.DBDir(), gaiaApp.LastBlockHeight())
+			if err != nil {
+				return err
+			}
+
+			balances, err := gaiaApp.ExportBalances(blockTime)
+			if err != nil {
+				return fmt.Errorf("error exporting balances: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if outputDocument != "" {
+				f, err := os.Create(outputDocument)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				out = f
+			}
+
+			return writeBalances(out, format, balances)
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+	cmd.Flags().Int64(server.FlagHeight, -1, "Export balances at a particular height (-1 means latest height)")
+	cmd.Flags().String(flagBalancesFormat, balancesFormatCSV, "Output format (csv|json)")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "Write the balances to the given file instead of STDOUT")
+
+	return cmd
+}
+
+// loadBlockTime returns the time of the block of the height from the block
+// store of the node.
+func loadBlockTime(dbBackend, dbDir string, height int64) (time.Time, error) {
+	db, err := dbm.NewDB("blockstore", dbm.BackendType(dbBackend), dbDir)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to open block store: %w", err)
+	}
+	defer db.Close()
+
+	meta := tmstore.NewBlockStore(db).LoadBlockMeta(height)
+	if meta == nil {
+		return time.Time{}, fmt.Errorf("block %d not found in block store", height)
+	}
+
+	return meta.Header.Time, nil
+}
+
+func writeBalances(w io.Writer, format string, balances []gaia.AccountBalance) error {
+	if format == balancesFormatCSV {
+		return gaia.WriteBalancesCSV(w, balances)
+	}
+
+	bz, err := json.MarshalIndent(balances, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(bz))
+	return err
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/server"
+)
+
+func TestExportCmdFlags(t *testing.T) {
+	cmd := ExportCmd(t.TempDir())
+
+	// the flags of the SDK export command are kept
+	for _, flag := range []string{server.FlagHeight, server.FlagForZeroHeight, server.FlagJailAllowedAddrs, flagTraceStore} {
+		require.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+	require.NoError(t, cmd.ParseFlags([]string{"--trace-store", "trace.log"}))
+}
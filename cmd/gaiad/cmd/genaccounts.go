@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 
 	"github.com/spf13/cobra"
 
@@ -25,6 +26,8 @@ const (
 	flagVestingStart = "vesting-start-time"
 	flagVestingEnd   = "vesting-end-time"
 	flagVestingAmt   = "vesting-amount"
+
+	flagVestingPeriods = "vesting-periods"
 )
 
 // AddGenesisAccountCmd returns add-genesis-account cobra Command.
@@ -36,6 +39,20 @@ func AddGenesisAccountCmd(defaultNodeHome string) *cobra.Command {
 the account address or key name and a list of initial coins. If a key name is given,
 the address will be looked up in the local Keybase. The list of initial tokens must
 contain valid denominations. Accounts may optionally be supplied with vesting parameters.
+
+Periodic vesting accounts are created from a JSON file passed with --vesting-periods,
+giving the vesting start time (unix epoch) and the length in seconds and amount of
+each period:
+
+{
+  "start_time": 1625097600,
+  "periods": [
+    {"length": 31536000, "amount": "250000uatom"},
+    {"length": 2592000, "amount": "62500uatom"}
+  ]
+}
+
+The amounts of the periods must add up to --vesting-amount, which defaults to their sum.
 `,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -87,42 +104,31 @@ contain valid denominations. Accounts may optionally be supplied with vesting pa
 			if err != nil {
 				return err
 			}
+			vestingPeriodsFile, err := cmd.Flags().GetString(flagVestingPeriods)
+			if err != nil {
+				return err
+			}
 
 			vestingAmt, err := sdk.ParseCoinsNormalized(vestingAmtStr)
 			if err != nil {
 				return fmt.Errorf("failed to parse vesting amount: %w", err)
 			}
 
-			// create concrete account type based on input parameters
-			var genAccount authtypes.GenesisAccount
-
-			balances := banktypes.Balance{Address: addr.String(), Coins: coins.Sort()}
-			baseAccount := authtypes.NewBaseAccount(addr, nil, 0, 0)
-
-			if !vestingAmt.IsZero() {
-				baseVestingAccount := authvesting.NewBaseVestingAccount(baseAccount, vestingAmt.Sort(), vestingEnd)
-
-				if (balances.Coins.IsZero() && !baseVestingAccount.OriginalVesting.IsZero()) ||
-					baseVestingAccount.OriginalVesting.IsAnyGT(balances.Coins) {
-					return errors.New("vesting amount cannot be greater than total amount")
+			vesting := genesisVesting{Amount: vestingAmt, Start: vestingStart, End: vestingEnd}
+			if vestingPeriodsFile != "" {
+				if vestingStart != 0 || vestingEnd != 0 {
+					return fmt.Errorf("--%s cannot be combined with --%s or --%s", flagVestingPeriods, flagVestingStart, flagVestingEnd)
 				}
 
-				switch {
-				case vestingStart != 0 && vestingEnd != 0:
-					genAccount = authvesting.NewContinuousVestingAccountRaw(baseVestingAccount, vestingStart)
-
-				case vestingEnd != 0:
-					genAccount = authvesting.NewDelayedVestingAccountRaw(baseVestingAccount)
-
-				default:
-					return errors.New("invalid vesting parameters; must supply start and end time or end time")
+				vesting.Start, vesting.Periods, err = readVestingPeriods(vestingPeriodsFile)
+				if err != nil {
+					return err
 				}
-			} else {
-				genAccount = baseAccount
 			}
 
-			if err := genAccount.Validate(); err != nil {
-				return fmt.Errorf("failed to validate new genesis account: %w", err)
+			genAccount, balances, err := newGenesisAccount(addr, coins, vesting)
+			if err != nil {
+				return err
 			}
 
 			genFile := config.GenesisFile()
@@ -131,47 +137,10 @@ contain valid denominations. Accounts may optionally be supplied with vesting pa
 				return fmt.Errorf("failed to unmarshal genesis state: %w", err)
 			}
 
-			authGenState := authtypes.GetGenesisStateFromAppState(cdc, appState)
-
-			accs, err := authtypes.UnpackAccounts(authGenState.Accounts)
-			if err != nil {
-				return fmt.Errorf("failed to get accounts from any: %w", err)
-			}
-
-			if accs.Contains(addr) {
-				return fmt.Errorf("cannot add account at existing address %s", addr)
-			}
-
-			// Add the new account to the set of genesis accounts and sanitize the
-			// accounts afterwards.
-			accs = append(accs, genAccount)
-			accs = authtypes.SanitizeGenesisAccounts(accs)
-
-			genAccs, err := authtypes.PackAccounts(accs)
-			if err != nil {
-				return fmt.Errorf("failed to convert accounts into any's: %w", err)
-			}
-			authGenState.Accounts = genAccs
-
-			authGenStateBz, err := cdc.MarshalJSON(&authGenState)
-			if err != nil {
-				return fmt.Errorf("failed to marshal auth genesis state: %w", err)
-			}
-
-			appState[authtypes.ModuleName] = authGenStateBz
-
-			bankGenState := banktypes.GetGenesisStateFromAppState(depCdc, appState)
-			bankGenState.Balances = append(bankGenState.Balances, balances)
-			bankGenState.Balances = banktypes.SanitizeGenesisBalances(bankGenState.Balances)
-			bankGenState.Supply = bankGenState.Supply.Add(balances.Coins...)
-
-			bankGenStateBz, err := cdc.MarshalJSON(bankGenState)
-			if err != nil {
-				return fmt.Errorf("failed to marshal bank genesis state: %w", err)
+			if err := addGenesisAccounts(cdc, appState, []authtypes.GenesisAccount{genAccount}, []banktypes.Balance{balances}); err != nil {
+				return err
 			}
 
-			appState[banktypes.ModuleName] = bankGenStateBz
-
 			appStateJSON, err := json.Marshal(appState)
 			if err != nil {
 				return fmt.Errorf("failed to marshal application genesis state: %w", err)
@@ -187,7 +156,189 @@ contain valid denominations. Accounts may optionally be supplied with vesting pa
 	cmd.Flags().String(flagVestingAmt, "", "amount of coins for vesting accounts")
 	cmd.Flags().Int64(flagVestingStart, 0, "schedule start time (unix epoch) for vesting accounts")
 	cmd.Flags().Int64(flagVestingEnd, 0, "schedule end time (unix epoch) for vesting accounts")
+	cmd.Flags().String(flagVestingPeriods, "", "path to a JSON file with the start time and periods of a periodic vesting account")
 	flags.AddQueryFlagsToCmd(cmd)
 
 	return cmd
 }
+
+// genesisVesting holds the vesting parameters of a new genesis account. An
+// account with no vesting amount and no periods is not vesting.
+type genesisVesting struct {
+	Amount  sdk.Coins
+	Start   int64
+	End     int64
+	Periods authvesting.Periods
+}
+
+// newGenesisAccount creates the genesis account and balance of addr holding
+// coins, with the concrete account type based on the vesting parameters.
+func newGenesisAccount(addr sdk.AccAddress, coins sdk.Coins, vesting genesisVesting) (authtypes.GenesisAccount, banktypes.Balance, error) {
+	var genAccount authtypes.GenesisAccount
+
+	balances := banktypes.Balance{Address: addr.String(), Coins: coins.Sort()}
+	baseAccount := authtypes.NewBaseAccount(addr, nil, 0, 0)
+
+	if len(vesting.Periods) > 0 {
+		periodsAmt := sdk.NewCoins()
+		end := vesting.Start
+		for _, p := range vesting.Periods {
+			periodsAmt = periodsAmt.Add(p.Amount...)
+			end += p.Length
+		}
+
+		// the amounts are compared both ways, as Coins.IsEqual panics on
+		// coins of different denoms
+		if vesting.Amount.IsZero() {
+			vesting.Amount = periodsAmt
+		} else if !vesting.Amount.IsAllGTE(periodsAmt) || !periodsAmt.IsAllGTE(vesting.Amount) {
+			return nil, balances, fmt.Errorf("vesting periods add up to %s, not to the vesting amount %s", periodsAmt, vesting.Amount)
+		}
+		vesting.End = end
+	}
+
+	if !vesting.Amount.IsZero() {
+		baseVestingAccount := authvesting.NewBaseVestingAccount(baseAccount, vesting.Amount.Sort(), vesting.End)
+
+		if (balances.Coins.IsZero() && !baseVestingAccount.OriginalVesting.IsZero()) ||
+			baseVestingAccount.OriginalVesting.IsAnyGT(balances.Coins) {
+			return nil, balances, errors.New("vesting amount cannot be greater than total amount")
+		}
+
+		switch {
+		case len(vesting.Periods) > 0:
+			genAccount = authvesting.NewPeriodicVestingAccountRaw(baseVestingAccount, vesting.Start, vesting.Periods)
+
+		case vesting.Start != 0 && vesting.End != 0:
+			genAccount = authvesting.NewContinuousVestingAccountRaw(baseVestingAccount, vesting.Start)
+
+		case vesting.End != 0:
+			genAccount = authvesting.NewDelayedVestingAccountRaw(baseVestingAccount)
+
+		default:
+			return nil, balances, errors.New("invalid vesting parameters; must supply start and end time or end time")
+		}
+	} else {
+		genAccount = baseAccount
+	}
+
+	if err := genAccount.Validate(); err != nil {
+		return nil, balances, fmt.Errorf("failed to validate new genesis account: %w", err)
+	}
+
+	return genAccount, balances, nil
+}
+
+// addGenesisAccounts adds the accounts and their balances to the auth and bank
+// genesis states of appState, and increases the total supply accordingly. It
+// fails if any account address is already in use.
+func addGenesisAccounts(cdc codec.Marshaler, appState map[string]json.RawMessage, newAccs []authtypes.GenesisAccount, newBalances []banktypes.Balance) error {
+	authGenState := authtypes.GetGenesisStateFromAppState(cdc, appState)
+
+	accs, err := authtypes.UnpackAccounts(authGenState.Accounts)
+	if err != nil {
+		return fmt.Errorf("failed to get accounts from any: %w", err)
+	}
+
+	existing := make(map[string]bool, len(accs)+len(newAccs))
+	for _, acc := range accs {
+		existing[acc.GetAddress().String()] = true
+	}
+
+	for _, acc := range newAccs {
+		addr := acc.GetAddress().String()
+		if existing[addr] {
+			return fmt.Errorf("cannot add account at existing address %s", addr)
+		}
+
+		existing[addr] = true
+		accs = append(accs, acc)
+	}
+
+	// Sanitize the accounts once all the new ones have been added.
+	accs = authtypes.SanitizeGenesisAccounts(accs)
+
+	genAccs, err := authtypes.PackAccounts(accs)
+	if err != nil {
+		return fmt.Errorf("failed to convert accounts into any's: %w", err)
+	}
+	authGenState.Accounts = genAccs
+
+	authGenStateBz, err := cdc.MarshalJSON(&authGenState)
+	if err != nil {
+		return fmt.Errorf("failed to marshal auth genesis state: %w", err)
+	}
+
+	appState[authtypes.ModuleName] = authGenStateBz
+
+	bankGenState := banktypes.GetGenesisStateFromAppState(cdc, appState)
+	bankGenState.Balances = append(bankGenState.Balances, newBalances...)
+	bankGenState.Balances = banktypes.SanitizeGenesisBalances(bankGenState.Balances)
+	for _, balance := range newBalances {
+		bankGenState.Supply = bankGenState.Supply.Add(balance.Coins...)
+	}
+
+	bankGenStateBz, err := cdc.MarshalJSON(bankGenState)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bank genesis state: %w", err)
+	}
+
+	appState[banktypes.ModuleName] = bankGenStateBz
+
+	return nil
+}
+
+// vestingPeriodsJSON is the format of the --vesting-periods file.
+type vestingPeriodsJSON struct {
+	StartTime int64 `json:"start_time"`
+	Periods   []struct {
+		Length int64  `json:"length"`
+		Amount string `json:"amount"`
+	} `json:"periods"`
+}
+
+// readVestingPeriods reads the start time and the periods of a periodic
+// vesting account from a JSON file.
+func readVestingPeriods(path string) (int64, authvesting.Periods, error) {
+	bz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read vesting periods file: %w", err)
+	}
+
+	return parseVestingPeriods(bz)
+}
+
+func parseVestingPeriods(bz []byte) (int64, authvesting.Periods, error) {
+	var input vestingPeriodsJSON
+	if err := json.Unmarshal(bz, &input); err != nil {
+		return 0, nil, fmt.Errorf("failed to parse vesting periods: %w", err)
+	}
+
+	return input.toPeriods()
+}
+
+// toPeriods validates the input and converts it to vesting periods.
+func (input vestingPeriodsJSON) toPeriods() (int64, authvesting.Periods, error) {
+	if input.StartTime <= 0 {
+		return 0, nil, errors.New("vesting periods must have a positive start time")
+	}
+	if len(input.Periods) == 0 {
+		return 0, nil, errors.New("vesting periods cannot be empty")
+	}
+
+	periods := make(authvesting.Periods, len(input.Periods))
+	for i, p := range input.Periods {
+		if p.Length <= 0 {
+			return 0, nil, fmt.Errorf("vesting period %d: length must be positive", i)
+		}
+
+		amount, err := sdk.ParseCoinsNormalized(p.Amount)
+		if err != nil {
+			return 0, nil, fmt.Errorf("vesting period %d: failed to parse amount: %w", i, err)
+		}
+
+		periods[i] = authvesting.Period{Length: p.Length, Amount: amount}
+	}
+
+	return input.StartTime, periods, nil
+}
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/server"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingexported "github.com/cosmos/cosmos-sdk/x/auth/vesting/exported"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/cosmos/cosmos-sdk/x/genutil"
+	genutiltypes "github.com/cosmos/cosmos-sdk/x/genutil/types"
+)
+
+const flagAllocationFormat = "format"
+
+const (
+	allocationFormatCSV  = "csv"
+	allocationFormatJSON = "json"
+)
+
+// csvAllocationHeader is the column layout of CSV allocation files. Only the
+// address and coins columns are required.
+var csvAllocationHeader = []string{"address", "coins", "vesting_amount", "vesting_start_time", "vesting_end_time"}
+
+// genesisAllocation is a single entry of an allocation file.
+type genesisAllocation struct {
+	Address          string              `json:"address"`
+	Coins            string              `json:"coins"`
+	VestingAmount    string              `json:"vesting_amount,omitempty"`
+	VestingStartTime int64               `json:"vesting_start_time,omitempty"`
+	VestingEndTime   int64               `json:"vesting_end_time,omitempty"`
+	VestingPeriods   *vestingPeriodsJSON `json:"vesting_periods,omitempty"`
+}
+
+// BulkAddGenesisAccountCmd returns bulk-add-genesis-account cobra Command.
+func BulkAddGenesisAccountCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bulk-add-genesis-account [allocation-file]",
+		Short: "Add the genesis accounts of an allocation file to genesis.json",
+		Long: `Add every genesis account of a CSV or JSON allocation file to genesis.json in
+a single pass. Entries are checked like with add-genesis-account, and no account is
+added if any entry is invalid or its address is already in use.
+
+A CSV file has one account per line, with an optional header line:
+
+address,coins,vesting_amount,vesting_start_time,vesting_end_time
+cosmos1...,"1000uatom,10stake",500uatom,1625097600,1656633600
+
+A JSON file holds a list of accounts, which may also be periodic vesting accounts:
+
+[
+  {"address": "cosmos1...", "coins": "1000uatom"},
+  {
+    "address": "cosmos1...",
+    "coins": "1000uatom",
+    "vesting_periods": {
+      "start_time": 1625097600,
+      "periods": [{"length": 31536000, "amount": "500uatom"}]
+    }
+  }
+]
+
+The format is derived from the file extension unless --format is given.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			cdc := clientCtx.JSONMarshaler.(codec.Marshaler)
+
+			serverCtx := server.GetServerContextFromCmd(cmd)
+			config := serverCtx.Config
+
+			config.SetRoot(clientCtx.HomeDir)
+
+			format, err := cmd.Flags().GetString(flagAllocationFormat)
+			if err != nil {
+				return err
+			}
+
+			allocations, err := readGenesisAllocations(args[0], format)
+			if err != nil {
+				return err
+			}
+
+			genAccounts := make([]authtypes.GenesisAccount, len(allocations))
+			balances := make([]banktypes.Balance, len(allocations))
+			for i, alloc := range allocations {
+				genAccounts[i], balances[i], err = alloc.toGenesisAccount()
+				if err != nil {
+					return fmt.Errorf("entry %d (%s): %w", i+1, alloc.Address, err)
+				}
+			}
+
+			genFile := config.GenesisFile()
+			appState, genDoc, err := genutiltypes.GenesisStateFromGenFile(genFile)
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal genesis state: %w", err)
+			}
+
+			if err := addGenesisAccounts(cdc, appState, genAccounts, balances); err != nil {
+				return err
+			}
+
+			appStateJSON, err := json.Marshal(appState)
+			if err != nil {
+				return fmt.Errorf("failed to marshal application genesis state: %w", err)
+			}
+
+			genDoc.AppState = appStateJSON
+			if err := genutil.ExportGenesisFile(genDoc, genFile); err != nil {
+				return err
+			}
+
+			return printAllocationSummary(cmd.OutOrStdout(), genAccounts, balances)
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+	cmd.Flags().String(flagAllocationFormat, "", "Format of the allocation file (csv|json); derived from the file extension by default")
+
+	return cmd
+}
+
+// toGenesisAccount creates the genesis account and balance of the entry.
+func (alloc genesisAllocation) toGenesisAccount() (authtypes.GenesisAccount, banktypes.Balance, error) {
+	addr, err := sdk.AccAddressFromBech32(alloc.Address)
+	if err != nil {
+		return nil, banktypes.Balance{}, fmt.Errorf("invalid address: %w", err)
+	}
+
+	coins, err := sdk.ParseCoinsNormalized(alloc.Coins)
+	if err != nil {
+		return nil, banktypes.Balance{}, fmt.Errorf("failed to parse coins: %w", err)
+	}
+
+	vestingAmt, err := sdk.ParseCoinsNormalized(alloc.VestingAmount)
+	if err != nil {
+		return nil, banktypes.Balance{}, fmt.Errorf("failed to parse vesting amount: %w", err)
+	}
+
+	vesting := genesisVesting{Amount: vestingAmt, Start: alloc.VestingStartTime, End: alloc.VestingEndTime}
+	if alloc.VestingPeriods != nil {
+		if alloc.VestingStartTime != 0 || alloc.VestingEndTime != 0 {
+			return nil, banktypes.Balance{}, fmt.Errorf("vesting periods cannot be combined with vesting start or end time")
+		}
+
+		vesting.Start, vesting.Periods, err = alloc.VestingPeriods.toPeriods()
+		if err != nil {
+			return nil, banktypes.Balance{}, err
+		}
+	}
+
+	return newGenesisAccount(addr, coins, vesting)
+}
+
+// readGenesisAllocations reads the entries of a CSV or JSON allocation file.
+func readGenesisAllocations(path, format string) ([]genesisAllocation, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open allocation file: %w", err)
+	}
+	defer f.Close()
+
+	var allocations []genesisAllocation
+	switch format {
+	case allocationFormatCSV:
+		allocations, err = parseCSVAllocations(f)
+
+	case allocationFormatJSON:
+		allocations, err = parseJSONAllocations(f)
+
+	default:
+		return nil, fmt.Errorf("unknown allocation file format %q; must be %s or %s", format, allocationFormatCSV, allocationFormatJSON)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(allocations) == 0 {
+		return nil, fmt.Errorf("allocation file %s has no entries", path)
+	}
+
+	return allocations, nil
+}
+
+func parseJSONAllocations(r io.Reader) ([]genesisAllocation, error) {
+	bz, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allocation file: %w", err)
+	}
+
+	var allocations []genesisAllocation
+	if err := json.Unmarshal(bz, &allocations); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON allocation file: %w", err)
+	}
+
+	return allocations, nil
+}
+
+// parseCSVAllocations parses the allocations of a CSV file line by line, so
+// that errors report the line of the file. Blank lines and comment lines
+// starting with # are skipped, and so is the header line, if any.
+func parseCSVAllocations(r io.Reader) ([]genesisAllocation, error) {
+	var (
+		allocations []genesisAllocation
+		firstRecord = true
+	)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		reader := csv.NewReader(strings.NewReader(text))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		record, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: failed to parse CSV allocation: %w", line, err)
+		}
+
+		if firstRecord {
+			firstRecord = false
+			if strings.EqualFold(record[0], csvAllocationHeader[0]) {
+				continue
+			}
+		}
+
+		if len(record) < 2 || len(record) > len(csvAllocationHeader) {
+			return nil, fmt.Errorf("line %d: expected between 2 and %d columns, got %d", line, len(csvAllocationHeader), len(record))
+		}
+
+		alloc := genesisAllocation{Address: record[0], Coins: record[1]}
+		if len(record) > 2 {
+			alloc.VestingAmount = record[2]
+		}
+		if len(record) > 3 && record[3] != "" {
+			if alloc.VestingStartTime, err = strconv.ParseInt(record[3], 10, 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid vesting start time: %w", line, err)
+			}
+		}
+		if len(record) > 4 && record[4] != "" {
+			if alloc.VestingEndTime, err = strconv.ParseInt(record[4], 10, 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid vesting end time: %w", line, err)
+			}
+		}
+
+		allocations = append(allocations, alloc)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CSV allocation file: %w", err)
+	}
+
+	return allocations, nil
+}
+
+// printAllocationSummary prints the number of accounts added and the total and
+// vesting amounts per denom.
+func printAllocationSummary(w io.Writer, genAccounts []authtypes.GenesisAccount, balances []banktypes.Balance) error {
+	total := sdk.NewCoins()
+	for _, balance := range balances {
+		total = total.Add(balance.Coins...)
+	}
+
+	vesting := sdk.NewCoins()
+	vestingAccounts := 0
+	for _, acc := range genAccounts {
+		if vacc, ok := acc.(vestingexported.VestingAccount); ok {
+			vesting = vesting.Add(vacc.GetOriginalVesting()...)
+			vestingAccounts++
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "added %d genesis accounts (%d vesting)\n\n", len(genAccounts), vestingAccounts); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DENOM\tTOTAL\tVESTING")
+	for _, coin := range total {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", coin.Denom, coin.Amount, vesting.AmountOf(coin.Denom))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	authvesting "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestParseVestingPeriods(t *testing.T) {
+	start, periods, err := parseVestingPeriods([]byte(`{
+		"start_time": 1625097600,
+		"periods": [
+			{"length": 31536000, "amount": "250uatom"},
+			{"length": 2592000, "amount": "50uatom,10stake"}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, int64(1625097600), start)
+	require.Equal(t, authvesting.Periods{
+		{Length: 31536000, Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 250))},
+		{Length: 2592000, Amount: sdk.NewCoins(sdk.NewInt64Coin("stake", 10), sdk.NewInt64Coin("uatom", 50))},
+	}, periods)
+
+	invalid := []string{
+		`{"periods": [{"length": 1, "amount": "1uatom"}]}`,
+		`{"start_time": 1, "periods": []}`,
+		`{"start_time": 1, "periods": [{"length": 0, "amount": "1uatom"}]}`,
+		`{"start_time": 1, "periods": [{"length": 1, "amount": "uatom"}]}`,
+		`not json`,
+	}
+	for _, bz := range invalid {
+		_, _, err := parseVestingPeriods([]byte(bz))
+		require.Error(t, err, bz)
+	}
+}
+
+func TestNewGenesisAccount(t *testing.T) {
+	addr := sdk.AccAddress([]byte("addr1_______________"))
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000))
+	periods := authvesting.Periods{
+		{Length: 100, Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 300))},
+		{Length: 50, Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 200))},
+	}
+
+	acc, balance, err := newGenesisAccount(addr, coins, genesisVesting{})
+	require.NoError(t, err)
+	require.IsType(t, &authtypes.BaseAccount{}, acc)
+	require.Equal(t, coins, balance.Coins)
+
+	acc, _, err = newGenesisAccount(addr, coins, genesisVesting{Start: 1000, Periods: periods})
+	require.NoError(t, err)
+	pva, ok := acc.(*authvesting.PeriodicVestingAccount)
+	require.True(t, ok)
+	require.Equal(t, int64(1000), pva.StartTime)
+	require.Equal(t, int64(1150), pva.EndTime)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 500)), pva.OriginalVesting)
+
+	_, _, err = newGenesisAccount(addr, coins, genesisVesting{Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 500)), Start: 1000, Periods: periods})
+	require.NoError(t, err)
+
+	// periods must add up to the vesting amount
+	_, _, err = newGenesisAccount(addr, coins, genesisVesting{Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 400)), Start: 1000, Periods: periods})
+	require.Error(t, err)
+
+	// and be of the denoms of the vesting amount
+	coins = coins.Add(sdk.NewInt64Coin("stake", 1000))
+	_, _, err = newGenesisAccount(addr, coins, genesisVesting{Amount: sdk.NewCoins(sdk.NewInt64Coin("stake", 500)), Start: 1000, Periods: periods})
+	require.EqualError(t, err, "vesting periods add up to 500uatom, not to the vesting amount 500stake")
+	_, _, err = newGenesisAccount(addr, coins, genesisVesting{Amount: sdk.NewCoins(sdk.NewInt64Coin("stake", 500), sdk.NewInt64Coin("uatom", 500)), Start: 1000, Periods: periods})
+	require.Error(t, err)
+
+	// vesting more than the balance
+	_, _, err = newGenesisAccount(addr, sdk.NewCoins(sdk.NewInt64Coin("uatom", 400)), genesisVesting{Start: 1000, Periods: periods})
+	require.Error(t, err)
+
+	acc, _, err = newGenesisAccount(addr, coins, genesisVesting{Amount: coins, Start: 1000, End: 2000})
+	require.NoError(t, err)
+	require.IsType(t, &authvesting.ContinuousVestingAccount{}, acc)
+
+	acc, _, err = newGenesisAccount(addr, coins, genesisVesting{Amount: coins, End: 2000})
+	require.NoError(t, err)
+	require.IsType(t, &authvesting.DelayedVestingAccount{}, acc)
+}
+
+func TestAddGenesisAccounts(t *testing.T) {
+	cdc := gaia.MakeEncodingConfig().Marshaler
+	appState := gaia.ModuleBasics.DefaultGenesis(cdc)
+
+	addr1 := sdk.AccAddress([]byte("addr1_______________"))
+	addr2 := sdk.AccAddress([]byte("addr2_______________"))
+	acc1, bal1, err := newGenesisAccount(addr1, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000)), genesisVesting{})
+	require.NoError(t, err)
+	acc2, bal2, err := newGenesisAccount(addr2, sdk.NewCoins(sdk.NewInt64Coin("uatom", 500), sdk.NewInt64Coin("stake", 5)), genesisVesting{})
+	require.NoError(t, err)
+
+	require.NoError(t, addGenesisAccounts(cdc, appState, []authtypes.GenesisAccount{acc1, acc2}, []banktypes.Balance{bal1, bal2}))
+
+	authGenState := authtypes.GetGenesisStateFromAppState(cdc, appState)
+	require.Len(t, authGenState.Accounts, 2)
+	bankGenState := banktypes.GetGenesisStateFromAppState(cdc, appState)
+	require.Len(t, bankGenState.Balances, 2)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("stake", 5), sdk.NewInt64Coin("uatom", 1500)), bankGenState.Supply)
+
+	// existing addresses are rejected
+	require.Error(t, addGenesisAccounts(cdc, appState, []authtypes.GenesisAccount{acc1}, []banktypes.Balance{bal1}))
+}
+
+func TestParseCSVAllocations(t *testing.T) {
+	allocations, err := parseCSVAllocations(strings.NewReader(`address,coins,vesting_amount,vesting_start_time,vesting_end_time
+# comment lines are ignored
+cosmos1a,"1000uatom,10stake"
+cosmos1b,1000uatom,500uatom,1625097600,1656633600
+cosmos1c,1000uatom,500uatom,,1656633600
+`))
+	require.NoError(t, err)
+	require.Equal(t, []genesisAllocation{
+		{Address: "cosmos1a", Coins: "1000uatom,10stake"},
+		{Address: "cosmos1b", Coins: "1000uatom", VestingAmount: "500uatom", VestingStartTime: 1625097600, VestingEndTime: 1656633600},
+		{Address: "cosmos1c", Coins: "1000uatom", VestingAmount: "500uatom", VestingEndTime: 1656633600},
+	}, allocations)
+
+	_, err = parseCSVAllocations(strings.NewReader("cosmos1a\n"))
+	require.EqualError(t, err, "line 1: expected between 2 and 5 columns, got 1")
+
+	// errors report the line in the file, counting the header and comments
+	_, err = parseCSVAllocations(strings.NewReader(`address,coins
+# comment
+
+cosmos1a,1uatom
+cosmos1b,1uatom,1uatom,soon
+`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line 5: invalid vesting start time")
+}
+
+func TestGenesisAllocationToGenesisAccount(t *testing.T) {
+	addr := sdk.AccAddress([]byte("addr1_______________"))
+
+	allocations, err := parseJSONAllocations(strings.NewReader(`[
+		{"address": "` + addr.String() + `", "coins": "1000uatom"},
+		{
+			"address": "` + addr.String() + `",
+			"coins": "1000uatom",
+			"vesting_periods": {"start_time": 1000, "periods": [{"length": 10, "amount": "600uatom"}]}
+		}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, allocations, 2)
+
+	acc, _, err := allocations[0].toGenesisAccount()
+	require.NoError(t, err)
+	require.IsType(t, &authtypes.BaseAccount{}, acc)
+
+	acc, _, err = allocations[1].toGenesisAccount()
+	require.NoError(t, err)
+	require.IsType(t, &authvesting.PeriodicVestingAccount{}, acc)
+
+	allocations[1].VestingEndTime = 2000
+	_, _, err = allocations[1].toGenesisAccount()
+	require.Error(t, err)
+
+	_, _, err = genesisAllocation{Address: "cosmos1invalid", Coins: "1uatom"}.toGenesisAccount()
+	require.Error(t, err)
+}
+
+func TestPrintAllocationSummary(t *testing.T) {
+	addr1 := sdk.AccAddress([]byte("addr1_______________"))
+	addr2 := sdk.AccAddress([]byte("addr2_______________"))
+	acc1, bal1, err := newGenesisAccount(addr1, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000)), genesisVesting{})
+	require.NoError(t, err)
+	acc2, bal2, err := newGenesisAccount(addr2, sdk.NewCoins(sdk.NewInt64Coin("uatom", 500), sdk.NewInt64Coin("stake", 5)),
+		genesisVesting{Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 200)), End: 2000})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, printAllocationSummary(&buf, []authtypes.GenesisAccount{acc1, acc2}, []banktypes.Balance{bal1, bal2}))
+	require.Equal(t, `added 2 genesis accounts (1 vesting)
+
+DENOM  TOTAL  VESTING
+stake  5      0
+uatom  1500   200
+`, buf.String())
+}
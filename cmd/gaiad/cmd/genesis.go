@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/server"
+	"github.com/cosmos/cosmos-sdk/x/genutil"
+	genutiltypes "github.com/cosmos/cosmos-sdk/x/genutil/types"
+)
+
+// GenesisCmd returns the genesis cobra Command, grouping the commands editing
+// genesis.json.
+func GenesisCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                        "genesis",
+		Short:                      "Inspect and edit genesis.json",
+		DisableFlagParsing:         true,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		DenomMetadataCmd(defaultNodeHome),
+		ReplaceConsKeysCmd(defaultNodeHome),
+	)
+
+	return cmd
+}
+
+// readGenesisFile reads the app state and genesis document of the genesis
+// file of the home directory of the command.
+func readGenesisFile(cmd *cobra.Command) (string, map[string]json.RawMessage, *tmtypes.GenesisDoc, error) {
+	clientCtx := client.GetClientContextFromCmd(cmd)
+
+	serverCtx := server.GetServerContextFromCmd(cmd)
+	config := serverCtx.Config
+
+	config.SetRoot(clientCtx.HomeDir)
+
+	genFile := config.GenesisFile()
+	appState, genDoc, err := genutiltypes.GenesisStateFromGenFile(genFile)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to unmarshal genesis state: %w", err)
+	}
+
+	return genFile, appState, genDoc, nil
+}
+
+// writeGenesisFile writes the app state and genesis document to genFile.
+func writeGenesisFile(genFile string, appState map[string]json.RawMessage, genDoc *tmtypes.GenesisDoc) error {
+	appStateJSON, err := json.Marshal(appState)
+	if err != nil {
+		return fmt.Errorf("failed to marshal application genesis state: %w", err)
+	}
+
+	genDoc.AppState = appStateJSON
+	return genutil.ExportGenesisFile(genDoc, genFile)
+}
@@ -0,0 +1,391 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/server"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+const flagGenesisSpec = "config"
+
+// GenesisSpec is a declarative description of the accounts, validators and
+// module parameters of a genesis file, read from a YAML file such as the
+// config.yml of the repository:
+//
+//	version: 1
+//	chain_id: gaia-local
+//	accounts:
+//	  - name: alice
+//	    coins: ["1000token", "100000000stake"]
+//	  - name: bob
+//	    coins: ["500token"]
+//	    vesting:
+//	      coins: ["300token"]
+//	      end_time: 1700000000
+//	  - name: carol
+//	    address: cosmos1...
+//	    coins: ["10token"]
+//	validator:
+//	  name: alice
+//	  staked: "100000000stake"
+//	  commission:
+//	    rate: "0.1"
+//	    max_rate: "0.2"
+//	    max_change_rate: "0.01"
+//	genesis:
+//	  staking:
+//	    params:
+//	      unbonding_time: "600s"
+//
+// Accounts without an address are keys of the keyring, created from their
+// mnemonic, or from a new one, if they do not exist. Every validator names the
+// account it is created from. Genesis holds module genesis overrides by module
+// name, merged into the default genesis state of the module.
+type GenesisSpec struct {
+	Version    int                    `yaml:"version"`
+	ChainID    string                 `yaml:"chain_id"`
+	Accounts   []AccountSpec          `yaml:"accounts"`
+	Validator  *ValidatorSpec         `yaml:"validator"`
+	Validators []ValidatorSpec        `yaml:"validators"`
+	Genesis    map[string]interface{} `yaml:"genesis"`
+}
+
+// AccountSpec is a genesis account of a GenesisSpec.
+type AccountSpec struct {
+	Name     string       `yaml:"name"`
+	Address  string       `yaml:"address"`
+	Mnemonic string       `yaml:"mnemonic"`
+	Coins    []string     `yaml:"coins"`
+	Vesting  *VestingSpec `yaml:"vesting"`
+}
+
+// VestingSpec is the vesting schedule of an AccountSpec. The account is a
+// delayed vesting account if it has no start time, and a continuous vesting
+// account otherwise.
+type VestingSpec struct {
+	Coins     []string `yaml:"coins"`
+	StartTime int64    `yaml:"start_time"`
+	EndTime   int64    `yaml:"end_time"`
+}
+
+// ValidatorSpec is a genesis validator of a GenesisSpec, created by a gentx
+// signed by the key of the account of the same name.
+type ValidatorSpec struct {
+	Name              string          `yaml:"name"`
+	Staked            string          `yaml:"staked"`
+	Commission        *CommissionSpec `yaml:"commission"`
+	MinSelfDelegation string          `yaml:"min_self_delegation"`
+}
+
+// CommissionSpec holds the commission rates of a ValidatorSpec.
+type CommissionSpec struct {
+	Rate          string `yaml:"rate"`
+	MaxRate       string `yaml:"max_rate"`
+	MaxChangeRate string `yaml:"max_change_rate"`
+}
+
+// ReadGenesisSpec reads and validates the GenesisSpec of a YAML file.
+func ReadGenesisSpec(path string) (GenesisSpec, error) {
+	bz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return GenesisSpec{}, err
+	}
+
+	var spec GenesisSpec
+	if err := yaml.UnmarshalStrict(bz, &spec); err != nil {
+		return GenesisSpec{}, fmt.Errorf("failed to parse genesis spec %s: %w", path, err)
+	}
+
+	if err := spec.Validate(); err != nil {
+		return GenesisSpec{}, fmt.Errorf("invalid genesis spec %s: %w", path, err)
+	}
+
+	return spec, nil
+}
+
+// Validate checks that the accounts are named or have an address, that names
+// are unique and that every validator names an account without an address.
+func (s GenesisSpec) Validate() error {
+	if s.Version > 1 {
+		return fmt.Errorf("unsupported version %d", s.Version)
+	}
+
+	names := make(map[string]AccountSpec)
+	for i, acc := range s.Accounts {
+		if acc.Name == "" && acc.Address == "" {
+			return fmt.Errorf("account %d has neither a name nor an address", i+1)
+		}
+		if acc.Name == "" {
+			continue
+		}
+		if _, ok := names[acc.Name]; ok {
+			return fmt.Errorf("duplicate account %s", acc.Name)
+		}
+		names[acc.Name] = acc
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range s.ValidatorList() {
+		acc, ok := names[v.Name]
+		if !ok {
+			return fmt.Errorf("validator %s has no account", v.Name)
+		}
+		if acc.Address != "" {
+			return fmt.Errorf("the account of validator %s must be a key, not an address", v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate validator %s", v.Name)
+		}
+		seen[v.Name] = true
+	}
+
+	return nil
+}
+
+// ValidatorList returns the validator and the validators of the spec.
+func (s GenesisSpec) ValidatorList() []ValidatorSpec {
+	var validators []ValidatorSpec
+	if s.Validator != nil {
+		validators = append(validators, *s.Validator)
+	}
+
+	return append(validators, s.Validators...)
+}
+
+// Account returns the account of the given name.
+func (s GenesisSpec) Account(name string) (AccountSpec, bool) {
+	for _, acc := range s.Accounts {
+		if acc.Name == name {
+			return acc, true
+		}
+	}
+
+	return AccountSpec{}, false
+}
+
+// isValidator reports whether the account is the account of a validator.
+func (s GenesisSpec) isValidator(acc AccountSpec) bool {
+	for _, v := range s.ValidatorList() {
+		if acc.Name != "" && v.Name == acc.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// address returns the address of the account, creating its key in the keyring
+// if it has no address and the key does not exist. The mnemonic of a key
+// created from a new mnemonic is returned too.
+func (acc AccountSpec) address(kb keyring.Keyring, algo keyring.SignatureAlgo) (sdk.AccAddress, string, error) {
+	if acc.Address != "" {
+		addr, err := sdk.AccAddressFromBech32(acc.Address)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid address of account %s: %w", acc.Name, err)
+		}
+
+		return addr, "", nil
+	}
+
+	if info, err := kb.Key(acc.Name); err == nil {
+		return info.GetAddress(), "", nil
+	}
+
+	if acc.Mnemonic != "" {
+		info, err := kb.NewAccount(acc.Name, acc.Mnemonic, keyring.DefaultBIP39Passphrase, sdk.FullFundraiserPath, algo)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to recover key %s: %w", acc.Name, err)
+		}
+
+		return info.GetAddress(), "", nil
+	}
+
+	return server.GenerateSaveCoinKey(kb, acc.Name, false, algo)
+}
+
+// genesisAccount creates the genesis account and balance of the account.
+func (acc AccountSpec) genesisAccount(addr sdk.AccAddress) (authtypes.GenesisAccount, banktypes.Balance, error) {
+	coins, err := sdk.ParseCoinsNormalized(strings.Join(acc.Coins, ","))
+	if err != nil {
+		return nil, banktypes.Balance{}, fmt.Errorf("failed to parse coins of account %s: %w", acc.Name, err)
+	}
+
+	var vesting genesisVesting
+	if acc.Vesting != nil {
+		vesting.Amount, err = sdk.ParseCoinsNormalized(strings.Join(acc.Vesting.Coins, ","))
+		if err != nil {
+			return nil, banktypes.Balance{}, fmt.Errorf("failed to parse vesting coins of account %s: %w", acc.Name, err)
+		}
+		vesting.Start, vesting.End = acc.Vesting.StartTime, acc.Vesting.EndTime
+	}
+
+	genAccount, balance, err := newGenesisAccount(addr, coins, vesting)
+	if err != nil {
+		return nil, banktypes.Balance{}, fmt.Errorf("account %s: %w", acc.Name, err)
+	}
+
+	return genAccount, balance, nil
+}
+
+// createValidatorMsg returns the MsgCreateValidator of the validator. The
+// stake defaults to 100 consensus power of the default bond denom, and the
+// commission rates to those of the gentx command.
+func (v ValidatorSpec) createValidatorMsg(addr sdk.AccAddress, pubKey cryptotypes.PubKey, moniker string) (*stakingtypes.MsgCreateValidator, error) {
+	staked := sdk.NewCoin(sdk.DefaultBondDenom, sdk.TokensFromConsensusPower(100))
+	if v.Staked != "" {
+		var err error
+		if staked, err = sdk.ParseCoinNormalized(v.Staked); err != nil {
+			return nil, fmt.Errorf("failed to parse stake of validator %s: %w", v.Name, err)
+		}
+	}
+
+	commission := CommissionSpec{Rate: "0.1", MaxRate: "0.2", MaxChangeRate: "0.01"}
+	if v.Commission != nil {
+		commission = *v.Commission
+	}
+
+	rates := make([]sdk.Dec, 3)
+	for i, rate := range []string{commission.Rate, commission.MaxRate, commission.MaxChangeRate} {
+		var err error
+		if rates[i], err = sdk.NewDecFromStr(rate); err != nil {
+			return nil, fmt.Errorf("invalid commission rate %q of validator %s: %w", rate, v.Name, err)
+		}
+	}
+
+	minSelfDelegation := sdk.OneInt()
+	if v.MinSelfDelegation != "" {
+		var ok bool
+		if minSelfDelegation, ok = sdk.NewIntFromString(v.MinSelfDelegation); !ok {
+			return nil, fmt.Errorf("invalid minimum self delegation %q of validator %s", v.MinSelfDelegation, v.Name)
+		}
+	}
+
+	msg, err := stakingtypes.NewMsgCreateValidator(
+		sdk.ValAddress(addr),
+		pubKey,
+		staked,
+		stakingtypes.NewDescription(moniker, "", "", "", ""),
+		stakingtypes.NewCommissionRates(rates[0], rates[1], rates[2]),
+		minSelfDelegation,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, msg.ValidateBasic()
+}
+
+// signGentx signs a gentx of the message with the key of the keyring, and
+// returns it JSON-encoded.
+func signGentx(clientCtx client.Context, kb keyring.Keyring, chainID, keyName, memo string, msg sdk.Msg) ([]byte, error) {
+	txBuilder := clientCtx.TxConfig.NewTxBuilder()
+	if err := txBuilder.SetMsgs(msg); err != nil {
+		return nil, err
+	}
+
+	txBuilder.SetMemo(memo)
+
+	txFactory := tx.Factory{}
+	txFactory = txFactory.
+		WithChainID(chainID).
+		WithMemo(memo).
+		WithKeybase(kb).
+		WithTxConfig(clientCtx.TxConfig)
+
+	if err := tx.Sign(txFactory, keyName, txBuilder, true); err != nil {
+		return nil, err
+	}
+
+	return clientCtx.TxConfig.TxJSONEncoder()(txBuilder.GetTx())
+}
+
+// applyGenesisOverrides merges the genesis overrides of the modules into
+// their genesis state, and validates the result.
+func applyGenesisOverrides(
+	mbm module.BasicManager, cdc codec.JSONMarshaler, txConfig client.TxEncodingConfig,
+	appState map[string]json.RawMessage, overrides map[string]interface{},
+) error {
+	for name, override := range overrides {
+		basic, ok := mbm[name]
+		if !ok {
+			return fmt.Errorf("genesis override of unknown module %s", name)
+		}
+
+		var state interface{}
+		if len(appState[name]) > 0 {
+			if err := json.Unmarshal(appState[name], &state); err != nil {
+				return err
+			}
+		}
+
+		bz, err := json.Marshal(mergeJSON(state, jsonValue(override)))
+		if err != nil {
+			return fmt.Errorf("invalid genesis override of module %s: %w", name, err)
+		}
+
+		if err := basic.ValidateGenesis(cdc, txConfig, bz); err != nil {
+			return fmt.Errorf("invalid genesis override of module %s: %w", name, err)
+		}
+		appState[name] = bz
+	}
+
+	return nil
+}
+
+// mergeJSON merges the override into the JSON value; objects are merged key
+// by key and any other value is replaced.
+func mergeJSON(value, override interface{}) interface{} {
+	valueMap, ok := value.(map[string]interface{})
+	overrideMap, ok2 := override.(map[string]interface{})
+	if !ok || !ok2 {
+		return override
+	}
+
+	for k, v := range overrideMap {
+		valueMap[k] = mergeJSON(valueMap[k], v)
+	}
+
+	return valueMap
+}
+
+// jsonValue converts a YAML value, whose maps have interface{} keys, to a JSON
+// value.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			m[fmt.Sprint(k)] = jsonValue(child)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			m[k] = jsonValue(child)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, child := range v {
+			s[i] = jsonValue(child)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authvesting "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+func TestReadGenesisSpec(t *testing.T) {
+	spec, err := ReadGenesisSpec(filepath.Join("..", "..", "..", "config.yml"))
+	require.NoError(t, err)
+	require.Len(t, spec.Accounts, 2)
+	require.Equal(t, []ValidatorSpec{{Name: "alice", Staked: "100000000stake"}}, spec.ValidatorList())
+
+	invalid := map[string]string{
+		"unknown field":        "accounts:\n  - name: alice\n    coin: [1stake]\n",
+		"unnamed account":      "accounts:\n  - coins: [1stake]\n",
+		"duplicate account":    "accounts:\n  - name: alice\n  - name: alice\n",
+		"validator no account": "validator:\n  name: alice\n",
+		"validator address": "accounts:\n  - name: alice\n    address: cosmos1cvuag8kgm4vlxu4jq3eqptnr5z9rzay77pmtfq\n" +
+			"validator:\n  name: alice\n",
+		"duplicate validator": "accounts:\n  - name: alice\nvalidators:\n  - name: alice\n  - name: alice\n",
+		"version":             "version: 2\n",
+	}
+	for name, bz := range invalid {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(bz), 0600))
+
+		_, err := ReadGenesisSpec(path)
+		require.Error(t, err, name)
+	}
+}
+
+func TestTestnetSpec(t *testing.T) {
+	spec, err := testnetSpec(GenesisSpec{Accounts: []AccountSpec{{Name: "alice", Coins: []string{"1stake"}}}}, "node", 2)
+	require.NoError(t, err)
+	require.Len(t, spec.Accounts, 3)
+	require.Equal(t, []string{"1000000000node1token", "500000000stake"}, spec.Accounts[2].Coins)
+	require.Len(t, spec.ValidatorList(), 2)
+	require.Equal(t, "node0", spec.ValidatorList()[0].Name)
+
+	// the validators of a spec replace the default ones
+	withValidator := GenesisSpec{
+		Accounts:  []AccountSpec{{Name: "alice", Coins: []string{"1stake"}}},
+		Validator: &ValidatorSpec{Name: "alice"},
+	}
+	spec, err = testnetSpec(withValidator, "node", 4)
+	require.NoError(t, err)
+	require.Len(t, spec.Accounts, 1)
+	require.Len(t, spec.ValidatorList(), 1)
+
+	_, err = testnetSpec(GenesisSpec{}, "node", 0)
+	require.Error(t, err)
+}
+
+func TestAccountSpec(t *testing.T) {
+	kb := keyring.NewInMemory()
+
+	acc := AccountSpec{Name: "alice", Coins: []string{"10stake", "5uatom"}, Vesting: &VestingSpec{Coins: []string{"5uatom"}, EndTime: 100}}
+	addr, mnemonic, err := acc.address(kb, hd.Secp256k1)
+	require.NoError(t, err)
+	require.NotEmpty(t, mnemonic)
+
+	// the key is reused
+	again, mnemonic, err := acc.address(kb, hd.Secp256k1)
+	require.NoError(t, err)
+	require.Equal(t, addr, again)
+	require.Empty(t, mnemonic)
+
+	genAccount, balance, err := acc.genesisAccount(addr)
+	require.NoError(t, err)
+	require.IsType(t, &authvesting.DelayedVestingAccount{}, genAccount)
+	require.Equal(t, "10stake,5uatom", balance.Coins.String())
+
+	// keys are recovered from their mnemonic
+	recovered, _, err := AccountSpec{Name: "bob", Mnemonic: mnemonicOf(t, kb)}.address(keyring.NewInMemory(), hd.Secp256k1)
+	require.NoError(t, err)
+	require.NotEmpty(t, recovered)
+
+	_, _, err = AccountSpec{Name: "carol", Coins: []string{"x"}}.genesisAccount(addr)
+	require.Error(t, err)
+}
+
+func mnemonicOf(t *testing.T, kb keyring.Keyring) string {
+	_, mnemonic, err := kb.NewMnemonic("tmp", keyring.English, sdk.FullFundraiserPath, hd.Secp256k1)
+	require.NoError(t, err)
+	return mnemonic
+}
+
+func TestValidatorSpecCreateValidatorMsg(t *testing.T) {
+	kb := keyring.NewInMemory()
+	info, _, err := kb.NewMnemonic("alice", keyring.English, sdk.FullFundraiserPath, hd.Secp256k1)
+	require.NoError(t, err)
+
+	msg, err := ValidatorSpec{Name: "alice"}.createValidatorMsg(info.GetAddress(), info.GetPubKey(), "node")
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewCoin(sdk.DefaultBondDenom, sdk.TokensFromConsensusPower(100)), msg.Value)
+	require.Equal(t, stakingtypes.NewCommissionRates(sdk.NewDecWithPrec(1, 1), sdk.NewDecWithPrec(2, 1), sdk.NewDecWithPrec(1, 2)), msg.Commission)
+
+	msg, err = ValidatorSpec{
+		Name:              "alice",
+		Staked:            "5uatom",
+		Commission:        &CommissionSpec{Rate: "0.05", MaxRate: "0.1", MaxChangeRate: "0.1"},
+		MinSelfDelegation: "2",
+	}.createValidatorMsg(info.GetAddress(), info.GetPubKey(), "node")
+	require.NoError(t, err)
+	require.Equal(t, "5uatom", msg.Value.String())
+	require.Equal(t, sdk.NewInt(2), msg.MinSelfDelegation)
+
+	_, err = ValidatorSpec{Name: "alice", Commission: &CommissionSpec{Rate: "0.5", MaxRate: "0.1", MaxChangeRate: "0.1"}}.
+		createValidatorMsg(info.GetAddress(), info.GetPubKey(), "node")
+	require.Error(t, err)
+}
+
+func TestApplyGenesisOverrides(t *testing.T) {
+	encodingConfig := gaia.MakeEncodingConfig()
+	cdc := encodingConfig.Marshaler
+	appState := gaia.ModuleBasics.DefaultGenesis(cdc)
+
+	overrides := map[interface{}]interface{}{
+		"staking": map[interface{}]interface{}{
+			"params": map[interface{}]interface{}{"unbonding_time": "600s", "max_validators": 50},
+		},
+	}
+	require.NoError(t, applyGenesisOverrides(gaia.ModuleBasics, cdc, encodingConfig.TxConfig, appState, jsonValue(overrides).(map[string]interface{})))
+
+	var stakingGenState stakingtypes.GenesisState
+	cdc.MustUnmarshalJSON(appState[stakingtypes.ModuleName], &stakingGenState)
+	require.Equal(t, uint32(50), stakingGenState.Params.MaxValidators)
+	require.Equal(t, 10*time.Minute, stakingGenState.Params.UnbondingTime)
+	// the other params are kept
+	require.Equal(t, sdk.DefaultBondDenom, stakingGenState.Params.BondDenom)
+
+	invalid := []map[string]interface{}{
+		{"unknown": map[string]interface{}{}},
+		{"staking": map[string]interface{}{"params": map[string]interface{}{"unknown_param": 1}}},
+		{"staking": map[string]interface{}{"params": map[string]interface{}{"bond_denom": ""}}},
+	}
+	for _, override := range invalid {
+		err := applyGenesisOverrides(gaia.ModuleBasics, cdc, encodingConfig.TxConfig, gaia.ModuleBasics.DefaultGenesis(cdc), override)
+		require.Error(t, err, override)
+	}
+}
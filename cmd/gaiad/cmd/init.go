@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/server"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/cosmos/cosmos-sdk/x/genutil"
+	genutilcli "github.com/cosmos/cosmos-sdk/x/genutil/client/cli"
+	genutiltypes "github.com/cosmos/cosmos-sdk/x/genutil/types"
+	stakingcli "github.com/cosmos/cosmos-sdk/x/staking/client/cli"
+)
+
+// defaultNodeIP is the IP of the gentx memo when the external IP of the node
+// cannot be found.
+const defaultNodeIP = "127.0.0.1"
+
+// InitCmd returns the init cobra Command of the genutil module, extended to
+// build the genesis file from a YAML genesis spec given with --config.
+func InitCmd(mbm module.BasicManager, genBalIterator banktypes.GenesisBalancesIterator, defaultNodeHome string) *cobra.Command {
+	cmd := genutilcli.InitCmd(mbm, defaultNodeHome)
+	cmd.Long = `Initialize validators's and node's configuration files.
+
+With --config, the accounts, validator and module genesis overrides of a YAML genesis
+spec, such as the config.yml of the repository, are added to the genesis file. The keys
+of the accounts without an address are created in the keyring unless they exist. The
+gentx of the validator, whose account must be a key, is signed with the consensus key of
+the node and collected into the genesis file, which is then ready to start the node.
+Its memo holds the --ip of the node, which defaults to the external IP of the machine.
+`
+
+	initFiles := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		specFile, _ := cmd.Flags().GetString(flagGenesisSpec)
+		if specFile == "" {
+			return initFiles(cmd, args)
+		}
+
+		spec, err := ReadGenesisSpec(specFile)
+		if err != nil {
+			return err
+		}
+		if len(spec.ValidatorList()) > 1 {
+			return fmt.Errorf("genesis spec %s has %d validators, but a node has at most one", specFile, len(spec.ValidatorList()))
+		}
+
+		if spec.ChainID != "" && !cmd.Flags().Changed(flags.FlagChainID) {
+			if err := cmd.Flags().Set(flags.FlagChainID, spec.ChainID); err != nil {
+				return err
+			}
+		}
+
+		if err := initFiles(cmd, args); err != nil {
+			return err
+		}
+
+		return initGenesisFromSpec(cmd, mbm, genBalIterator, spec, args[0])
+	}
+
+	cmd.Flags().String(flagGenesisSpec, "", "YAML genesis spec of the accounts, validator and module genesis overrides of the genesis file")
+	cmd.Flags().String(flags.FlagKeyringBackend, flags.DefaultKeyringBackend, "Select keyring's backend (os|file|kwallet|pass|test)")
+	cmd.Flags().String(flags.FlagKeyAlgorithm, string(hd.Secp256k1Type), "Key signing algorithm to generate keys for")
+	cmd.Flags().String(stakingcli.FlagIP, "", "The node's public IP in the gentx memo of the --config validator (defaults to the external IP, or "+defaultNodeIP+" if unknown)")
+
+	return cmd
+}
+
+// initGenesisFromSpec adds the accounts, module genesis overrides and
+// validator of the spec to the genesis file written by init.
+func initGenesisFromSpec(
+	cmd *cobra.Command, mbm module.BasicManager, genBalIterator banktypes.GenesisBalancesIterator,
+	spec GenesisSpec, moniker string,
+) error {
+	clientCtx := client.GetClientContextFromCmd(cmd)
+	cdc := clientCtx.JSONMarshaler.(codec.Marshaler)
+
+	keyringBackend, _ := cmd.Flags().GetString(flags.FlagKeyringBackend)
+	algoStr, _ := cmd.Flags().GetString(flags.FlagKeyAlgorithm)
+
+	kb, err := keyring.New(sdk.KeyringServiceName(), keyringBackend, clientCtx.HomeDir, bufio.NewReader(cmd.InOrStdin()))
+	if err != nil {
+		return err
+	}
+
+	keyringAlgos, _ := kb.SupportedAlgorithms()
+	algo, err := keyring.NewSigningAlgoFromString(algoStr, keyringAlgos)
+	if err != nil {
+		return err
+	}
+
+	genFile, appState, genDoc, err := readGenesisFile(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := applyGenesisOverrides(mbm, cdc, clientCtx.TxConfig, appState, spec.Genesis); err != nil {
+		return err
+	}
+
+	addrs := make(map[string]sdk.AccAddress)
+	genAccounts := make([]authtypes.GenesisAccount, len(spec.Accounts))
+	balances := make([]banktypes.Balance, len(spec.Accounts))
+	for i, acc := range spec.Accounts {
+		addr, secret, err := acc.address(kb, algo)
+		if err != nil {
+			return err
+		}
+		addrs[acc.Name] = addr
+
+		if secret != "" {
+			cmd.PrintErrf("Created key %s (%s). Write down its mnemonic, which is the only way to recover it:\n%s\n\n", acc.Name, addr, secret)
+		}
+
+		if genAccounts[i], balances[i], err = acc.genesisAccount(addr); err != nil {
+			return err
+		}
+	}
+
+	if err := addGenesisAccounts(cdc, appState, genAccounts, balances); err != nil {
+		return err
+	}
+
+	if err := writeGenesisFile(genFile, appState, genDoc); err != nil {
+		return err
+	}
+
+	validators := spec.ValidatorList()
+	if len(validators) == 0 {
+		return nil
+	}
+
+	serverCtx := server.GetServerContextFromCmd(cmd)
+	config := serverCtx.Config
+	config.Moniker = moniker
+
+	nodeID, pubKey, err := genutil.InitializeNodeValidatorFiles(config)
+	if err != nil {
+		return err
+	}
+
+	v := validators[0]
+	msg, err := v.createValidatorMsg(addrs[v.Name], pubKey, moniker)
+	if err != nil {
+		return err
+	}
+
+	ip, _ := cmd.Flags().GetString(stakingcli.FlagIP)
+	if ip == "" {
+		if ip, err = server.ExternalIP(); err != nil {
+			// e.g. offline or in a container
+			ip = defaultNodeIP
+			cmd.PrintErrf("Failed to find the external IP of the node (%s); using %s in the gentx memo, set --%s to override it\n", err, ip, stakingcli.FlagIP)
+		}
+	}
+
+	memo := fmt.Sprintf("%s@%s:%d", nodeID, ip, defaultP2PPort)
+	txBz, err := signGentx(clientCtx, kb, genDoc.ChainID, v.Name, memo, msg)
+	if err != nil {
+		return err
+	}
+
+	gentxsDir := filepath.Join(config.RootDir, "config", "gentx")
+	if err := writeFile(fmt.Sprintf("gentx-%s.json", nodeID), gentxsDir, txBz); err != nil {
+		return err
+	}
+
+	genDoc, err = tmtypes.GenesisDocFromFile(genFile)
+	if err != nil {
+		return err
+	}
+
+	initCfg := genutiltypes.NewInitConfig(genDoc.ChainID, gentxsDir, nodeID, pubKey)
+	if _, err := genutil.GenAppStateFromConfig(cdc, clientCtx.TxConfig, config, initCfg, *genDoc, genBalIterator); err != nil {
+		return fmt.Errorf("failed to collect the gentx of validator %s: %w", v.Name, err)
+	}
+
+	cmd.PrintErrf("Created validator %s staking %s\n", v.Name, msg.Value)
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/x/genutil"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+// ReplaceConsKeysCmd returns the replace-cons-keys cobra Command.
+func ReplaceConsKeysCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replace-cons-keys [replacement-keys-file]",
+		Short: "Replace the consensus keys of validators in genesis.json",
+		Long: `Replace the consensus keys of the validators listed in a JSON file. The staking
+validators, the slashing signing infos and missed blocks, and the validators of the
+genesis document are moved to the new consensus addresses. Nothing is written if a
+validator is unknown or listed twice, or if a new key is listed twice or already used
+by another validator. A table of the old and new consensus addresses is printed.
+
+Example replacement keys file:
+
+[
+  {
+    "validator_name": "validator-0",
+    "validator_address": "cosmosvaloper1...",
+    "stargate_consensus_public_key": "cosmosvalconspub1..."
+  }
+]
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cdc := client.GetClientContextFromCmd(cmd).JSONMarshaler
+
+			replacements, err := gaia.ReadConsKeyReplacements(args[0])
+			if err != nil {
+				return err
+			}
+
+			genFile, _, genDoc, err := readGenesisFile(cmd)
+			if err != nil {
+				return err
+			}
+
+			changes, err := gaia.ReplaceConsensusKeys(cdc, genDoc, replacements)
+			if err != nil {
+				return fmt.Errorf("failed to replace consensus keys: %w", err)
+			}
+
+			if err := genutil.ExportGenesisFile(genDoc, genFile); err != nil {
+				return err
+			}
+
+			return gaia.PrintConsKeyChanges(cmd.OutOrStdout(), changes)
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+
+	return cmd
+}
@@ -68,32 +68,58 @@ func initRootCmd(rootCmd *cobra.Command, encodingConfig params.EncodingConfig) {
 	authclient.Codec = encodingConfig.Marshaler
 
 	rootCmd.AddCommand(
-		genutilcli.InitCmd(gaia.ModuleBasics, gaia.DefaultNodeHome),
+		InitCmd(gaia.ModuleBasics, banktypes.GenesisBalancesIterator{}, gaia.DefaultNodeHome),
 		genutilcli.CollectGenTxsCmd(banktypes.GenesisBalancesIterator{}, gaia.DefaultNodeHome),
 		gaia.MigrateGenesisCmd(),
 		genutilcli.GenTxCmd(gaia.ModuleBasics, encodingConfig.TxConfig, banktypes.GenesisBalancesIterator{}, gaia.DefaultNodeHome),
-		genutilcli.ValidateGenesisCmd(gaia.ModuleBasics),
+		ValidateGenesisCmd(gaia.ModuleBasics),
 		AddGenesisAccountCmd(gaia.DefaultNodeHome),
+		BulkAddGenesisAccountCmd(gaia.DefaultNodeHome),
+		GenesisCmd(gaia.DefaultNodeHome),
+		ExportBalancesCmd(gaia.DefaultNodeHome),
 		tmcli.NewCompletionCmd(rootCmd, true),
 		testnetCmd(gaia.ModuleBasics, banktypes.GenesisBalancesIterator{}),
-		debug.Cmd(),
+		debugCmd(),
 	)
 
 	server.AddCommands(rootCmd, gaia.DefaultNodeHome, newApp, createSimappAndExport, addModuleInitFlags)
+	replaceCommand(rootCmd, ExportCmd(gaia.DefaultNodeHome))
 
 	// add keybase, auxiliary RPC, query, and tx child commands
 	rootCmd.AddCommand(
 		rpc.StatusCommand(),
-		queryCommand(),
-		txCommand(),
+		QueryCommand(),
+		TxCommand(),
 		keys.Commands(gaia.DefaultNodeHome),
 	)
 }
+
+// debugCmd returns the SDK debug command extended with the Gaia debug tools.
+func debugCmd() *cobra.Command {
+	cmd := debug.Cmd()
+	cmd.AddCommand(StateDiffCmd(gaia.DefaultNodeHome))
+
+	return cmd
+}
+
+// replaceCommand replaces the child command of the same name with cmd.
+func replaceCommand(parent, cmd *cobra.Command) {
+	for _, c := range parent.Commands() {
+		if c.Name() == cmd.Name() {
+			parent.RemoveCommand(c)
+		}
+	}
+
+	parent.AddCommand(cmd)
+}
+
 func addModuleInitFlags(startCmd *cobra.Command) {
 	crisis.AddModuleInitFlags(startCmd)
 }
 
-func queryCommand() *cobra.Command {
+// QueryCommand returns the query command of gaiad, with the query commands of
+// the modules of the app.
+func QueryCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:                        "query",
 		Aliases:                    []string{"q"},
@@ -117,7 +143,9 @@ func queryCommand() *cobra.Command {
 	return cmd
 }
 
-func txCommand() *cobra.Command {
+// TxCommand returns the tx command of gaiad, with the tx commands of the
+// modules of the app.
+func TxCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:                        "tx",
 		Short:                      "Transactions subcommands",
@@ -196,6 +224,17 @@ func createSimappAndExport(
 	logger log.Logger, db dbm.DB, traceStore io.Writer, height int64, forZeroHeight bool, jailAllowedAddrs []string,
 	appOpts servertypes.AppOptions) (servertypes.ExportedApp, error) {
 
+	gaiaApp, err := newExportApp(logger, db, traceStore, height, appOpts)
+	if err != nil {
+		return servertypes.ExportedApp{}, err
+	}
+
+	return gaiaApp.ExportAppStateAndValidators(forZeroHeight, jailAllowedAddrs)
+}
+
+// newExportApp creates a GaiaApp loaded at the given height, or at the latest
+// height if height is -1, to export its state.
+func newExportApp(logger log.Logger, db dbm.DB, traceStore io.Writer, height int64, appOpts servertypes.AppOptions) (*gaia.GaiaApp, error) {
 	encCfg := gaia.MakeEncodingConfig() // Ideally, we would reuse the one created by NewRootCmd.
 	encCfg.Marshaler = codec.NewProtoCodec(encCfg.InterfaceRegistry)
 	var gaiaApp *gaia.GaiaApp
@@ -203,11 +242,11 @@ func createSimappAndExport(
 		gaiaApp = gaia.NewGaiaApp(logger, db, traceStore, false, map[int64]bool{}, "", cast.ToUint(appOpts.Get(server.FlagInvCheckPeriod)), encCfg, appOpts)
 
 		if err := gaiaApp.LoadHeight(height); err != nil {
-			return servertypes.ExportedApp{}, err
+			return nil, err
 		}
 	} else {
 		gaiaApp = gaia.NewGaiaApp(logger, db, traceStore, true, map[int64]bool{}, "", cast.ToUint(appOpts.Get(server.FlagInvCheckPeriod)), encCfg, appOpts)
 	}
 
-	return gaiaApp.ExportAppStateAndValidators(forZeroHeight, jailAllowedAddrs)
+	return gaiaApp, nil
 }
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+	tmcli "github.com/tendermint/tendermint/libs/cli"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/server"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+const flagDiffLimit = "limit"
+
+// StateDiffCmd returns the debug state-diff cobra Command.
+func StateDiffCmd(defaultNodeHome string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state-diff [height-a|genesis-a] [height-b|genesis-b]",
+		Short: "Compare the state at two heights, or two genesis files",
+		Long: `Compare the application state at two heights of the application DB of the node, store
+by store, or the app state of two genesis files, module by module. Changed store values
+are decoded with the store decoders of the modules. Both arguments are heights if they
+are integers, and genesis files otherwise. The node must be stopped to compare heights.
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, _ := cmd.Flags().GetInt(flagDiffLimit)
+			output, _ := cmd.Flags().GetString(tmcli.OutputFlag)
+
+			var (
+				diffs []gaia.ModuleStateDiff
+				err   error
+			)
+
+			heightA, errA := strconv.ParseInt(args[0], 10, 64)
+			heightB, errB := strconv.ParseInt(args[1], 10, 64)
+			if errA == nil && errB == nil {
+				diffs, err = diffHeights(cmd, heightA, heightB, limit)
+			} else {
+				diffs, err = diffGenesisFiles(args[0], args[1], limit)
+			}
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				bz, err := json.MarshalIndent(diffs, "", "  ")
+				if err != nil {
+					return err
+				}
+
+				_, err = fmt.Fprintln(cmd.OutOrStdout(), string(bz))
+				return err
+			}
+
+			return gaia.PrintStateDiff(cmd.OutOrStdout(), diffs)
+		},
+	}
+
+	cmd.Flags().String(flags.FlagHome, defaultNodeHome, "The application home directory")
+	cmd.Flags().Int(flagDiffLimit, 20, "Maximum number of differences listed per module (0 lists all of them)")
+	cmd.Flags().StringP(tmcli.OutputFlag, "o", "text", "Output format (text|json)")
+
+	return cmd
+}
+
+func diffHeights(cmd *cobra.Command, heightA, heightB int64, limit int) ([]gaia.ModuleStateDiff, error) {
+	serverCtx := server.GetServerContextFromCmd(cmd)
+	config := serverCtx.Config
+
+	homeDir, _ := cmd.Flags().GetString(flags.FlagHome)
+	config.SetRoot(homeDir)
+
+	db, err := sdk.NewLevelDB("application", filepath.Join(config.RootDir, "data"))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// the app is only used for its store keys and decoders, and is not loaded
+	gaiaApp := gaia.NewGaiaApp(
+		serverCtx.Logger, db, nil, false, map[int64]bool{}, "",
+		cast.ToUint(serverCtx.Viper.Get(server.FlagInvCheckPeriod)), gaia.MakeEncodingConfig(), serverCtx.Viper,
+	)
+
+	diffs, err := gaiaApp.DiffHeights(db, heightA, heightB, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare heights %d and %d: %w", heightA, heightB, err)
+	}
+
+	return diffs, nil
+}
+
+func diffGenesisFiles(genFileA, genFileB string, limit int) ([]gaia.ModuleStateDiff, error) {
+	appStateA, _, err := gaia.GenesisStateFromGenFile(genFileA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genesis file %s: %w", genFileA, err)
+	}
+
+	appStateB, _, err := gaia.GenesisStateFromGenFile(genFileB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genesis file %s: %w", genFileB, err)
+	}
+
+	return gaia.DiffGenesisStates(appStateA, appStateB, limit)
+}
@@ -9,17 +9,17 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	tmconfig "github.com/tendermint/tendermint/config"
 	tmos "github.com/tendermint/tendermint/libs/os"
-	tmrand "github.com/tendermint/tendermint/libs/rand"
 	"github.com/tendermint/tendermint/types"
-	tmtime "github.com/tendermint/tendermint/types/time"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/flags"
-	"github.com/cosmos/cosmos-sdk/client/tx"
 	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
 	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
@@ -31,7 +31,6 @@ import (
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/cosmos/cosmos-sdk/x/genutil"
 	genutiltypes "github.com/cosmos/cosmos-sdk/x/genutil/types"
-	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 )
 
 var (
@@ -40,6 +39,11 @@ var (
 	flagOutputDir         = "output-dir"
 	flagNodeDaemonHome    = "node-daemon-home"
 	flagStartingIPAddress = "starting-ip-address"
+	flagHostnameTemplate  = "hostname-template"
+	flagSingleHost        = "single-host"
+	flagPortOffset        = "port-offset"
+	flagSentries          = "sentries"
+	flagFullNodes         = "full-nodes"
 )
 
 // get cmd to initialize all files for tendermint testnet and application
@@ -52,8 +56,39 @@ necessary files (private validator, genesis, config, etc.).
 
 Note, strict routability for addresses is turned off in the config file.
 
+The nodes have consecutive IPv4 or IPv6 addresses from --starting-ip-address, or, with
+--hostname-template, the hostnames of the template in which {{i}} is replaced by the index
+of the node, e.g. node{{i}}.svc for node0.svc, node1.svc, ... With --single-host, all nodes
+run on the same host, 127.0.0.1 unless --starting-ip-address is given, and the ports of
+node i are the default ones plus i times --port-offset. The persistent peers of every node
+are the addresses and P2P ports of the other nodes.
+
+With --sentries, each validator gets that many sentry nodes, and with --full-nodes, that
+many full nodes, which are not validators, are added. Their node directories follow those
+of the validators, and are addressed in the same way. A validator with sentries only
+connects to its own sentries, with peer exchange disabled, and its sentries connect to it,
+keeping its node ID private, and to the other sentries. The full nodes connect to the
+sentries or, without sentries, to the validators.
+
+The accounts, validators and module genesis overrides of the testnet may be given by a
+YAML genesis spec with --config, such as the config.yml of the repository. A node is
+then created for each validator of the spec, whose key is created in the keyring of its
+node, and the keys of the other accounts without an address are created in the keyring
+of the first node. Without --config, every validator gets an account funded with 1000
+tokens of its own and 500 stake tokens, and stakes 100 of them.
+
+With --seed, the node keys, consensus keys, account mnemonics and chain ID are derived
+from the given master seed, and the genesis time is fixed, so that the same seed and
+arguments recreate the same testnet. With --no-secrets, the account keys are only kept in
+memory to sign the gentxs, and neither keyrings nor key_seed.json files are written; the
+node and consensus keys, without which the nodes cannot run, are still written.
+
 Example:
 	gaiad testnet --v 4 --output-dir ./output --starting-ip-address 192.168.10.2
+	gaiad testnet --v 4 --output-dir ./output --starting-ip-address fd00::2
+	gaiad testnet --v 4 --output-dir ./output --hostname-template node{{i}}.svc
+	gaiad testnet --v 4 --output-dir ./output --single-host --port-offset 100
+	gaiad testnet --v 2 --sentries 2 --full-nodes 1 --output-dir ./output --single-host
 	`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			clientCtx, err := client.GetClientQueryContext(cmd)
@@ -72,10 +107,41 @@ Example:
 			startingIPAddress, _ := cmd.Flags().GetString(flagStartingIPAddress)
 			numValidators, _ := cmd.Flags().GetInt(flagNumValidators)
 			algo, _ := cmd.Flags().GetString(flags.FlagKeyAlgorithm)
+			specFile, _ := cmd.Flags().GetString(flagGenesisSpec)
+			seed, _ := cmd.Flags().GetString(flagSeed)
+			noSecrets, _ := cmd.Flags().GetBool(flagNoSecrets)
+			hostnameTemplate, _ := cmd.Flags().GetString(flagHostnameTemplate)
+			singleHost, _ := cmd.Flags().GetBool(flagSingleHost)
+			portOffset, _ := cmd.Flags().GetInt(flagPortOffset)
+			sentries, _ := cmd.Flags().GetInt(flagSentries)
+			fullNodes, _ := cmd.Flags().GetInt(flagFullNodes)
+
+			if hostnameTemplate != "" && cmd.Flags().Changed(flagStartingIPAddress) {
+				return fmt.Errorf("--%s and --%s cannot be used together", flagHostnameTemplate, flagStartingIPAddress)
+			}
+			if singleHost && !cmd.Flags().Changed(flagStartingIPAddress) {
+				startingIPAddress = "127.0.0.1"
+			}
+
+			var spec GenesisSpec
+			if specFile != "" {
+				if spec, err = ReadGenesisSpec(specFile); err != nil {
+					return err
+				}
+			}
 
 			return InitTestnet(
 				clientCtx, cmd, config, mbm, genBalIterator, outputDir, chainID, minGasPrices,
-				nodeDirPrefix, nodeDaemonHome, startingIPAddress, keyringBackend, algo, numValidators,
+				nodeDirPrefix, nodeDaemonHome, keyringBackend, algo, numValidators, spec,
+				TestnetKeyOptions{Seed: seed, NoSecrets: noSecrets},
+				TestnetTopology{
+					StartingIPAddress: startingIPAddress,
+					HostnameTemplate:  hostnameTemplate,
+					SingleHost:        singleHost,
+					PortOffset:        portOffset,
+					Sentries:          sentries,
+					FullNodes:         fullNodes,
+				},
 			)
 		},
 	}
@@ -84,17 +150,78 @@ Example:
 	cmd.Flags().StringP(flagOutputDir, "o", "./mytestnet", "Directory to store initialization data for the testnet")
 	cmd.Flags().String(flagNodeDirPrefix, "node", "Prefix the directory name for each node with (node results in node0, node1, ...)")
 	cmd.Flags().String(flagNodeDaemonHome, "gaiad", "Home directory of the node's daemon configuration")
-	cmd.Flags().String(flagStartingIPAddress, "192.168.0.1", "Starting IP address (192.168.0.1 results in persistent peers list ID0@192.168.0.1:46656, ID1@192.168.0.2:46656, ...)")
+	cmd.Flags().String(flagStartingIPAddress, "192.168.0.1", "Starting IPv4 or IPv6 address (192.168.0.1 results in persistent peers list ID0@192.168.0.1:26656, ID1@192.168.0.2:26656, ...)")
+	cmd.Flags().String(flagHostnameTemplate, "", "Hostname of the nodes instead of IP addresses, in which {{i}} is replaced by the index of the node (node{{i}}.svc results in persistent peers list ID0@node0.svc:26656, ID1@node1.svc:26656, ...)")
+	cmd.Flags().Bool(flagSingleHost, false, "Run all nodes on the same host, with the ports of each node offset by --port-offset")
+	cmd.Flags().Int(flagPortOffset, 100, "Offset of the ports of each node from those of the previous node with --single-host")
+	cmd.Flags().Int(flagSentries, 0, "Number of sentry nodes of each validator")
+	cmd.Flags().Int(flagFullNodes, 0, "Number of full nodes which are not validators")
 	cmd.Flags().String(flags.FlagChainID, "", "genesis file chain-id, if left blank will be randomly created")
 	cmd.Flags().String(server.FlagMinGasPrices, fmt.Sprintf("0.000006%s", sdk.DefaultBondDenom), "Minimum gas prices to accept for transactions; All fees in a tx must meet this minimum (e.g. 0.01photino,0.001stake)")
 	cmd.Flags().String(flags.FlagKeyringBackend, flags.DefaultKeyringBackend, "Select keyring's backend (os|file|test)")
 	cmd.Flags().String(flags.FlagKeyAlgorithm, string(hd.Secp256k1Type), "Key signing algorithm to generate keys for")
+	cmd.Flags().String(flagGenesisSpec, "", "YAML genesis spec of the accounts, validators and module genesis overrides of the testnet; --v is ignored if it has validators")
+	cmd.Flags().String(flagSeed, "", "Master seed to derive the node keys, consensus keys, account mnemonics and chain ID from, instead of generating them randomly")
+	cmd.Flags().Bool(flagNoSecrets, false, "Keep the account keys in memory instead of writing keyrings and key seed files")
+
+	cmd.AddCommand(testnetStartCmd(mbm, genBalIterator))
 
 	return cmd
 }
 
 const nodeDirPerm = 0755
 
+// Default listen ports of a testnet node.
+const (
+	defaultP2PPort  = 26656
+	defaultRPCPort  = 26657
+	defaultAPIPort  = 1317
+	defaultGRPCPort = 9090
+)
+
+// testnetNode is a node of a testnet.
+type testnetNode struct {
+	Moniker string
+	Dir     string
+
+	// Host is the address of the node advertised to its peers, and ListenHost
+	// the address its P2P, RPC, API and gRPC servers listen on.
+	Host       string
+	ListenHost string
+	P2PPort    int
+	RPCPort    int
+	APIPort    int
+	GRPCPort   int
+
+	// NodeID, Address and Mnemonic are set by the initialization. Address is
+	// the address of the funded key of the node, named after its moniker.
+	NodeID   string
+	Address  sdk.AccAddress
+	Mnemonic string
+}
+
+// peerAddress returns the address of the node in the persistent peers of
+// the other nodes.
+func (n testnetNode) peerAddress() string {
+	return fmt.Sprintf("%s@%s", n.NodeID, net.JoinHostPort(n.Host, strconv.Itoa(n.P2PPort)))
+}
+
+func (n testnetNode) p2pAddress() string {
+	return "tcp://" + net.JoinHostPort(n.ListenHost, strconv.Itoa(n.P2PPort))
+}
+
+func (n testnetNode) rpcAddress() string {
+	return "tcp://" + net.JoinHostPort(n.ListenHost, strconv.Itoa(n.RPCPort))
+}
+
+func (n testnetNode) apiAddress() string {
+	return "tcp://" + net.JoinHostPort(n.ListenHost, strconv.Itoa(n.APIPort))
+}
+
+func (n testnetNode) grpcAddress() string {
+	return net.JoinHostPort(n.ListenHost, strconv.Itoa(n.GRPCPort))
+}
+
 // Initialize the testnet
 func InitTestnet(
 	clientCtx client.Context,
@@ -107,19 +234,99 @@ func InitTestnet(
 	minGasPrices,
 	nodeDirPrefix,
 	nodeDaemonHome,
-	startingIPAddress,
 	keyringBackend,
 	algoStr string,
 	numValidators int,
+	spec GenesisSpec,
+	keyOpts TestnetKeyOptions,
+	topology TestnetTopology,
 ) error {
 
-	if chainID == "" {
-		chainID = "chain-" + tmrand.NewRand().Str(6)
+	spec, err := testnetSpec(spec, nodeDirPrefix, numValidators)
+	if err != nil {
+		return err
 	}
+	chainID = testnetChainID(chainID, spec, keyOpts)
 
-	nodeIDs := make([]string, numValidators)
-	valPubKeys := make([]cryptotypes.PubKey, numValidators)
+	nodes, err := topology.nodes(outputDir, nodeDirPrefix, nodeDaemonHome, spec.ValidatorList())
+	if err != nil {
+		return err
+	}
+
+	nodeConfig.P2P.AddrBookStrict = false
+	nodeConfig.P2P.AllowDuplicateIP = topology.SingleHost
+
+	numValidators = len(spec.ValidatorList())
+	appConfig := testnetAppConfig(chainID, minGasPrices)
+	err = initTestnetFiles(
+		clientCtx, cmd, nodeConfig, appConfig, mbm, genBalIterator,
+		spec, keyOpts, nodes[:numValidators], outputDir, chainID, keyringBackend, algoStr,
+	)
+	if err != nil {
+		return err
+	}
 
+	genFile := filepath.Join(nodes[0].Dir, "config", "genesis.json")
+	if err := initFullNodeFiles(nodeConfig, appConfig, keyOpts, nodes[numValidators:], genFile); err != nil {
+		_ = os.RemoveAll(outputDir)
+		return err
+	}
+
+	writePeerConfigs(nodeConfig, topology, nodes, numValidators)
+
+	cmd.PrintErrf("Successfully initialized %d node directories\n", len(nodes))
+	return nil
+}
+
+// testnetSpec returns the genesis spec of a testnet. Unless the spec has
+// validators, numValidators validators named after their node directory are
+// added to it, each with an account funded with 1000 tokens of its own and 500
+// stake tokens, staking 100 of them.
+func testnetSpec(spec GenesisSpec, nodeDirPrefix string, numValidators int) (GenesisSpec, error) {
+	if len(spec.ValidatorList()) == 0 {
+		accounts := make([]AccountSpec, len(spec.Accounts), len(spec.Accounts)+numValidators)
+		copy(accounts, spec.Accounts)
+
+		for i := 0; i < numValidators; i++ {
+			nodeDirName := fmt.Sprintf("%s%d", nodeDirPrefix, i)
+			accounts = append(accounts, AccountSpec{
+				Name: nodeDirName,
+				Coins: []string{
+					sdk.NewCoin(fmt.Sprintf("%stoken", nodeDirName), sdk.TokensFromConsensusPower(1000)).String(),
+					sdk.NewCoin(sdk.DefaultBondDenom, sdk.TokensFromConsensusPower(500)).String(),
+				},
+			})
+			spec.Validators = append(spec.Validators, ValidatorSpec{
+				Name:       nodeDirName,
+				Staked:     sdk.NewCoin(sdk.DefaultBondDenom, sdk.TokensFromConsensusPower(100)).String(),
+				Commission: &CommissionSpec{Rate: "1", MaxRate: "1", MaxChangeRate: "1"},
+			})
+		}
+		spec.Accounts = accounts
+	}
+
+	if len(spec.ValidatorList()) == 0 {
+		return spec, fmt.Errorf("the testnet needs at least one validator")
+	}
+
+	return spec, spec.Validate()
+}
+
+// testnetChainID returns the chain ID of a testnet: the given one, else the
+// one of the spec, else one derived from the seed or a random one.
+func testnetChainID(chainID string, spec GenesisSpec, keyOpts TestnetKeyOptions) string {
+	switch {
+	case chainID != "":
+		return chainID
+	case spec.ChainID != "":
+		return spec.ChainID
+	default:
+		return keyOpts.chainID()
+	}
+}
+
+// testnetAppConfig returns the app config of the nodes of a testnet.
+func testnetAppConfig(chainID, minGasPrices string) *srvconfig.Config {
 	simappConfig := srvconfig.DefaultConfig()
 	simappConfig.MinGasPrices = minGasPrices
 	simappConfig.API.Enable = true
@@ -128,6 +335,30 @@ func InitTestnet(
 	simappConfig.Telemetry.EnableHostnameLabel = false
 	simappConfig.Telemetry.GlobalLabels = [][]string{{"chain_id", chainID}}
 
+	return simappConfig
+}
+
+// initTestnetFiles writes the files of the validator nodes of a testnet in
+// their directories, and the gentxs in the gentxs directory of outputDir.
+func initTestnetFiles(
+	clientCtx client.Context,
+	cmd *cobra.Command,
+	nodeConfig *tmconfig.Config,
+	appConfig *srvconfig.Config,
+	mbm module.BasicManager,
+	genBalIterator banktypes.GenesisBalancesIterator,
+	spec GenesisSpec,
+	keyOpts TestnetKeyOptions,
+	nodes []testnetNode,
+	outputDir,
+	chainID,
+	keyringBackend,
+	algoStr string,
+) error {
+	numValidators := len(nodes)
+	valPubKeys := make([]cryptotypes.PubKey, numValidators)
+	validators := spec.ValidatorList()
+
 	var (
 		genAccounts []authtypes.GenesisAccount
 		genBalances []banktypes.Balance
@@ -136,37 +367,31 @@ func InitTestnet(
 
 	inBuf := bufio.NewReader(cmd.InOrStdin())
 	// generate private keys, node IDs, and initial transactions
-	for i := 0; i < numValidators; i++ {
-		nodeDirName := fmt.Sprintf("%s%d", nodeDirPrefix, i)
-		nodeDir := filepath.Join(outputDir, nodeDirName, nodeDaemonHome)
+	for i := range nodes {
+		moniker := nodes[i].Moniker
+		nodeDir := nodes[i].Dir
 		gentxsDir := filepath.Join(outputDir, "gentxs")
 
 		nodeConfig.SetRoot(nodeDir)
-		nodeConfig.RPC.ListenAddress = "tcp://0.0.0.0:26657"
 
 		if err := os.MkdirAll(filepath.Join(nodeDir, "config"), nodeDirPerm); err != nil {
 			_ = os.RemoveAll(outputDir)
 			return err
 		}
 
-		nodeConfig.Moniker = nodeDirName
+		nodeConfig.Moniker = moniker
 
-		ip, err := getIP(i, startingIPAddress)
+		var err error
+		nodes[i].NodeID, valPubKeys[i], err = keyOpts.initNodeValidatorFiles(nodeConfig)
 		if err != nil {
 			_ = os.RemoveAll(outputDir)
 			return err
 		}
 
-		nodeIDs[i], valPubKeys[i], err = genutil.InitializeNodeValidatorFiles(nodeConfig)
-		if err != nil {
-			_ = os.RemoveAll(outputDir)
-			return err
-		}
-
-		memo := fmt.Sprintf("%s@%s:26656", nodeIDs[i], ip)
+		memo := nodes[i].peerAddress()
 		genFiles = append(genFiles, nodeConfig.GenesisFile())
 
-		kb, err := keyring.New(sdk.KeyringServiceName(), keyringBackend, nodeDir, inBuf)
+		kb, err := keyOpts.keyring(keyringBackend, nodeDir, inBuf)
 		if err != nil {
 			return err
 		}
@@ -177,100 +402,111 @@ func InitTestnet(
 			return err
 		}
 
-		addr, secret, err := server.GenerateSaveCoinKey(kb, nodeDirName, true, algo)
+		acc, _ := spec.Account(validators[i].Name)
+		if acc, err = keyOpts.account(acc); err != nil {
+			return err
+		}
+
+		addr, secret, err := acc.address(kb, algo)
 		if err != nil {
 			_ = os.RemoveAll(outputDir)
 			return err
 		}
+		if secret == "" {
+			secret = acc.Mnemonic
+		}
+		nodes[i].Address, nodes[i].Mnemonic = addr, secret
 
-		info := map[string]string{"secret": secret}
-
-		cliPrint, err := json.Marshal(info)
-		if err != nil {
+		if err := keyOpts.writeKeySeed(fmt.Sprintf("%v.json", "key_seed"), nodeDir, secret); err != nil {
 			return err
 		}
 
-		// save private key seed words
-		if err := writeFile(fmt.Sprintf("%v.json", "key_seed"), nodeDir, cliPrint); err != nil {
+		genAccount, balance, err := acc.genesisAccount(addr)
+		if err != nil {
 			return err
 		}
 
-		accTokens := sdk.TokensFromConsensusPower(1000)
-		accStakingTokens := sdk.TokensFromConsensusPower(500)
-		coins := sdk.Coins{
-			sdk.NewCoin(fmt.Sprintf("%stoken", nodeDirName), accTokens),
-			sdk.NewCoin(sdk.DefaultBondDenom, accStakingTokens),
+		genBalances = append(genBalances, balance)
+		genAccounts = append(genAccounts, genAccount)
+
+		createValMsg, err := validators[i].createValidatorMsg(addr, valPubKeys[i], nodes[i].Moniker)
+		if err != nil {
+			return err
 		}
 
-		genBalances = append(genBalances, banktypes.Balance{Address: addr.String(), Coins: coins.Sort()})
-		genAccounts = append(genAccounts, authtypes.NewBaseAccount(addr, nil, 0, 0))
-
-		valTokens := sdk.TokensFromConsensusPower(100)
-		createValMsg, err := stakingtypes.NewMsgCreateValidator(
-			sdk.ValAddress(addr),
-			valPubKeys[i],
-			sdk.NewCoin(sdk.DefaultBondDenom, valTokens),
-			stakingtypes.NewDescription(nodeDirName, "", "", "", ""),
-			stakingtypes.NewCommissionRates(sdk.OneDec(), sdk.OneDec(), sdk.OneDec()),
-			sdk.OneInt(),
-		)
+		txBz, err := signGentx(clientCtx, kb, chainID, acc.Name, memo, createValMsg)
 		if err != nil {
 			return err
 		}
 
-		txBuilder := clientCtx.TxConfig.NewTxBuilder()
-		if err := txBuilder.SetMsgs(createValMsg); err != nil {
+		if err := writeFile(fmt.Sprintf("%v.json", moniker), gentxsDir, txBz); err != nil {
 			return err
 		}
 
-		txBuilder.SetMemo(memo)
+		appConfig.API.Address = nodes[i].apiAddress()
+		appConfig.GRPC.Address = nodes[i].grpcAddress()
+		srvconfig.WriteConfigFile(filepath.Join(nodeDir, "config/app.toml"), appConfig)
+	}
+
+	// the keys of the other accounts are created in the keyring of the first node
+	kb, err := keyOpts.keyring(keyringBackend, nodes[0].Dir, inBuf)
+	if err != nil {
+		return err
+	}
+
+	keyringAlgos, _ := kb.SupportedAlgorithms()
+	algo, err := keyring.NewSigningAlgoFromString(algoStr, keyringAlgos)
+	if err != nil {
+		return err
+	}
 
-		txFactory := tx.Factory{}
-		txFactory = txFactory.
-			WithChainID(chainID).
-			WithMemo(memo).
-			WithKeybase(kb).
-			WithTxConfig(clientCtx.TxConfig)
+	for _, acc := range spec.Accounts {
+		if spec.isValidator(acc) {
+			continue
+		}
 
-		if err := tx.Sign(txFactory, nodeDirName, txBuilder, true); err != nil {
+		if acc, err = keyOpts.account(acc); err != nil {
 			return err
 		}
 
-		txBz, err := clientCtx.TxConfig.TxJSONEncoder()(txBuilder.GetTx())
+		addr, secret, err := acc.address(kb, algo)
 		if err != nil {
 			return err
 		}
+		if secret == "" {
+			secret = acc.Mnemonic
+		}
 
-		if err := writeFile(fmt.Sprintf("%v.json", nodeDirName), gentxsDir, txBz); err != nil {
+		if err := keyOpts.writeKeySeed(fmt.Sprintf("key_seed_%s.json", acc.Name), nodes[0].Dir, secret); err != nil {
 			return err
 		}
 
-		srvconfig.WriteConfigFile(filepath.Join(nodeDir, "config/app.toml"), simappConfig)
-	}
+		genAccount, balance, err := acc.genesisAccount(addr)
+		if err != nil {
+			return err
+		}
 
-	if err := initGenFiles(clientCtx, mbm, chainID, genAccounts, genBalances, genFiles, numValidators); err != nil {
-		return err
+		genBalances = append(genBalances, balance)
+		genAccounts = append(genAccounts, genAccount)
 	}
 
-	err := collectGenFiles(
-		clientCtx, nodeConfig, chainID, nodeIDs, valPubKeys, numValidators,
-		outputDir, nodeDirPrefix, nodeDaemonHome, genBalIterator,
-	)
-	if err != nil {
+	if err := initGenFiles(clientCtx, mbm, chainID, genAccounts, genBalances, genFiles, numValidators, spec.Genesis); err != nil {
 		return err
 	}
 
-	cmd.PrintErrf("Successfully initialized %d node directories\n", numValidators)
-	return nil
+	return collectGenFiles(clientCtx, nodeConfig, chainID, keyOpts.genesisTime(), nodes, valPubKeys, outputDir, genBalIterator)
 }
 
 func initGenFiles(
 	clientCtx client.Context, mbm module.BasicManager, chainID string,
 	genAccounts []authtypes.GenesisAccount, genBalances []banktypes.Balance,
-	genFiles []string, numValidators int,
+	genFiles []string, numValidators int, overrides map[string]interface{},
 ) error {
 
 	appGenState := mbm.DefaultGenesis(clientCtx.JSONMarshaler)
+	if err := applyGenesisOverrides(mbm, clientCtx.JSONMarshaler, clientCtx.TxConfig, appGenState, overrides); err != nil {
+		return err
+	}
 
 	// set the accounts in the genesis state
 	var authGenState authtypes.GenesisState
@@ -312,23 +548,18 @@ func initGenFiles(
 }
 
 func collectGenFiles(
-	clientCtx client.Context, nodeConfig *tmconfig.Config, chainID string,
-	nodeIDs []string, valPubKeys []cryptotypes.PubKey, numValidators int,
-	outputDir, nodeDirPrefix, nodeDaemonHome string, genBalIterator banktypes.GenesisBalancesIterator,
+	clientCtx client.Context, nodeConfig *tmconfig.Config, chainID string, genTime time.Time,
+	nodes []testnetNode, valPubKeys []cryptotypes.PubKey,
+	outputDir string, genBalIterator banktypes.GenesisBalancesIterator,
 ) error {
 
 	var appState json.RawMessage
-	genTime := tmtime.Now()
 
-	for i := 0; i < numValidators; i++ {
-		nodeDirName := fmt.Sprintf("%s%d", nodeDirPrefix, i)
-		nodeDir := filepath.Join(outputDir, nodeDirName, nodeDaemonHome)
+	for i, node := range nodes {
 		gentxsDir := filepath.Join(outputDir, "gentxs")
-		nodeConfig.Moniker = nodeDirName
+		setNodeConfig(nodeConfig, node)
 
-		nodeConfig.SetRoot(nodeDir)
-
-		nodeID, valPubKey := nodeIDs[i], valPubKeys[i]
+		nodeID, valPubKey := node.NodeID, valPubKeys[i]
 		initCfg := genutiltypes.NewInitConfig(chainID, gentxsDir, nodeID, valPubKey)
 
 		genDoc, err := types.GenesisDocFromFile(nodeConfig.GenesisFile())
@@ -357,6 +588,61 @@ func collectGenFiles(
 	return nil
 }
 
+// initFullNodeFiles writes the node and consensus keys, app config and
+// genesis file of the nodes of a testnet which are not validators.
+func initFullNodeFiles(
+	nodeConfig *tmconfig.Config, appConfig *srvconfig.Config, keyOpts TestnetKeyOptions,
+	nodes []testnetNode, genFile string,
+) error {
+	for i := range nodes {
+		setNodeConfig(nodeConfig, nodes[i])
+
+		if err := os.MkdirAll(filepath.Join(nodes[i].Dir, "config"), nodeDirPerm); err != nil {
+			return err
+		}
+
+		var err error
+		if nodes[i].NodeID, _, err = keyOpts.initNodeValidatorFiles(nodeConfig); err != nil {
+			return err
+		}
+
+		if err := tmos.CopyFile(genFile, nodeConfig.GenesisFile()); err != nil {
+			return err
+		}
+
+		appConfig.API.Address = nodes[i].apiAddress()
+		appConfig.GRPC.Address = nodes[i].grpcAddress()
+		srvconfig.WriteConfigFile(filepath.Join(nodes[i].Dir, "config/app.toml"), appConfig)
+	}
+
+	return nil
+}
+
+// writePeerConfigs writes the config.toml of the nodes of a testnet, whose
+// first numValidators nodes are the validators, with their P2P config.
+func writePeerConfigs(nodeConfig *tmconfig.Config, topology TestnetTopology, nodes []testnetNode, numValidators int) {
+	for i, node := range nodes {
+		setNodeConfig(nodeConfig, node)
+
+		peers := topology.peers(nodes, numValidators, i)
+		nodeConfig.P2P.PexReactor = peers.Pex
+		nodeConfig.P2P.PersistentPeers = strings.Join(peers.PersistentPeers, ",")
+		nodeConfig.P2P.PrivatePeerIDs = strings.Join(peers.PrivatePeerIDs, ",")
+		nodeConfig.P2P.UnconditionalPeerIDs = strings.Join(peers.UnconditionalPeerIDs, ",")
+
+		tmconfig.WriteConfigFile(filepath.Join(node.Dir, "config", "config.toml"), nodeConfig)
+	}
+}
+
+// setNodeConfig sets the root directory, moniker and listen addresses of the
+// node in the config.
+func setNodeConfig(nodeConfig *tmconfig.Config, node testnetNode) {
+	nodeConfig.SetRoot(node.Dir)
+	nodeConfig.Moniker = node.Moniker
+	nodeConfig.P2P.ListenAddress = node.p2pAddress()
+	nodeConfig.RPC.ListenAddress = node.rpcAddress()
+}
+
 func getIP(i int, startingIPAddr string) (ip string, err error) {
 	if len(startingIPAddr) == 0 {
 		ip, err = server.ExternalIP()
@@ -368,17 +654,31 @@ func getIP(i int, startingIPAddr string) (ip string, err error) {
 	return calculateIP(startingIPAddr, i)
 }
 
+// calculateIP returns the IPv4 or IPv6 address i addresses after ip, carrying
+// over to the previous bytes of the address.
 func calculateIP(ip string, i int) (string, error) {
-	ipv4 := net.ParseIP(ip).To4()
-	if ipv4 == nil {
-		return "", fmt.Errorf("%v: non ipv4 address", ip)
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", fmt.Errorf("%v: invalid IP address", ip)
+	}
+	if ipv4 := addr.To4(); ipv4 != nil {
+		addr = ipv4
 	}
 
-	for j := 0; j < i; j++ {
-		ipv4[3]++
+	next := make(net.IP, len(addr))
+	copy(next, addr)
+
+	carry := i
+	for j := len(next) - 1; j >= 0 && carry > 0; j-- {
+		sum := int(next[j]) + carry
+		next[j] = byte(sum % 256)
+		carry = sum / 256
+	}
+	if carry > 0 {
+		return "", fmt.Errorf("%v: no room for %d more addresses", ip, i)
 	}
 
-	return ipv4.String(), nil
+	return next.String(), nil
 }
 
 func writeFile(name string, dir string, contents []byte) error {
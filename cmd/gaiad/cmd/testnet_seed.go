@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/cosmos/go-bip39"
+	tmconfig "github.com/tendermint/tendermint/config"
+	tmed25519 "github.com/tendermint/tendermint/crypto/ed25519"
+	tmos "github.com/tendermint/tendermint/libs/os"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	"github.com/tendermint/tendermint/p2p"
+	"github.com/tendermint/tendermint/privval"
+	tmtime "github.com/tendermint/tendermint/types/time"
+
+	cryptocodec "github.com/cosmos/cosmos-sdk/crypto/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/genutil"
+)
+
+const (
+	flagSeed      = "seed"
+	flagNoSecrets = "no-secrets"
+)
+
+// seedGenesisTime is the genesis time of the testnets derived from a seed,
+// which must not depend on the time they are created at.
+var seedGenesisTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// TestnetKeyOptions control how the keys of a testnet are created and stored.
+type TestnetKeyOptions struct {
+	// Seed, if not empty, is the master seed the node keys, consensus keys,
+	// account mnemonics and chain ID of the testnet are derived from, so that
+	// the same seed and arguments recreate the same testnet. The genesis time
+	// is then seedGenesisTime.
+	Seed string
+
+	// NoSecrets keeps the account keys in memory, only to sign the gentxs,
+	// instead of writing them to keyrings and key_seed.json files. The node
+	// and consensus keys, without which the nodes cannot run, are still
+	// written.
+	NoSecrets bool
+}
+
+// derive returns 32 bytes derived from the seed for the purpose.
+func (o TestnetKeyOptions) derive(purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(o.Seed))
+	_, _ = mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// chainID returns the chain ID derived from the seed, or a random one.
+func (o TestnetKeyOptions) chainID() string {
+	if o.Seed == "" {
+		return "chain-" + tmrand.NewRand().Str(6)
+	}
+
+	return "chain-" + hex.EncodeToString(o.derive("chain_id"))[:6]
+}
+
+// genesisTime returns the genesis time of the testnet.
+func (o TestnetKeyOptions) genesisTime() time.Time {
+	if o.Seed == "" {
+		return tmtime.Now()
+	}
+
+	return seedGenesisTime
+}
+
+// account returns the account with the mnemonic of its key derived from the
+// seed, if it is a key without a mnemonic.
+func (o TestnetKeyOptions) account(acc AccountSpec) (AccountSpec, error) {
+	if o.Seed == "" || acc.Address != "" || acc.Mnemonic != "" {
+		return acc, nil
+	}
+
+	mnemonic, err := bip39.NewMnemonic(o.derive("account/" + acc.Name))
+	if err != nil {
+		return acc, err
+	}
+	acc.Mnemonic = mnemonic
+
+	return acc, nil
+}
+
+// keyring returns the keyring of the directory, or an in-memory keyring if no
+// secrets are written.
+func (o TestnetKeyOptions) keyring(backend, dir string, inBuf io.Reader) (keyring.Keyring, error) {
+	if o.NoSecrets {
+		return keyring.NewInMemory(), nil
+	}
+
+	return keyring.New(sdk.KeyringServiceName(), backend, dir, inBuf)
+}
+
+// writeKeySeed writes the mnemonic of a key to the file of the directory,
+// unless no secrets are written.
+func (o TestnetKeyOptions) writeKeySeed(name, dir, mnemonic string) error {
+	if o.NoSecrets || mnemonic == "" {
+		return nil
+	}
+
+	cliPrint, err := json.Marshal(map[string]string{"secret": mnemonic})
+	if err != nil {
+		return err
+	}
+
+	// save private key seed words
+	return writeFile(name, dir, cliPrint)
+}
+
+// initNodeValidatorFiles creates the node key and consensus key files of the
+// node, derived from the seed if any.
+func (o TestnetKeyOptions) initNodeValidatorFiles(config *tmconfig.Config) (string, cryptotypes.PubKey, error) {
+	if o.Seed == "" {
+		return genutil.InitializeNodeValidatorFiles(config)
+	}
+
+	for _, file := range []string{config.NodeKeyFile(), config.PrivValidatorKeyFile(), config.PrivValidatorStateFile()} {
+		if err := tmos.EnsureDir(filepath.Dir(file), 0777); err != nil {
+			return "", nil, err
+		}
+	}
+
+	nodeKey := &p2p.NodeKey{PrivKey: tmed25519.GenPrivKeyFromSecret(o.derive("node_key/" + config.Moniker))}
+	if err := nodeKey.SaveAs(config.NodeKeyFile()); err != nil {
+		return "", nil, err
+	}
+
+	filePV := privval.NewFilePV(
+		tmed25519.GenPrivKeyFromSecret(o.derive("consensus_key/"+config.Moniker)),
+		config.PrivValidatorKeyFile(), config.PrivValidatorStateFile(),
+	)
+	filePV.Save()
+
+	tmValPubKey, err := filePV.GetPubKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	valPubKey, err := cryptocodec.FromTmPubKeyInterface(tmValPubKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid consensus key: %w", err)
+	}
+
+	return string(nodeKey.ID()), valPubKey, nil
+}
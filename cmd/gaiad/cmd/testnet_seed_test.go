@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cosmos/go-bip39"
+	"github.com/stretchr/testify/require"
+	tmconfig "github.com/tendermint/tendermint/config"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+)
+
+func TestTestnetKeyOptionsSeed(t *testing.T) {
+	opts := TestnetKeyOptions{Seed: "seed"}
+	require.Equal(t, opts.chainID(), TestnetKeyOptions{Seed: "seed"}.chainID())
+	require.NotEqual(t, opts.chainID(), TestnetKeyOptions{Seed: "other"}.chainID())
+	require.Equal(t, seedGenesisTime, opts.genesisTime())
+
+	alice, err := opts.account(AccountSpec{Name: "alice"})
+	require.NoError(t, err)
+	require.True(t, bip39.IsMnemonicValid(alice.Mnemonic))
+
+	bob, err := opts.account(AccountSpec{Name: "bob"})
+	require.NoError(t, err)
+	require.NotEqual(t, alice.Mnemonic, bob.Mnemonic)
+
+	// given mnemonics and addresses are kept, and nothing is derived without a seed
+	acc, err := opts.account(AccountSpec{Name: "alice", Mnemonic: bob.Mnemonic})
+	require.NoError(t, err)
+	require.Equal(t, bob.Mnemonic, acc.Mnemonic)
+
+	acc, err = TestnetKeyOptions{}.account(AccountSpec{Name: "alice"})
+	require.NoError(t, err)
+	require.Empty(t, acc.Mnemonic)
+}
+
+func TestTestnetKeyOptionsInitNodeValidatorFiles(t *testing.T) {
+	initFiles := func(opts TestnetKeyOptions, moniker string) (string, string) {
+		config := tmconfig.DefaultConfig()
+		config.SetRoot(t.TempDir())
+		config.Moniker = moniker
+		require.NoError(t, os.MkdirAll(filepath.Join(config.RootDir, "config"), nodeDirPerm))
+
+		nodeID, pubKey, err := opts.initNodeValidatorFiles(config)
+		require.NoError(t, err)
+		require.FileExists(t, config.NodeKeyFile())
+		require.FileExists(t, config.PrivValidatorKeyFile())
+		require.FileExists(t, config.PrivValidatorStateFile())
+
+		return nodeID, pubKey.String()
+	}
+
+	opts := TestnetKeyOptions{Seed: "seed"}
+	nodeID, pubKey := initFiles(opts, "node0")
+	otherNodeID, otherPubKey := initFiles(opts, "node0")
+	require.Equal(t, nodeID, otherNodeID)
+	require.Equal(t, pubKey, otherPubKey)
+
+	otherNodeID, otherPubKey = initFiles(opts, "node1")
+	require.NotEqual(t, nodeID, otherNodeID)
+	require.NotEqual(t, pubKey, otherPubKey)
+
+	otherNodeID, otherPubKey = initFiles(TestnetKeyOptions{}, "node0")
+	require.NotEqual(t, nodeID, otherNodeID)
+	require.NotEqual(t, pubKey, otherPubKey)
+}
+
+func TestTestnetKeyOptionsNoSecrets(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, TestnetKeyOptions{NoSecrets: true}.writeKeySeed("key_seed.json", dir, "mnemonic"))
+	require.NoFileExists(t, filepath.Join(dir, "key_seed.json"))
+
+	kb, err := TestnetKeyOptions{NoSecrets: true}.keyring("file", dir, nil)
+	require.NoError(t, err)
+	_, _, err = AccountSpec{Name: "alice"}.address(kb, hd.Secp256k1)
+	require.NoError(t, err)
+
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, TestnetKeyOptions{}.writeKeySeed("key_seed.json", dir, "mnemonic"))
+	require.FileExists(t, filepath.Join(dir, "key_seed.json"))
+}
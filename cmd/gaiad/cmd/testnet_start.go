@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+
+	tmconfig "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/node"
+	"github.com/tendermint/tendermint/p2p"
+	pvm "github.com/tendermint/tendermint/privval"
+	"github.com/tendermint/tendermint/proxy"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	"github.com/tendermint/tendermint/rpc/client/local"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/server"
+	"github.com/cosmos/cosmos-sdk/server/api"
+	srvconfig "github.com/cosmos/cosmos-sdk/server/config"
+	servergrpc "github.com/cosmos/cosmos-sdk/server/grpc"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	"github.com/cosmos/cosmos-sdk/version"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+const flagEnableLogging = "enable-logging"
+
+const (
+	// testnetStartHeight is the height the in-process testnet must reach
+	// before its endpoints are printed.
+	testnetStartHeight = 2
+	// testnetStartTimeout is how long to wait for the testnet to reach
+	// testnetStartHeight.
+	testnetStartTimeout = time.Minute
+	// testnetPollInterval is how often the height of the testnet is polled.
+	testnetPollInterval = time.Second
+)
+
+// errTestnetInterrupted is returned when the process is interrupted while
+// waiting for the testnet.
+var errTestnetInterrupted = errors.New("testnet interrupted")
+
+// testnetStartCmd returns the testnet start cobra Command, which runs a testnet
+// of several validators in-process.
+func testnetStartCmd(mbm module.BasicManager, genBalIterator banktypes.GenesisBalancesIterator) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Launch an in-process testnet of several validators",
+		Long: `start initializes "v" validator nodes in the output directory, as the testnet command
+does, and runs all of them in this process, listening on the loopback interface on
+automatically assigned P2P, RPC, API and gRPC ports.
+
+The validators may be given by a YAML genesis spec with --config, and the keys derived
+from a seed with --seed or kept in memory with --no-secrets, as with the testnet
+command. Once the first blocks are committed, the endpoints of every node and their
+funded keys are printed. The keys are stored with the test keyring backend in the node
+directories, unless --no-secrets is given.
+
+The testnet runs until the process is interrupted.
+`,
+		Example: fmt.Sprintf("$ %s testnet start --v 4 --output-dir ./.testnets", version.AppName),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+			serverCtx := server.GetServerContextFromCmd(cmd)
+			config := serverCtx.Config
+
+			outputDir, _ := cmd.Flags().GetString(flagOutputDir)
+			chainID, _ := cmd.Flags().GetString(flags.FlagChainID)
+			minGasPrices, _ := cmd.Flags().GetString(server.FlagMinGasPrices)
+			numValidators, _ := cmd.Flags().GetInt(flagNumValidators)
+			algo, _ := cmd.Flags().GetString(flags.FlagKeyAlgorithm)
+			enableLogging, _ := cmd.Flags().GetBool(flagEnableLogging)
+			specFile, _ := cmd.Flags().GetString(flagGenesisSpec)
+			seed, _ := cmd.Flags().GetString(flagSeed)
+			noSecrets, _ := cmd.Flags().GetBool(flagNoSecrets)
+			keyOpts := TestnetKeyOptions{Seed: seed, NoSecrets: noSecrets}
+
+			if _, err := os.Stat(outputDir); err == nil {
+				return fmt.Errorf("output directory %s already exists; remove it or choose another one", outputDir)
+			}
+
+			var spec GenesisSpec
+			if specFile != "" {
+				if spec, err = ReadGenesisSpec(specFile); err != nil {
+					return err
+				}
+			}
+
+			spec, err = testnetSpec(spec, "node", numValidators)
+			if err != nil {
+				return err
+			}
+			chainID = testnetChainID(chainID, spec, keyOpts)
+
+			nodes, err := loopbackNodes(outputDir, spec.ValidatorList())
+			if err != nil {
+				return err
+			}
+
+			// all nodes share the loopback address
+			config.P2P.AllowDuplicateIP = true
+			config.P2P.AddrBookStrict = false
+
+			// the telemetry metrics are global to the process
+			appConfig := testnetAppConfig(chainID, minGasPrices)
+			appConfig.Telemetry.Enabled = false
+
+			err = initTestnetFiles(
+				clientCtx, cmd, config, appConfig, mbm, genBalIterator,
+				spec, keyOpts, nodes, outputDir, chainID, keyring.BackendTest, algo,
+			)
+			if err != nil {
+				return err
+			}
+
+			logger := log.NewNopLogger()
+			if enableLogging {
+				logger = serverCtx.Logger
+			}
+
+			// the signals are caught from now on, so that the nodes are
+			// stopped if the process is interrupted while they start
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			defer signal.Stop(quit)
+
+			errCh := make(chan error, len(nodes))
+			processes := make([]*testnetProcess, 0, len(nodes))
+			defer func() {
+				for _, p := range processes {
+					p.stop()
+				}
+			}()
+
+			for _, n := range nodes {
+				p, err := startTestnetNode(clientCtx.WithChainID(chainID), logger.With("module", n.Moniker), n, errCh)
+				if err != nil {
+					return fmt.Errorf("failed to start %s: %w", n.Moniker, err)
+				}
+				processes = append(processes, p)
+			}
+
+			err = waitForTestnetHeight(processes[0].client, testnetStartHeight, testnetStartTimeout, errCh, quit)
+			if errors.Is(err, errTestnetInterrupted) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			printTestnet(cmd.OutOrStdout(), chainID, nodes, !noSecrets)
+
+			select {
+			case err := <-errCh:
+				return err
+			case <-quit:
+				return nil
+			}
+		},
+	}
+
+	cmd.Flags().Int(flagNumValidators, 4, "Number of validators of the testnet")
+	cmd.Flags().StringP(flagOutputDir, "o", "./.testnets", "Directory to store the data of the testnet, which must not exist")
+	cmd.Flags().String(flags.FlagChainID, "", "genesis file chain-id, if left blank will be randomly created")
+	cmd.Flags().String(server.FlagMinGasPrices, fmt.Sprintf("0.000006%s", sdk.DefaultBondDenom), "Minimum gas prices to accept for transactions; All fees in a tx must meet this minimum (e.g. 0.01photino,0.001stake)")
+	cmd.Flags().String(flags.FlagKeyAlgorithm, string(hd.Secp256k1Type), "Key signing algorithm to generate keys for")
+	cmd.Flags().Bool(flagEnableLogging, false, "Write the logs of the nodes to STDOUT")
+	cmd.Flags().String(flagGenesisSpec, "", "YAML genesis spec of the accounts, validators and module genesis overrides of the testnet; --v is ignored if it has validators")
+	cmd.Flags().String(flagSeed, "", "Master seed to derive the node keys, consensus keys, account mnemonics and chain ID from, instead of generating them randomly")
+	cmd.Flags().Bool(flagNoSecrets, false, "Keep the account keys in memory instead of writing keyrings and key seed files")
+
+	return cmd
+}
+
+// loopbackNodes returns the nodes of the validators of an in-process testnet,
+// which listen on free ports of the loopback interface.
+func loopbackNodes(outputDir string, validators []ValidatorSpec) ([]testnetNode, error) {
+	nodes := make([]testnetNode, len(validators))
+	for i := range nodes {
+		ports := make([]int, 4)
+		for j := range ports {
+			_, port, err := server.FreeTCPAddr()
+			if err != nil {
+				return nil, err
+			}
+
+			if ports[j], err = strconv.Atoi(port); err != nil {
+				return nil, err
+			}
+		}
+
+		nodes[i] = testnetNode{
+			Moniker:    validators[i].Name,
+			Dir:        filepath.Join(outputDir, fmt.Sprintf("node%d", i), "gaiad"),
+			Host:       "127.0.0.1",
+			ListenHost: "127.0.0.1",
+			P2PPort:    ports[0],
+			RPCPort:    ports[1],
+			APIPort:    ports[2],
+			GRPCPort:   ports[3],
+		}
+	}
+
+	return nodes, nil
+}
+
+// testnetProcess is a node of a testnet running in-process.
+type testnetProcess struct {
+	db     dbm.DB
+	tmNode *node.Node
+	client *local.Local
+	api    *api.Server
+	grpc   *grpc.Server
+}
+
+// startTestnetNode starts a node from its config files, as the start command
+// would. Errors of the API server, which runs in the background, are sent to
+// errCh.
+func startTestnetNode(clientCtx client.Context, logger log.Logger, n testnetNode, errCh chan<- error) (*testnetProcess, error) {
+	tmCfg := tmconfig.DefaultConfig()
+	appOpts := viper.New()
+	for _, file := range []string{"config.toml", "app.toml"} {
+		appOpts.SetConfigFile(filepath.Join(n.Dir, "config", file))
+		if err := appOpts.MergeInConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if err := appOpts.Unmarshal(tmCfg); err != nil {
+		return nil, err
+	}
+	tmCfg.SetRoot(n.Dir)
+	appOpts.Set(flags.FlagHome, n.Dir)
+
+	appConfig := srvconfig.GetConfig(appOpts)
+
+	db, err := sdk.NewLevelDB("application", filepath.Join(n.Dir, "data"))
+	if err != nil {
+		return nil, err
+	}
+	app := newApp(logger, db, nil, appOpts)
+
+	nodeKey, err := p2p.LoadOrGenNodeKey(tmCfg.NodeKeyFile())
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	tmNode, err := node.NewNode(
+		tmCfg,
+		pvm.LoadOrGenFilePV(tmCfg.PrivValidatorKeyFile(), tmCfg.PrivValidatorStateFile()),
+		nodeKey,
+		proxy.NewLocalClientCreator(app),
+		node.DefaultGenesisDocProviderFunc(tmCfg),
+		node.DefaultDBProvider,
+		node.DefaultMetricsProvider(tmCfg.Instrumentation),
+		logger,
+	)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	p := &testnetProcess{db: db, tmNode: tmNode, client: local.New(tmNode)}
+	if err := tmNode.Start(); err != nil {
+		p.stop()
+		return nil, err
+	}
+
+	clientCtx = clientCtx.WithHomeDir(n.Dir).WithClient(p.client)
+	app.RegisterTxService(clientCtx)
+	app.RegisterTendermintService(clientCtx)
+
+	p.api = api.New(clientCtx, logger.With("module", "api-server"))
+	app.RegisterAPIRoutes(p.api, appConfig.API)
+	go func() {
+		if err := p.api.Start(appConfig); err != nil {
+			errCh <- fmt.Errorf("API server of %s: %w", n.Moniker, err)
+		}
+	}()
+
+	p.grpc, err = servergrpc.StartGRPCServer(clientCtx, app, appConfig.GRPC.Address)
+	if err != nil {
+		p.stop()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *testnetProcess) stop() {
+	if p.tmNode.IsRunning() {
+		_ = p.tmNode.Stop()
+		p.tmNode.Wait()
+	}
+
+	if p.api != nil {
+		_ = p.api.Close()
+	}
+
+	if p.grpc != nil {
+		p.grpc.Stop()
+	}
+
+	// the app database is closed once nothing commits to it anymore
+	_ = p.db.Close()
+}
+
+// waitForTestnetHeight waits for a node to commit the height, failing on the
+// first error sent to errCh, or with errTestnetInterrupted on a signal of
+// quit.
+func waitForTestnetHeight(c rpcclient.StatusClient, height int64, timeout time.Duration, errCh <-chan error, quit <-chan os.Signal) error {
+	ticker := time.NewTicker(testnetPollInterval)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+
+	for {
+		select {
+		case err := <-errCh:
+			return err
+		case <-quit:
+			return errTestnetInterrupted
+		case <-deadline:
+			return fmt.Errorf("timed out waiting for the testnet to reach height %d", height)
+		case <-ticker.C:
+			status, err := c.Status(context.Background())
+			if err == nil && status.SyncInfo.LatestBlockHeight >= height {
+				return nil
+			}
+		}
+	}
+}
+
+func printTestnet(w io.Writer, chainID string, nodes []testnetNode, keyring bool) {
+	fmt.Fprintf(w, "Testnet %s is running with %d validators; press Ctrl+C to stop it.\n", chainID, len(nodes))
+	for _, n := range nodes {
+		fmt.Fprintf(w, "\n%s (%s)\n", n.Moniker, n.Dir)
+		fmt.Fprintf(w, "  P2P:      %s\n", n.peerAddress())
+		fmt.Fprintf(w, "  RPC:      tcp://%s:%d\n", n.Host, n.RPCPort)
+		fmt.Fprintf(w, "  API:      http://%s:%d\n", n.Host, n.APIPort)
+		fmt.Fprintf(w, "  gRPC:     %s:%d\n", n.Host, n.GRPCPort)
+		fmt.Fprintf(w, "  Key:      %s %s\n", n.Moniker, n.Address)
+		if n.Mnemonic != "" {
+			fmt.Fprintf(w, "  Mnemonic: %s\n", n.Mnemonic)
+		}
+	}
+
+	if keyring {
+		fmt.Fprintf(w, "\nThe keys use the test keyring backend, e.g. %s keys list --keyring-backend test --home %s\n", version.AppName, nodes[0].Dir)
+	}
+}
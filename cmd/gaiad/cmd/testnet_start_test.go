@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// mockStatusClient reports the height, or fails with err.
+type mockStatusClient struct {
+	height int64
+	err    error
+}
+
+func (c mockStatusClient) Status(context.Context) (*ctypes.ResultStatus, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{LatestBlockHeight: c.height}}, nil
+}
+
+func TestLoopbackNodes(t *testing.T) {
+	validators := []ValidatorSpec{{Name: "alice"}, {Name: "bob"}}
+
+	nodes, err := loopbackNodes("out", validators)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+
+	ports := make(map[int]bool)
+	for i, n := range nodes {
+		require.Equal(t, validators[i].Name, n.Moniker)
+		require.Equal(t, filepath.Join("out", []string{"node0", "node1"}[i], "gaiad"), n.Dir)
+		require.Equal(t, "127.0.0.1", n.Host)
+		require.Equal(t, "127.0.0.1", n.ListenHost)
+
+		for _, port := range []int{n.P2PPort, n.RPCPort, n.APIPort, n.GRPCPort} {
+			require.Positive(t, port)
+			ports[port] = true
+		}
+	}
+
+	// every endpoint of every node has its own port
+	require.Len(t, ports, 8)
+}
+
+func TestWaitForTestnetHeight(t *testing.T) {
+	// the height is reached
+	err := waitForTestnetHeight(mockStatusClient{height: 2}, 2, 10*time.Second, nil, nil)
+	require.NoError(t, err)
+
+	// a node fails before the height is reached
+	errCh := make(chan error, 1)
+	errCh <- errors.New("API server of alice: address in use")
+	err = waitForTestnetHeight(mockStatusClient{height: 1}, 2, 10*time.Second, errCh, nil)
+	require.EqualError(t, err, "API server of alice: address in use")
+
+	// or the process is interrupted
+	quit := make(chan os.Signal, 1)
+	quit <- syscall.SIGINT
+	err = waitForTestnetHeight(mockStatusClient{height: 1}, 2, 10*time.Second, nil, quit)
+	require.ErrorIs(t, err, errTestnetInterrupted)
+
+	// the node never reaches the height, or cannot report it
+	err = waitForTestnetHeight(mockStatusClient{height: 1}, 2, 1500*time.Millisecond, nil, nil)
+	require.EqualError(t, err, "timed out waiting for the testnet to reach height 2")
+	err = waitForTestnetHeight(mockStatusClient{err: errors.New("not started")}, 2, 1500*time.Millisecond, nil, nil)
+	require.EqualError(t, err, "timed out waiting for the testnet to reach height 2")
+}
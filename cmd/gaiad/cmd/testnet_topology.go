@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hostnameIndex is replaced by the index of the node in the hostname template
+// of a testnet.
+const hostnameIndex = "{{i}}"
+
+// TestnetTopology controls the hosts and ports of the nodes of a testnet.
+type TestnetTopology struct {
+	// StartingIPAddress is the IPv4 or IPv6 address of the first node, which
+	// is incremented for the next ones. If empty, the nodes have the external
+	// IP address of this machine.
+	StartingIPAddress string
+
+	// HostnameTemplate, if not empty, is the hostname of the nodes, in which
+	// {{i}} is replaced by the index of the node, e.g. node{{i}}.svc. It takes
+	// precedence over StartingIPAddress.
+	HostnameTemplate string
+
+	// SingleHost runs all nodes on StartingIPAddress, the listen ports of the
+	// node i being the default ones plus i times PortOffset.
+	SingleHost bool
+	PortOffset int
+
+	// Sentries is the number of sentry nodes of each validator, which is then
+	// only connected to its sentries, and FullNodes the number of other full
+	// nodes, connected to the sentries or, without sentries, to the validators.
+	Sentries  int
+	FullNodes int
+}
+
+// testnetPeers is the P2P config of a testnet node.
+type testnetPeers struct {
+	Pex                  bool
+	PersistentPeers      []string
+	PrivatePeerIDs       []string
+	UnconditionalPeerIDs []string
+}
+
+// validate checks that the topology gives distinct addresses to n nodes.
+func (t TestnetTopology) validate(n int) error {
+	if t.HostnameTemplate != "" {
+		if t.SingleHost {
+			return fmt.Errorf("a hostname template cannot be used with a single host")
+		}
+		if n > 1 && !strings.Contains(t.HostnameTemplate, hostnameIndex) {
+			return fmt.Errorf("hostname template %s does not contain %s, so the nodes would have the same hostname", t.HostnameTemplate, hostnameIndex)
+		}
+		return nil
+	}
+
+	if !t.SingleHost {
+		// the last address must not overflow
+		_, err := getIP(n-1, t.StartingIPAddress)
+		return err
+	}
+
+	ports := make(map[int]string)
+	for i := 0; i < n; i++ {
+		node := t.node(i)
+		for _, p := range []struct {
+			name string
+			port int
+		}{
+			{"P2P", node.P2PPort}, {"RPC", node.RPCPort}, {"API", node.APIPort}, {"gRPC", node.GRPCPort},
+		} {
+			name, port := p.name, p.port
+			if port < 1 || port > 65535 {
+				return fmt.Errorf("%s port %d of node %d is out of range; use another port offset", name, port, i)
+			}
+			if other, ok := ports[port]; ok {
+				return fmt.Errorf("%s port %d of node %d is also the %s; use a larger port offset", name, port, i, other)
+			}
+			ports[port] = fmt.Sprintf("%s port of node %d", name, i)
+		}
+	}
+
+	return nil
+}
+
+// node returns the ports of the node i, without its host.
+func (t TestnetTopology) node(i int) testnetNode {
+	offset := 0
+	if t.SingleHost {
+		offset = i * t.PortOffset
+	}
+
+	return testnetNode{
+		P2PPort:  defaultP2PPort + offset,
+		RPCPort:  defaultRPCPort + offset,
+		APIPort:  defaultAPIPort + offset,
+		GRPCPort: defaultGRPCPort + offset,
+	}
+}
+
+// host returns the address the node i is advertised at to its peers.
+func (t TestnetTopology) host(i int) (string, error) {
+	switch {
+	case t.HostnameTemplate != "":
+		return strings.ReplaceAll(t.HostnameTemplate, hostnameIndex, strconv.Itoa(i)), nil
+	case t.SingleHost:
+		return getIP(0, t.StartingIPAddress)
+	default:
+		return getIP(i, t.StartingIPAddress)
+	}
+}
+
+// monikers returns the monikers of the nodes of a testnet: the validators,
+// then the sentries of each validator, then the full nodes.
+func (t TestnetTopology) monikers(validators []ValidatorSpec) []string {
+	monikers := make([]string, 0, len(validators)*(1+t.Sentries)+t.FullNodes)
+	for _, v := range validators {
+		monikers = append(monikers, v.Name)
+	}
+	for _, v := range validators {
+		for j := 0; j < t.Sentries; j++ {
+			monikers = append(monikers, fmt.Sprintf("%s-sentry%d", v.Name, j))
+		}
+	}
+	for j := 0; j < t.FullNodes; j++ {
+		monikers = append(monikers, fmt.Sprintf("fullnode%d", j))
+	}
+
+	return monikers
+}
+
+// nodes returns the nodes of a testnet, in the order of their monikers, each
+// in the nodeDaemonHome directory of its node directory.
+func (t TestnetTopology) nodes(outputDir, nodeDirPrefix, nodeDaemonHome string, validators []ValidatorSpec) ([]testnetNode, error) {
+	if t.Sentries < 0 || t.FullNodes < 0 {
+		return nil, fmt.Errorf("the numbers of sentries and full nodes cannot be negative")
+	}
+
+	monikers := t.monikers(validators)
+	if err := t.validate(len(monikers)); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]testnetNode, len(monikers))
+	for i := range nodes {
+		host, err := t.host(i)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes[i] = t.node(i)
+		nodes[i].Moniker = monikers[i]
+		nodes[i].Dir = filepath.Join(outputDir, fmt.Sprintf("%s%d", nodeDirPrefix, i), nodeDaemonHome)
+		nodes[i].Host = host
+		nodes[i].ListenHost = "0.0.0.0"
+		if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+			nodes[i].ListenHost = "::"
+		}
+	}
+
+	return nodes, nil
+}
+
+// peers returns the P2P config of the node i of a testnet, whose first
+// numValidators nodes are the validators. A validator with sentries only
+// connects to its sentries, without peer exchange, and its sentries, which
+// keep its node ID private, connect to it and to the other sentries.
+// Otherwise the validators connect to each other. The full nodes connect to
+// the sentries or, without sentries, to the validators.
+func (t TestnetTopology) peers(nodes []testnetNode, numValidators, i int) testnetPeers {
+	validators := nodes[:numValidators]
+	sentries := nodes[numValidators : numValidators*(1+t.Sentries)]
+
+	peers := testnetPeers{Pex: true}
+	switch {
+	case i < numValidators && t.Sentries > 0:
+		peers.Pex = false
+		peers.PersistentPeers = peerAddresses(sentries[i*t.Sentries:(i+1)*t.Sentries], "")
+	case i < numValidators:
+		peers.PersistentPeers = peerAddresses(validators, nodes[i].NodeID)
+	case i < numValidators+len(sentries):
+		validator := validators[(i-numValidators)/t.Sentries]
+		peers.PersistentPeers = append([]string{validator.peerAddress()}, peerAddresses(sentries, nodes[i].NodeID)...)
+		peers.PrivatePeerIDs = []string{validator.NodeID}
+		peers.UnconditionalPeerIDs = []string{validator.NodeID}
+	case t.Sentries > 0:
+		peers.PersistentPeers = peerAddresses(sentries, "")
+	default:
+		peers.PersistentPeers = peerAddresses(validators, "")
+	}
+
+	return peers
+}
+
+// peerAddresses returns the peer addresses of the nodes but the one of the
+// node ID exclude.
+func peerAddresses(nodes []testnetNode, exclude string) []string {
+	addrs := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if n.NodeID != exclude {
+			addrs = append(addrs, n.peerAddress())
+		}
+	}
+
+	return addrs
+}
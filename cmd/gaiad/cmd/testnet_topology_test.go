@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmconfig "github.com/tendermint/tendermint/config"
+)
+
+func TestCalculateIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		i       int
+		want    string
+		wantErr bool
+	}{
+		{"192.168.0.1", 0, "192.168.0.1", false},
+		{"192.168.0.1", 3, "192.168.0.4", false},
+		{"192.168.0.254", 3, "192.168.1.1", false},
+		{"192.168.255.255", 1, "192.169.0.0", false},
+		{"255.255.255.254", 2, "", true},
+		{"fd00::1", 2, "fd00::3", false},
+		{"fd00::ffff", 1, "fd00::1:0", false},
+		{"node0.svc", 1, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := calculateIP(tt.ip, tt.i)
+		if tt.wantErr {
+			require.Error(t, err, tt.ip)
+			continue
+		}
+		require.NoError(t, err, tt.ip)
+		require.Equal(t, tt.want, got, tt.ip)
+	}
+}
+
+func TestTestnetTopologyNodes(t *testing.T) {
+	validators := []ValidatorSpec{{Name: "node0"}, {Name: "node1"}, {Name: "node2"}}
+
+	nodes, err := TestnetTopology{StartingIPAddress: "fd00::1"}.nodes("out", "node", "gaiad", validators)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("out", "node2", "gaiad"), nodes[2].Dir)
+	require.Equal(t, "fd00::3", nodes[2].Host)
+	require.Equal(t, "tcp://[::]:26656", nodes[2].p2pAddress())
+	nodes[2].NodeID = "id2"
+	require.Equal(t, "id2@[fd00::3]:26656", nodes[2].peerAddress())
+
+	nodes, err = TestnetTopology{HostnameTemplate: "node{{i}}.svc"}.nodes("out", "node", "gaiad", validators)
+	require.NoError(t, err)
+	require.Equal(t, "node1.svc", nodes[1].Host)
+	require.Equal(t, "tcp://0.0.0.0:26657", nodes[1].rpcAddress())
+
+	_, err = TestnetTopology{HostnameTemplate: "node.svc"}.nodes("out", "node", "gaiad", validators)
+	require.Error(t, err)
+
+	topology := TestnetTopology{StartingIPAddress: "127.0.0.1", SingleHost: true, PortOffset: 100}
+	nodes, err = topology.nodes("out", "node", "gaiad", validators)
+	require.NoError(t, err)
+	for i, n := range nodes {
+		require.Equal(t, "127.0.0.1", n.Host)
+		require.Equal(t, defaultP2PPort+100*i, n.P2PPort)
+		require.Equal(t, defaultRPCPort+100*i, n.RPCPort)
+		require.Equal(t, defaultAPIPort+100*i, n.APIPort)
+		require.Equal(t, defaultGRPCPort+100*i, n.GRPCPort)
+	}
+
+	// the nodes do not serve pprof
+	nodeConfig := tmconfig.DefaultConfig()
+	setNodeConfig(nodeConfig, nodes[1])
+	require.Equal(t, "tcp://0.0.0.0:26757", nodeConfig.RPC.ListenAddress)
+	require.Empty(t, nodeConfig.RPC.PprofListenAddress)
+
+	// with an offset of 1, the P2P port of a node is the RPC port of the previous one
+	topology.PortOffset = 1
+	_, err = topology.nodes("out", "node", "gaiad", validators)
+	require.Error(t, err)
+
+	topology.PortOffset = 20000
+	_, err = topology.nodes("out", "node", "gaiad", validators)
+	require.Error(t, err)
+}
+
+func TestTestnetTopologyPeers(t *testing.T) {
+	validators := []ValidatorSpec{{Name: "val0"}, {Name: "val1"}}
+	topology := TestnetTopology{StartingIPAddress: "10.0.0.1", Sentries: 2, FullNodes: 1}
+
+	nodes, err := topology.nodes("out", "node", "gaiad", validators)
+	require.NoError(t, err)
+	require.Len(t, nodes, 7)
+	for i := range nodes {
+		nodes[i].NodeID = nodes[i].Moniker
+	}
+	require.Equal(t, "val1-sentry0", nodes[4].Moniker)
+	require.Equal(t, "fullnode0", nodes[6].Moniker)
+	require.Equal(t, filepath.Join("out", "node6", "gaiad"), nodes[6].Dir)
+	require.Equal(t, "10.0.0.7", nodes[6].Host)
+
+	// a validator only connects to its own sentries
+	peers := topology.peers(nodes, 2, 1)
+	require.False(t, peers.Pex)
+	require.Equal(t, []string{"val1-sentry0@10.0.0.5:26656", "val1-sentry1@10.0.0.6:26656"}, peers.PersistentPeers)
+	require.Empty(t, peers.PrivatePeerIDs)
+
+	// a sentry connects to its validator, kept private, and to the other sentries
+	peers = topology.peers(nodes, 2, 3)
+	require.True(t, peers.Pex)
+	require.Equal(t, []string{
+		"val0@10.0.0.1:26656",
+		"val0-sentry0@10.0.0.3:26656", "val1-sentry0@10.0.0.5:26656", "val1-sentry1@10.0.0.6:26656",
+	}, peers.PersistentPeers)
+	require.Equal(t, []string{"val0"}, peers.PrivatePeerIDs)
+	require.Equal(t, []string{"val0"}, peers.UnconditionalPeerIDs)
+
+	// a full node connects to the sentries
+	peers = topology.peers(nodes, 2, 6)
+	require.True(t, peers.Pex)
+	require.Len(t, peers.PersistentPeers, 4)
+	require.Empty(t, peers.PrivatePeerIDs)
+
+	// without sentries, validators connect to each other and full nodes to them
+	topology.Sentries = 0
+	nodes, err = topology.nodes("out", "node", "gaiad", validators)
+	require.NoError(t, err)
+	require.Len(t, nodes, 3)
+	for i := range nodes {
+		nodes[i].NodeID = nodes[i].Moniker
+	}
+	peers = topology.peers(nodes, 2, 0)
+	require.True(t, peers.Pex)
+	require.Equal(t, []string{"val1@10.0.0.2:26656"}, peers.PersistentPeers)
+	peers = topology.peers(nodes, 2, 2)
+	require.Equal(t, []string{"val0@10.0.0.1:26656", "val1@10.0.0.2:26656"}, peers.PersistentPeers)
+}
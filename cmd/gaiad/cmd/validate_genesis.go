@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/server"
+	"github.com/cosmos/cosmos-sdk/types/module"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+)
+
+// ValidateGenesisCmd returns the validate-genesis cobra Command. Unlike the SDK
+// command, it also validates sharded genesis files, whose app state is read
+// from the shards directory next to the genesis document.
+func ValidateGenesisCmd(mbm module.BasicManager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate-genesis [file]",
+		Args:  cobra.RangeArgs(0, 1),
+		Short: "validates the genesis file at the default location or at the location passed as an arg",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverCtx := server.GetServerContextFromCmd(cmd)
+			clientCtx := client.GetClientContextFromCmd(cmd)
+
+			// Load default if passed no args, otherwise load passed file
+			genesis := serverCtx.Config.GenesisFile()
+			if len(args) > 0 {
+				genesis = args[0]
+			}
+
+			genState, _, err := gaia.GenesisStateFromGenFile(genesis)
+			if err != nil {
+				return fmt.Errorf("error reading genesis file %s: %w", genesis, err)
+			}
+
+			if err := mbm.ValidateGenesis(clientCtx.JSONMarshaler, clientCtx.TxConfig, genState); err != nil {
+				return fmt.Errorf("error validating genesis file %s: %w", genesis, err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "File at %s is a valid genesis file\n", genesis)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,162 @@
+// Package network starts networks of in-process Gaia validators for
+// end-to-end tests, on top of the test network of the SDK, and runs the
+// gaiad query and tx commands against them.
+package network
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	tmcli "github.com/tendermint/tendermint/libs/cli"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	servertypes "github.com/cosmos/cosmos-sdk/server/types"
+	"github.com/cosmos/cosmos-sdk/simapp"
+	storetypes "github.com/cosmos/cosmos-sdk/store/types"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	clitestutil "github.com/cosmos/cosmos-sdk/testutil/cli"
+	"github.com/cosmos/cosmos-sdk/testutil/network"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authclient "github.com/cosmos/cosmos-sdk/x/auth/client"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+
+	gaia "github.com/cosmos/gaia/v4/app"
+	"github.com/cosmos/gaia/v4/app/params"
+	"github.com/cosmos/gaia/v4/cmd/gaiad/cmd"
+)
+
+type (
+	// Config is the config of a test network.
+	Config = network.Config
+	// Validator is a validator of a test network.
+	Validator = network.Validator
+)
+
+// Network is a network of in-process Gaia validators. The client context of
+// each validator has a keyring with the key of the validator, named after its
+// moniker and funded with Config.AccountTokens of its own token and
+// Config.StakingTokens of the bond denom. Only the first validator serves the
+// RPC, API and gRPC endpoints the commands are run against.
+type Network struct {
+	*network.Network
+
+	cleanup sync.Once
+}
+
+// New starts a network of Gaia validators of the config, and stops it when
+// the test ends. Only one network runs at a time: New waits for the network
+// of another test to be stopped.
+func New(t *testing.T, cfg Config) *Network {
+	n := &Network{Network: network.New(t, cfg)}
+	t.Cleanup(n.Cleanup)
+
+	_, err := n.WaitForHeight(1)
+	if err != nil {
+		t.Fatalf("test network did not start: %v", err)
+	}
+
+	return n
+}
+
+// Cleanup stops the network. It may be called before the test ends, e.g. to
+// start another network, and does nothing once the network is stopped.
+func (n *Network) Cleanup() {
+	n.cleanup.Do(n.Network.Cleanup)
+}
+
+// DefaultConfig returns the config of a network of four Gaia validators, with
+// the default genesis state of the modules of the app.
+func DefaultConfig() Config {
+	encCfg := gaia.MakeEncodingConfig()
+
+	return Config{
+		Codec:             encCfg.Marshaler,
+		TxConfig:          encCfg.TxConfig,
+		LegacyAmino:       encCfg.Amino,
+		InterfaceRegistry: encCfg.InterfaceRegistry,
+		AccountRetriever:  authtypes.AccountRetriever{},
+		AppConstructor:    NewAppConstructor(encCfg),
+		GenesisState:      gaia.ModuleBasics.DefaultGenesis(encCfg.Marshaler),
+		TimeoutCommit:     2 * time.Second,
+		ChainID:           "chain-" + tmrand.NewRand().Str(6),
+		NumValidators:     4,
+		BondDenom:         sdk.DefaultBondDenom,
+		MinGasPrices:      fmt.Sprintf("0.000006%s", sdk.DefaultBondDenom),
+		AccountTokens:     sdk.TokensFromConsensusPower(1000),
+		StakingTokens:     sdk.TokensFromConsensusPower(500),
+		BondedTokens:      sdk.TokensFromConsensusPower(100),
+		PruningStrategy:   storetypes.PruningOptionNothing,
+		CleanupDir:        true,
+		SigningAlgo:       string(hd.Secp256k1Type),
+		KeyringOptions:    []keyring.Option{},
+	}
+}
+
+// NewAppConstructor returns the constructor of the GaiaApp of a validator.
+func NewAppConstructor(encCfg params.EncodingConfig) network.AppConstructor {
+	return func(val network.Validator) servertypes.Application {
+		return gaia.NewGaiaApp(
+			val.Ctx.Logger, dbm.NewMemDB(), nil, true, map[int64]bool{}, val.Ctx.Config.RootDir, 0,
+			encCfg,
+			simapp.EmptyAppOptions{},
+			baseapp.SetPruning(storetypes.NewPruningOptionsFromString(val.AppConfig.Pruning)),
+			baseapp.SetMinGasPrices(val.AppConfig.MinGasPrices),
+		)
+	}
+}
+
+// ExecQueryCmd runs gaiad query with the args against the validator, which
+// must be the first one, and returns its output, in JSON unless the args set
+// another output format.
+func (n *Network) ExecQueryCmd(val *Validator, args ...string) (testutil.BufferWriter, error) {
+	args = append([]string{fmt.Sprintf("--%s=json", tmcli.OutputFlag)}, args...)
+	return clitestutil.ExecTestCLICmd(val.ClientCtx, cmd.QueryCommand(), args)
+}
+
+// ExecTxCmd runs gaiad tx with the args against the validator, which must be
+// the first one, and returns its output. The transaction is signed with the
+// key of the keyring of the validator given by --from, and broadcast in block
+// mode without confirmation, paying fees of 10 bond denom tokens unless the
+// args set other fees.
+func (n *Network) ExecTxCmd(val *Validator, args ...string) (testutil.BufferWriter, error) {
+	authclient.Codec = n.Config.Codec
+
+	fees := sdk.NewCoins(sdk.NewInt64Coin(n.Config.BondDenom, 10))
+	args = append([]string{
+		fmt.Sprintf("--%s=true", flags.FlagSkipConfirmation),
+		fmt.Sprintf("--%s=%s", flags.FlagBroadcastMode, flags.BroadcastBlock),
+		fmt.Sprintf("--%s=%s", flags.FlagFees, fees),
+	}, args...)
+	return clitestutil.ExecTestCLICmd(val.ClientCtx, cmd.TxCommand(), args)
+}
+
+// NewAccount creates a key in the keyring of the validator, funds it with the
+// coins sent by the validator, and returns its address.
+func (n *Network) NewAccount(val *Validator, name string, coins sdk.Coins) (sdk.AccAddress, error) {
+	info, _, err := val.ClientCtx.Keyring.NewMnemonic(name, keyring.English, sdk.FullFundraiserPath, hd.Secp256k1)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := n.ExecTxCmd(val, "bank", "send", val.Address.String(), info.GetAddress().String(), coins.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var res sdk.TxResponse
+	if err := val.ClientCtx.JSONMarshaler.UnmarshalJSON(out.Bytes(), &res); err != nil {
+		return nil, err
+	}
+	if res.Code != 0 {
+		return nil, fmt.Errorf("failed to fund %s: %s", name, res.RawLog)
+	}
+
+	return info.GetAddress(), nil
+}
@@ -0,0 +1,50 @@
+package network_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+
+	"github.com/cosmos/gaia/v4/testutil/network"
+)
+
+func TestNetwork(t *testing.T) {
+	cfg := network.DefaultConfig()
+	cfg.NumValidators = 2
+	n := network.New(t, cfg)
+	// the network is stopped again when the test ends
+	defer n.Cleanup()
+	val := n.Validators[0]
+
+	out, err := n.ExecQueryCmd(val, "staking", "validators")
+	require.NoError(t, err)
+	var validators stakingtypes.QueryValidatorsResponse
+	require.NoError(t, val.ClientCtx.JSONMarshaler.UnmarshalJSON(out.Bytes(), &validators))
+	require.Len(t, validators.Validators, 2)
+
+	coins := sdk.NewCoins(sdk.NewInt64Coin(cfg.BondDenom, 1000))
+	addr, err := n.NewAccount(val, "alice", coins)
+	require.NoError(t, err)
+
+	out, err = n.ExecQueryCmd(val, "bank", "balances", addr.String())
+	require.NoError(t, err)
+	var balances banktypes.QueryAllBalancesResponse
+	require.NoError(t, val.ClientCtx.JSONMarshaler.UnmarshalJSON(out.Bytes(), &balances))
+	require.Equal(t, coins, balances.Balances)
+
+	// the new key signs transactions from the keyring of the validator
+	out, err = n.ExecTxCmd(val, "bank", "send", "alice", val.Address.String(), "100"+cfg.BondDenom, "--fees=10"+cfg.BondDenom)
+	require.NoError(t, err)
+	var res sdk.TxResponse
+	require.NoError(t, val.ClientCtx.JSONMarshaler.UnmarshalJSON(out.Bytes(), &res))
+	require.Zero(t, res.Code, res.RawLog)
+
+	out, err = n.ExecQueryCmd(val, "bank", "balances", addr.String())
+	require.NoError(t, err)
+	require.NoError(t, val.ClientCtx.JSONMarshaler.UnmarshalJSON(out.Bytes(), &balances))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin(cfg.BondDenom, 890)), balances.Balances)
+}
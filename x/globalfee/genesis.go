@@ -0,0 +1,18 @@
+package globalfee
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/cosmos/gaia/v4/x/globalfee/keeper"
+	"github.com/cosmos/gaia/v4/x/globalfee/types"
+)
+
+// InitGenesis sets the global fee parameters from the genesis state.
+func InitGenesis(ctx sdk.Context, k keeper.Keeper, data types.GenesisState) {
+	k.SetParams(ctx, data.Params)
+}
+
+// ExportGenesis returns the global fee genesis state.
+func ExportGenesis(ctx sdk.Context, k keeper.Keeper) *types.GenesisState {
+	return types.NewGenesisState(k.GetParams(ctx))
+}
@@ -0,0 +1,43 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramtypes "github.com/cosmos/cosmos-sdk/x/params/types"
+
+	"github.com/cosmos/gaia/v4/x/globalfee/types"
+)
+
+// Keeper of the global fee store. The module only holds parameters, so the
+// keeper is a thin wrapper around its params subspace.
+type Keeper struct {
+	paramSpace paramtypes.Subspace
+}
+
+// NewKeeper creates a new global fee Keeper instance.
+func NewKeeper(paramSpace paramtypes.Subspace) Keeper {
+	// set KeyTable if it has not already been set
+	if !paramSpace.HasKeyTable() {
+		paramSpace = paramSpace.WithKeyTable(types.ParamKeyTable())
+	}
+
+	return Keeper{paramSpace: paramSpace}
+}
+
+// GetParams returns the global fee parameters. Parameters that were never set,
+// e.g. on a chain that upgraded to a binary with this module, are returned at
+// their default value.
+func (k Keeper) GetParams(ctx sdk.Context) types.Params {
+	params := types.DefaultParams()
+	k.paramSpace.GetIfExists(ctx, types.KeyMinimumGasPrices, &params.MinimumGasPrices)
+	return params
+}
+
+// SetParams sets the global fee parameters.
+func (k Keeper) SetParams(ctx sdk.Context, params types.Params) {
+	k.paramSpace.SetParamSet(ctx, &params)
+}
+
+// GetMinimumGasPrices returns the chain-wide minimum gas prices.
+func (k Keeper) GetMinimumGasPrices(ctx sdk.Context) sdk.DecCoins {
+	return k.GetParams(ctx).MinimumGasPrices
+}
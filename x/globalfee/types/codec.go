@@ -0,0 +1,13 @@
+package types
+
+import (
+	"github.com/cosmos/cosmos-sdk/codec"
+)
+
+// ModuleCdc is the amino codec used to encode the global fee genesis state and
+// query responses.
+var ModuleCdc = codec.NewLegacyAmino()
+
+func init() {
+	ModuleCdc.Seal()
+}
@@ -0,0 +1,21 @@
+package types
+
+// GenesisState defines the global fee genesis state.
+type GenesisState struct {
+	Params Params `json:"params" yaml:"params"`
+}
+
+// NewGenesisState creates a new global fee genesis state.
+func NewGenesisState(params Params) *GenesisState {
+	return &GenesisState{Params: params}
+}
+
+// DefaultGenesisState returns the default global fee genesis state.
+func DefaultGenesisState() *GenesisState {
+	return NewGenesisState(DefaultParams())
+}
+
+// Validate performs a basic validation of the genesis state.
+func (gs GenesisState) Validate() error {
+	return gs.Params.Validate()
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramtypes "github.com/cosmos/cosmos-sdk/x/params/types"
+)
+
+// KeyMinimumGasPrices is the parameter store key of the chain-wide minimum
+// gas prices.
+var KeyMinimumGasPrices = []byte("MinimumGasPrices")
+
+var _ paramtypes.ParamSet = (*Params)(nil)
+
+// Params defines the global fee parameters, set by governance through
+// parameter change proposals.
+type Params struct {
+	// MinimumGasPrices are the chain-wide minimum gas prices a transaction fee
+	// must meet in at least one of the listed denominations.
+	MinimumGasPrices sdk.DecCoins `json:"minimum_gas_prices" yaml:"minimum_gas_prices"`
+}
+
+// ParamKeyTable returns the key table of the global fee parameters.
+func ParamKeyTable() paramtypes.KeyTable {
+	return paramtypes.NewKeyTable().RegisterParamSet(&Params{})
+}
+
+// DefaultParams returns the default global fee parameters: no chain-wide
+// minimum gas price.
+func DefaultParams() Params {
+	return Params{MinimumGasPrices: sdk.DecCoins{}}
+}
+
+// ParamSetPairs implements the ParamSet interface.
+func (p *Params) ParamSetPairs() paramtypes.ParamSetPairs {
+	return paramtypes.ParamSetPairs{
+		paramtypes.NewParamSetPair(KeyMinimumGasPrices, &p.MinimumGasPrices, validateMinimumGasPrices),
+	}
+}
+
+// Validate performs a basic validation of the parameters.
+func (p Params) Validate() error {
+	return validateMinimumGasPrices(p.MinimumGasPrices)
+}
+
+func validateMinimumGasPrices(i interface{}) error {
+	v, ok := i.(sdk.DecCoins)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+
+	if err := v.Validate(); err != nil {
+		return fmt.Errorf("invalid minimum gas prices: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/cosmos/gaia/v4/x/globalfee/types"
+)
+
+func TestParamsValidate(t *testing.T) {
+	require.NoError(t, types.DefaultParams().Validate())
+
+	valid := types.Params{MinimumGasPrices: sdk.NewDecCoins(
+		sdk.NewDecCoinFromDec("stake", sdk.NewDecWithPrec(1, 2)),
+		sdk.NewDecCoinFromDec("uatom", sdk.NewDecWithPrec(25, 4)),
+	)}
+	require.NoError(t, valid.Validate())
+
+	unsorted := types.Params{MinimumGasPrices: sdk.DecCoins{
+		sdk.NewDecCoinFromDec("uatom", sdk.NewDecWithPrec(25, 4)),
+		sdk.NewDecCoinFromDec("stake", sdk.NewDecWithPrec(1, 2)),
+	}}
+	require.Error(t, unsorted.Validate())
+
+	negative := types.Params{MinimumGasPrices: sdk.DecCoins{
+		sdk.DecCoin{Denom: "uatom", Amount: sdk.NewDec(-1)},
+	}}
+	require.Error(t, negative.Validate())
+}
+
+func TestGenesisStateJSONRoundTrip(t *testing.T) {
+	gs := types.NewGenesisState(types.Params{MinimumGasPrices: sdk.NewDecCoins(
+		sdk.NewDecCoinFromDec("uatom", sdk.NewDecWithPrec(25, 4)),
+	)})
+
+	bz := types.ModuleCdc.MustMarshalJSON(gs)
+
+	var decoded types.GenesisState
+	require.NoError(t, types.ModuleCdc.UnmarshalJSON(bz, &decoded))
+	require.Equal(t, *gs, decoded)
+	require.NoError(t, decoded.Validate())
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+
+	"github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+// GetQueryCmd returns the cli query commands for the rate limit module.
+func GetQueryCmd() *cobra.Command {
+	queryCmd := &cobra.Command{
+		Use:                        types.ModuleName,
+		Short:                      "Querying commands for the rate limit module",
+		DisableFlagParsing:         true,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	queryCmd.AddCommand(
+		GetCmdQueryQuotas(),
+		GetCmdQueryFlows(),
+		GetCmdQueryFlow(),
+	)
+
+	return queryCmd
+}
+
+// GetCmdQueryQuotas implements a command to return the rate limit quotas.
+func GetCmdQueryQuotas() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quotas",
+		Short: "Query the rate limit quotas set by governance",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			route := fmt.Sprintf("custom/%s/%s", types.QuerierRoute, types.QueryQuotas)
+			res, _, err := clientCtx.QueryWithData(route, nil)
+			if err != nil {
+				return err
+			}
+
+			var quotas []types.Quota
+			if err := types.ModuleCdc.UnmarshalJSON(res, &quotas); err != nil {
+				return err
+			}
+
+			return clientCtx.PrintObjectLegacy(quotas)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+// GetCmdQueryFlows implements a command to return the current usage of every
+// rate limit quota.
+func GetCmdQueryFlows() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "flows",
+		Short: "Query the current usage of every rate limit quota",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			route := fmt.Sprintf("custom/%s/%s", types.QuerierRoute, types.QueryFlows)
+			res, _, err := clientCtx.QueryWithData(route, nil)
+			if err != nil {
+				return err
+			}
+
+			var usages []types.QuotaUsage
+			if err := types.ModuleCdc.UnmarshalJSON(res, &usages); err != nil {
+				return err
+			}
+
+			return clientCtx.PrintObjectLegacy(usages)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
+
+// GetCmdQueryFlow implements a command to return the current usage of the
+// quota of a channel and denom.
+func GetCmdQueryFlow() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "flow [channel-id] [denom]",
+		Short: "Query the current usage of the rate limit quota of a channel and denom",
+		Example: fmt.Sprintf(
+			"$ <appd> query %s flow channel-0 uatom", types.ModuleName,
+		),
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			bz, err := types.ModuleCdc.MarshalJSON(types.NewQueryFlowParams(args[0], args[1]))
+			if err != nil {
+				return err
+			}
+
+			route := fmt.Sprintf("custom/%s/%s", types.QuerierRoute, types.QueryFlow)
+			res, _, err := clientCtx.QueryWithData(route, bz)
+			if err != nil {
+				return err
+			}
+
+			var usage types.QuotaUsage
+			if err := types.ModuleCdc.UnmarshalJSON(res, &usage); err != nil {
+				return err
+			}
+
+			return clientCtx.PrintObjectLegacy(usage)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+
+	return cmd
+}
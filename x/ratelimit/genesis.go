@@ -0,0 +1,22 @@
+package ratelimit
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/cosmos/gaia/v4/x/ratelimit/keeper"
+	"github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+// InitGenesis sets the rate limit parameters and flows from the genesis state.
+func InitGenesis(ctx sdk.Context, k keeper.Keeper, data types.GenesisState) {
+	k.SetParams(ctx, data.Params)
+
+	for _, record := range data.Flows {
+		k.SetFlow(ctx, record.ChannelID, record.Denom, record.Flow)
+	}
+}
+
+// ExportGenesis returns the rate limit genesis state.
+func ExportGenesis(ctx sdk.Context, k keeper.Keeper) *types.GenesisState {
+	return types.NewGenesisState(k.GetParams(ctx), k.GetAllFlows(ctx))
+}
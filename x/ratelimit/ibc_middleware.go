@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	capabilitytypes "github.com/cosmos/cosmos-sdk/x/capability/types"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	porttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/05-port/types"
+
+	"github.com/cosmos/gaia/v4/x/ratelimit/keeper"
+	"github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+var _ porttypes.IBCModule = IBCMiddleware{}
+
+// IBCMiddleware wraps the transfer module to rate limit the tokens received
+// on each channel and to release the outflow of refunded packets. Outgoing
+// transfers are checked by the keeper, which the transfer keeper uses as its
+// channel keeper.
+type IBCMiddleware struct {
+	app    porttypes.IBCModule
+	keeper keeper.Keeper
+}
+
+// NewIBCMiddleware creates a new IBCMiddleware wrapping the given application.
+func NewIBCMiddleware(app porttypes.IBCModule, k keeper.Keeper) IBCMiddleware {
+	return IBCMiddleware{
+		app:    app,
+		keeper: k,
+	}
+}
+
+// OnChanOpenInit implements the IBCModule interface
+func (im IBCMiddleware) OnChanOpenInit(
+	ctx sdk.Context,
+	order channeltypes.Order,
+	connectionHops []string,
+	portID string,
+	channelID string,
+	chanCap *capabilitytypes.Capability,
+	counterparty channeltypes.Counterparty,
+	version string,
+) error {
+	return im.app.OnChanOpenInit(ctx, order, connectionHops, portID, channelID, chanCap, counterparty, version)
+}
+
+// OnChanOpenTry implements the IBCModule interface
+func (im IBCMiddleware) OnChanOpenTry(
+	ctx sdk.Context,
+	order channeltypes.Order,
+	connectionHops []string,
+	portID,
+	channelID string,
+	chanCap *capabilitytypes.Capability,
+	counterparty channeltypes.Counterparty,
+	version,
+	counterpartyVersion string,
+) error {
+	return im.app.OnChanOpenTry(ctx, order, connectionHops, portID, channelID, chanCap, counterparty, version, counterpartyVersion)
+}
+
+// OnChanOpenAck implements the IBCModule interface
+func (im IBCMiddleware) OnChanOpenAck(ctx sdk.Context, portID, channelID string, counterpartyVersion string) error {
+	return im.app.OnChanOpenAck(ctx, portID, channelID, counterpartyVersion)
+}
+
+// OnChanOpenConfirm implements the IBCModule interface
+func (im IBCMiddleware) OnChanOpenConfirm(ctx sdk.Context, portID, channelID string) error {
+	return im.app.OnChanOpenConfirm(ctx, portID, channelID)
+}
+
+// OnChanCloseInit implements the IBCModule interface
+func (im IBCMiddleware) OnChanCloseInit(ctx sdk.Context, portID, channelID string) error {
+	return im.app.OnChanCloseInit(ctx, portID, channelID)
+}
+
+// OnChanCloseConfirm implements the IBCModule interface
+func (im IBCMiddleware) OnChanCloseConfirm(ctx sdk.Context, portID, channelID string) error {
+	return im.app.OnChanCloseConfirm(ctx, portID, channelID)
+}
+
+// OnRecvPacket implements the IBCModule interface. A packet exceeding the
+// quota of its channel and denom is acknowledged with an error, so that the
+// tokens are refunded on the sending chain. The inflow is only recorded if the
+// transfer module successfully processed the packet.
+func (im IBCMiddleware) OnRecvPacket(ctx sdk.Context, packet channeltypes.Packet) (*sdk.Result, []byte, error) {
+	data, err := types.UnmarshalPacketData(packet)
+	if err != nil {
+		return im.app.OnRecvPacket(ctx, packet)
+	}
+
+	// record the inflow in a cached context which is only written if the
+	// transfer module successfully processes the packet
+	cacheCtx, writeCache := ctx.CacheContext()
+	channelID, denom, amount := packet.GetDestChannel(), types.RecvDenom(packet, data), sdk.NewIntFromUint64(data.Amount)
+	if err := im.keeper.CheckAndUpdateInflow(cacheCtx, channelID, denom, amount); err != nil {
+		im.keeper.Logger(ctx).Info("rejected incoming transfer", "channel", channelID, "denom", denom, "amount", amount, "reason", err)
+		ack := channeltypes.NewErrorAcknowledgement(err.Error())
+		return &sdk.Result{}, ack.GetBytes(), nil
+	}
+
+	res, ackBz, err := im.app.OnRecvPacket(ctx, packet)
+	if err != nil {
+		return res, ackBz, err
+	}
+
+	var ack channeltypes.Acknowledgement
+	if err := transfertypes.ModuleCdc.UnmarshalJSON(ackBz, &ack); err == nil {
+		if _, failed := ack.Response.(*channeltypes.Acknowledgement_Error); !failed {
+			writeCache()
+		}
+	}
+
+	return res, ackBz, nil
+}
+
+// OnAcknowledgementPacket implements the IBCModule interface. The outflow of
+// a packet acknowledged with an error is reverted as the tokens are refunded.
+func (im IBCMiddleware) OnAcknowledgementPacket(ctx sdk.Context, packet channeltypes.Packet, acknowledgement []byte) (*sdk.Result, error) {
+	res, err := im.app.OnAcknowledgementPacket(ctx, packet, acknowledgement)
+	if err != nil {
+		return res, err
+	}
+
+	var ack channeltypes.Acknowledgement
+	if err := transfertypes.ModuleCdc.UnmarshalJSON(acknowledgement, &ack); err != nil {
+		return res, nil
+	}
+
+	if _, failed := ack.Response.(*channeltypes.Acknowledgement_Error); failed {
+		im.undoOutflow(ctx, packet)
+	}
+
+	return res, nil
+}
+
+// OnTimeoutPacket implements the IBCModule interface. The outflow of a timed
+// out packet is reverted as the tokens are refunded.
+func (im IBCMiddleware) OnTimeoutPacket(ctx sdk.Context, packet channeltypes.Packet) (*sdk.Result, error) {
+	res, err := im.app.OnTimeoutPacket(ctx, packet)
+	if err != nil {
+		return res, err
+	}
+
+	im.undoOutflow(ctx, packet)
+	return res, nil
+}
+
+func (im IBCMiddleware) undoOutflow(ctx sdk.Context, packet channeltypes.Packet) {
+	data, err := types.UnmarshalPacketData(packet)
+	if err != nil {
+		return
+	}
+
+	im.keeper.UndoOutflow(ctx, packet.GetSourceChannel(), types.SendDenom(data), sdk.NewIntFromUint64(data.Amount))
+}
@@ -0,0 +1,181 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+
+	simappparams "github.com/cosmos/cosmos-sdk/simapp/params"
+	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	bankexported "github.com/cosmos/cosmos-sdk/x/bank/exported"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	clienttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/02-client/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	porttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/05-port/types"
+	paramskeeper "github.com/cosmos/cosmos-sdk/x/params/keeper"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+
+	"github.com/cosmos/gaia/v4/x/ratelimit"
+	"github.com/cosmos/gaia/v4/x/ratelimit/keeper"
+	"github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+var (
+	successAck = channeltypes.NewResultAcknowledgement([]byte{byte(1)}).GetBytes()
+	errorAck   = channeltypes.NewErrorAcknowledgement("transfer failed").GetBytes()
+)
+
+type mockBankKeeper struct {
+	supply sdk.Coins
+}
+
+func (k mockBankKeeper) GetSupply(sdk.Context) bankexported.SupplyI {
+	return banktypes.NewSupply(k.supply)
+}
+
+// mockTransferModule acknowledges the received packets with ack, and counts
+// the packets it processed.
+type mockTransferModule struct {
+	porttypes.IBCModule
+	ack      []byte
+	received int
+}
+
+func (m *mockTransferModule) OnRecvPacket(sdk.Context, channeltypes.Packet) (*sdk.Result, []byte, error) {
+	m.received++
+	return &sdk.Result{}, m.ack, nil
+}
+
+func (m *mockTransferModule) OnAcknowledgementPacket(sdk.Context, channeltypes.Packet, []byte) (*sdk.Result, error) {
+	return &sdk.Result{}, nil
+}
+
+func (m *mockTransferModule) OnTimeoutPacket(sdk.Context, channeltypes.Packet) (*sdk.Result, error) {
+	return &sdk.Result{}, nil
+}
+
+func setupMiddleware(t *testing.T, supply sdk.Coins, quotas ...types.Quota) (sdk.Context, keeper.Keeper, *mockTransferModule, ratelimit.IBCMiddleware) {
+	key := sdk.NewKVStoreKey(types.StoreKey)
+	paramsKey := sdk.NewKVStoreKey(paramstypes.StoreKey)
+	paramsTKey := sdk.NewTransientStoreKey(paramstypes.TStoreKey)
+
+	db := dbm.NewMemDB()
+	cms := store.NewCommitMultiStore(db)
+	cms.MountStoreWithDB(key, sdk.StoreTypeIAVL, db)
+	cms.MountStoreWithDB(paramsKey, sdk.StoreTypeIAVL, db)
+	cms.MountStoreWithDB(paramsTKey, sdk.StoreTypeTransient, db)
+	require.NoError(t, cms.LoadLatestVersion())
+
+	encCfg := simappparams.MakeTestEncodingConfig()
+	paramsKeeper := paramskeeper.NewKeeper(encCfg.Marshaler, encCfg.Amino, paramsKey, paramsTKey)
+	k := keeper.NewKeeper(key, paramsKeeper.Subspace(types.ModuleName), mockBankKeeper{supply: supply}, nil)
+
+	ctx := sdk.NewContext(cms, tmproto.Header{Time: time.Unix(1600000000, 0).UTC()}, false, log.NewNopLogger())
+	k.SetParams(ctx, types.Params{Quotas: quotas})
+
+	app := &mockTransferModule{ack: successAck}
+	return ctx, k, app, ratelimit.NewIBCMiddleware(app, k)
+}
+
+// transferPacket returns a packet of a transfer from channel-7 of the
+// counterparty to channel-0.
+func transferPacket(denom string, amount uint64) channeltypes.Packet {
+	data := transfertypes.NewFungibleTokenPacketData(denom, amount, "sender", "receiver")
+	return channeltypes.NewPacket(
+		data.GetBytes(), 1, transfertypes.PortID, "channel-7", transfertypes.PortID, "channel-0",
+		clienttypes.NewHeight(0, 100), 0,
+	)
+}
+
+func TestOnRecvPacket(t *testing.T) {
+	voucher := transfertypes.ParseDenomTrace("transfer/channel-0/uosmo").IBCDenom()
+	quota := types.NewQuota("channel-0", voucher, sdk.NewDec(10), sdk.NewDec(5), 24)
+	ctx, k, app, im := setupMiddleware(t, sdk.NewCoins(sdk.NewInt64Coin(voucher, 1000)), quota)
+
+	inflow := func() sdk.Int {
+		flow, found := k.GetFlow(ctx, "channel-0", voucher)
+		if !found {
+			return sdk.ZeroInt()
+		}
+		return flow.Inflow
+	}
+
+	// the inflow is recorded when the transfer module receives the packet
+	_, ack, err := im.OnRecvPacket(ctx, transferPacket("uosmo", 20))
+	require.NoError(t, err)
+	require.Equal(t, successAck, ack)
+	require.Equal(t, sdk.NewInt(20), inflow())
+
+	// but not when it fails to
+	app.ack = errorAck
+	_, ack, err = im.OnRecvPacket(ctx, transferPacket("uosmo", 20))
+	require.NoError(t, err)
+	require.Equal(t, errorAck, ack)
+	require.Equal(t, sdk.NewInt(20), inflow())
+	require.Equal(t, 2, app.received)
+
+	// packets over the receive quota of 5% are acknowledged with an error,
+	// without reaching the transfer module
+	app.ack = successAck
+	_, ack, err = im.OnRecvPacket(ctx, transferPacket("uosmo", 40))
+	require.NoError(t, err)
+	require.Contains(t, string(ack), types.ErrQuotaExceeded.Error())
+	require.Equal(t, 2, app.received)
+	require.Equal(t, sdk.NewInt(20), inflow())
+
+	// packets of other denoms are not rate limited
+	_, ack, err = im.OnRecvPacket(ctx, transferPacket("ujuno", 1000))
+	require.NoError(t, err)
+	require.Equal(t, successAck, ack)
+}
+
+func TestOnAcknowledgementAndTimeoutPacket(t *testing.T) {
+	quota := types.NewQuota("channel-7", "uatom", sdk.NewDec(10), sdk.NewDec(5), 24)
+	ctx, k, _, im := setupMiddleware(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000)), quota)
+
+	outflow := func() sdk.Int {
+		flow, _ := k.GetFlow(ctx, "channel-7", "uatom")
+		return flow.Outflow
+	}
+
+	// the packets are sent from channel-7
+	packet := transferPacket("uatom", 40)
+	require.NoError(t, k.CheckAndUpdateOutflow(ctx, "channel-7", "uatom", sdk.NewInt(100)))
+
+	// a successful acknowledgement keeps the outflow
+	_, err := im.OnAcknowledgementPacket(ctx, packet, successAck)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewInt(100), outflow())
+
+	// an error acknowledgement refunds it
+	_, err = im.OnAcknowledgementPacket(ctx, packet, errorAck)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewInt(60), outflow())
+
+	// and so does a timeout
+	_, err = im.OnTimeoutPacket(ctx, packet)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewInt(20), outflow())
+}
+
+func TestOnRecvPacketOfUnmintedDenom(t *testing.T) {
+	voucher := transfertypes.ParseDenomTrace("transfer/channel-0/uosmo").IBCDenom()
+	quota := types.NewQuota("channel-0", voucher, sdk.NewDec(10), sdk.NewDec(5), 24)
+	ctx, k, app, im := setupMiddleware(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000)), quota)
+
+	// the first transfer of a voucher which was never minted cannot mint any
+	// amount of it
+	_, ack, err := im.OnRecvPacket(ctx, transferPacket("uosmo", 1000000000))
+	require.NoError(t, err)
+	require.Contains(t, string(ack), types.ErrQuotaExceeded.Error())
+	require.Zero(t, app.received)
+
+	_, found := k.GetFlow(ctx, "channel-0", voucher)
+	require.False(t, found)
+}
@@ -0,0 +1,53 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// CheckAndUpdateInflow records an inflow of tokens received on the channel. It
+// returns an error, and records nothing, if the inflow exceeds the quota.
+// Channels and denoms without a quota are not rate limited.
+func (k Keeper) CheckAndUpdateInflow(ctx sdk.Context, channelID, denom string, amount sdk.Int) error {
+	quota, found := k.GetParams(ctx).GetQuota(channelID, denom)
+	if !found {
+		return nil
+	}
+
+	flow := k.CurrentFlow(ctx, quota)
+	if err := flow.AddInflow(amount, quota); err != nil {
+		return err
+	}
+
+	k.SetFlow(ctx, channelID, denom, flow)
+	return nil
+}
+
+// CheckAndUpdateOutflow records an outflow of tokens sent over the channel. It
+// returns an error, and records nothing, if the outflow exceeds the quota.
+// Channels and denoms without a quota are not rate limited.
+func (k Keeper) CheckAndUpdateOutflow(ctx sdk.Context, channelID, denom string, amount sdk.Int) error {
+	quota, found := k.GetParams(ctx).GetQuota(channelID, denom)
+	if !found {
+		return nil
+	}
+
+	flow := k.CurrentFlow(ctx, quota)
+	if err := flow.AddOutflow(amount, quota); err != nil {
+		return err
+	}
+
+	k.SetFlow(ctx, channelID, denom, flow)
+	return nil
+}
+
+// UndoOutflow reverts an outflow whose tokens were refunded to the sender. It
+// is a no-op if the window in which the outflow was recorded has expired.
+func (k Keeper) UndoOutflow(ctx sdk.Context, channelID, denom string, amount sdk.Int) {
+	flow, found := k.GetFlow(ctx, channelID, denom)
+	if !found || flow.IsExpired(ctx.BlockTime()) {
+		return
+	}
+
+	flow.UndoOutflow(amount)
+	k.SetFlow(ctx, channelID, denom, flow)
+}
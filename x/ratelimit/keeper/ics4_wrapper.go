@@ -0,0 +1,46 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	capabilitytypes "github.com/cosmos/cosmos-sdk/x/capability/types"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	ibcexported "github.com/cosmos/cosmos-sdk/x/ibc/core/exported"
+
+	"github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+var _ transfertypes.ChannelKeeper = Keeper{}
+
+// GetChannel implements the transfer ChannelKeeper interface.
+func (k Keeper) GetChannel(ctx sdk.Context, srcPort, srcChan string) (channeltypes.Channel, bool) {
+	return k.channelKeeper.GetChannel(ctx, srcPort, srcChan)
+}
+
+// GetNextSequenceSend implements the transfer ChannelKeeper interface.
+func (k Keeper) GetNextSequenceSend(ctx sdk.Context, portID, channelID string) (uint64, bool) {
+	return k.channelKeeper.GetNextSequenceSend(ctx, portID, channelID)
+}
+
+// ChanCloseInit implements the transfer ChannelKeeper interface.
+func (k Keeper) ChanCloseInit(ctx sdk.Context, portID, channelID string, chanCap *capabilitytypes.Capability) error {
+	return k.channelKeeper.ChanCloseInit(ctx, portID, channelID, chanCap)
+}
+
+// SendPacket implements the transfer ChannelKeeper interface. It checks the
+// outgoing transfer against the quota of its channel and denom before handing
+// the packet to the IBC channel keeper.
+func (k Keeper) SendPacket(ctx sdk.Context, channelCap *capabilitytypes.Capability, packet ibcexported.PacketI) error {
+	data, err := types.UnmarshalPacketData(packet)
+	if err != nil {
+		return sdkerrors.Wrapf(sdkerrors.ErrUnknownRequest, "cannot unmarshal ICS-20 transfer packet data: %s", err.Error())
+	}
+
+	amount := sdk.NewIntFromUint64(data.Amount)
+	if err := k.CheckAndUpdateOutflow(ctx, packet.GetSourceChannel(), types.SendDenom(data), amount); err != nil {
+		return err
+	}
+
+	return k.channelKeeper.SendPacket(ctx, channelCap, packet)
+}
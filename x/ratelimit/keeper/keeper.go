@@ -0,0 +1,128 @@
+package keeper
+
+import (
+	"fmt"
+
+	"github.com/tendermint/tendermint/libs/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	paramtypes "github.com/cosmos/cosmos-sdk/x/params/types"
+
+	"github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+// Keeper of the rate limit store. It tracks the flows of rate-limited channels
+// and sits between the transfer keeper and the IBC channel keeper to check
+// outgoing packets against their quota.
+type Keeper struct {
+	storeKey      sdk.StoreKey
+	paramSpace    paramtypes.Subspace
+	bankKeeper    types.BankKeeper
+	channelKeeper transfertypes.ChannelKeeper
+}
+
+// NewKeeper creates a new rate limit Keeper instance.
+func NewKeeper(
+	storeKey sdk.StoreKey, paramSpace paramtypes.Subspace,
+	bankKeeper types.BankKeeper, channelKeeper transfertypes.ChannelKeeper,
+) Keeper {
+	// set KeyTable if it has not already been set
+	if !paramSpace.HasKeyTable() {
+		paramSpace = paramSpace.WithKeyTable(types.ParamKeyTable())
+	}
+
+	return Keeper{
+		storeKey:      storeKey,
+		paramSpace:    paramSpace,
+		bankKeeper:    bankKeeper,
+		channelKeeper: channelKeeper,
+	}
+}
+
+// Logger returns a module-specific logger.
+func (k Keeper) Logger(ctx sdk.Context) log.Logger {
+	return ctx.Logger().With("module", fmt.Sprintf("x/%s", types.ModuleName))
+}
+
+// GetParams returns the rate limit parameters. Parameters that were never set
+// are returned at their default value.
+func (k Keeper) GetParams(ctx sdk.Context) types.Params {
+	params := types.DefaultParams()
+	k.paramSpace.GetIfExists(ctx, types.KeyQuotas, &params.Quotas)
+	return params
+}
+
+// SetParams sets the rate limit parameters.
+func (k Keeper) SetParams(ctx sdk.Context, params types.Params) {
+	k.paramSpace.SetParamSet(ctx, &params)
+}
+
+// GetFlow returns the stored flow of the given channel and denom.
+func (k Keeper) GetFlow(ctx sdk.Context, channelID, denom string) (types.Flow, bool) {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(types.FlowKey(channelID, denom))
+	if bz == nil {
+		return types.Flow{}, false
+	}
+
+	var flow types.Flow
+	types.ModuleCdc.MustUnmarshalBinaryBare(bz, &flow)
+	return flow, true
+}
+
+// SetFlow stores the flow of the given channel and denom.
+func (k Keeper) SetFlow(ctx sdk.Context, channelID, denom string, flow types.Flow) {
+	store := ctx.KVStore(k.storeKey)
+	store.Set(types.FlowKey(channelID, denom), types.ModuleCdc.MustMarshalBinaryBare(flow))
+}
+
+// IterateFlows iterates over all the stored flows. The iteration stops when
+// the callback returns true.
+func (k Keeper) IterateFlows(ctx sdk.Context, cb func(record types.FlowRecord) (stop bool)) {
+	store := ctx.KVStore(k.storeKey)
+	iterator := sdk.KVStorePrefixIterator(store, types.FlowKeyPrefix)
+	defer iterator.Close()
+
+	for ; iterator.Valid(); iterator.Next() {
+		channelID, denom := types.SplitFlowKey(iterator.Key())
+
+		var flow types.Flow
+		types.ModuleCdc.MustUnmarshalBinaryBare(iterator.Value(), &flow)
+
+		if cb(types.FlowRecord{ChannelID: channelID, Denom: denom, Flow: flow}) {
+			break
+		}
+	}
+}
+
+// GetAllFlows returns all the stored flows.
+func (k Keeper) GetAllFlows(ctx sdk.Context) []types.FlowRecord {
+	flows := []types.FlowRecord{}
+	k.IterateFlows(ctx, func(record types.FlowRecord) bool {
+		flows = append(flows, record)
+		return false
+	})
+
+	return flows
+}
+
+// CurrentFlow returns the flow of the current window of the quota. A new,
+// empty window valued at the current supply of the denom is started if there
+// is no flow yet or the previous window has expired. A window started while
+// the denom had no supply, which rejects any net flow, is valued at the supply
+// as soon as the denom is minted otherwise, e.g. through a channel without
+// quota.
+func (k Keeper) CurrentFlow(ctx sdk.Context, quota types.Quota) types.Flow {
+	channelValue := k.bankKeeper.GetSupply(ctx).GetTotal().AmountOf(quota.Denom)
+
+	flow, found := k.GetFlow(ctx, quota.ChannelID, quota.Denom)
+	if found && !flow.IsExpired(ctx.BlockTime()) {
+		if flow.ChannelValue.IsZero() {
+			flow.ChannelValue = channelValue
+		}
+		return flow
+	}
+
+	return types.NewFlow(channelValue, ctx.BlockTime(), quota)
+}
@@ -0,0 +1,137 @@
+package keeper_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+
+	simappparams "github.com/cosmos/cosmos-sdk/simapp/params"
+	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	bankexported "github.com/cosmos/cosmos-sdk/x/bank/exported"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	capabilitytypes "github.com/cosmos/cosmos-sdk/x/capability/types"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	clienttypes "github.com/cosmos/cosmos-sdk/x/ibc/core/02-client/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	ibcexported "github.com/cosmos/cosmos-sdk/x/ibc/core/exported"
+	paramskeeper "github.com/cosmos/cosmos-sdk/x/params/keeper"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+
+	"github.com/cosmos/gaia/v4/x/ratelimit/keeper"
+	"github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+type mockBankKeeper struct {
+	supply sdk.Coins
+}
+
+func (k *mockBankKeeper) GetSupply(sdk.Context) bankexported.SupplyI {
+	return banktypes.NewSupply(k.supply)
+}
+
+// mockChannelKeeper records the packets handed to the IBC channel keeper.
+type mockChannelKeeper struct {
+	transfertypes.ChannelKeeper
+	sent []ibcexported.PacketI
+}
+
+func (k *mockChannelKeeper) SendPacket(_ sdk.Context, _ *capabilitytypes.Capability, packet ibcexported.PacketI) error {
+	k.sent = append(k.sent, packet)
+	return nil
+}
+
+func setupKeeper(t *testing.T, supply sdk.Coins, quotas ...types.Quota) (sdk.Context, keeper.Keeper, *mockBankKeeper, *mockChannelKeeper) {
+	key := sdk.NewKVStoreKey(types.StoreKey)
+	paramsKey := sdk.NewKVStoreKey(paramstypes.StoreKey)
+	paramsTKey := sdk.NewTransientStoreKey(paramstypes.TStoreKey)
+
+	db := dbm.NewMemDB()
+	cms := store.NewCommitMultiStore(db)
+	cms.MountStoreWithDB(key, sdk.StoreTypeIAVL, db)
+	cms.MountStoreWithDB(paramsKey, sdk.StoreTypeIAVL, db)
+	cms.MountStoreWithDB(paramsTKey, sdk.StoreTypeTransient, db)
+	require.NoError(t, cms.LoadLatestVersion())
+
+	encCfg := simappparams.MakeTestEncodingConfig()
+	paramsKeeper := paramskeeper.NewKeeper(encCfg.Marshaler, encCfg.Amino, paramsKey, paramsTKey)
+
+	bk := &mockBankKeeper{supply: supply}
+	ck := &mockChannelKeeper{}
+	k := keeper.NewKeeper(key, paramsKeeper.Subspace(types.ModuleName), bk, ck)
+
+	ctx := sdk.NewContext(cms, tmproto.Header{Time: time.Unix(1600000000, 0).UTC()}, false, log.NewNopLogger())
+	k.SetParams(ctx, types.Params{Quotas: quotas})
+
+	return ctx, k, bk, ck
+}
+
+func transferPacket(sourceChannel, denom string, amount uint64) channeltypes.Packet {
+	data := transfertypes.NewFungibleTokenPacketData(denom, amount, "sender", "receiver")
+	return channeltypes.NewPacket(
+		data.GetBytes(), 1, transfertypes.PortID, sourceChannel, transfertypes.PortID, "channel-7",
+		clienttypes.NewHeight(0, 100), 0,
+	)
+}
+
+func TestSendPacketQuota(t *testing.T) {
+	quota := types.NewQuota("channel-0", "uatom", sdk.NewDec(10), sdk.NewDec(5), 24)
+	ctx, k, _, ck := setupKeeper(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000)), quota)
+
+	// the send quota is 10% of the supply of 1000
+	require.NoError(t, k.SendPacket(ctx, nil, transferPacket("channel-0", "uatom", 60)))
+	err := k.SendPacket(ctx, nil, transferPacket("channel-0", "uatom", 50))
+	require.ErrorIs(t, err, types.ErrQuotaExceeded)
+	require.Len(t, ck.sent, 1)
+
+	flow, found := k.GetFlow(ctx, "channel-0", "uatom")
+	require.True(t, found)
+	require.Equal(t, sdk.NewInt(60), flow.Outflow)
+	require.Equal(t, sdk.NewInt(1000), flow.ChannelValue)
+
+	// channels and denoms without a quota are not rate limited
+	require.NoError(t, k.SendPacket(ctx, nil, transferPacket("channel-1", "uatom", 500)))
+	require.NoError(t, k.SendPacket(ctx, nil, transferPacket("channel-0", "stake", 500)))
+	require.Len(t, ck.sent, 3)
+
+	// refunds free up the quota
+	k.UndoOutflow(ctx, "channel-0", "uatom", sdk.NewInt(60))
+	require.NoError(t, k.SendPacket(ctx, nil, transferPacket("channel-0", "uatom", 100)))
+
+	// a new window starts once the previous one expired, and refunds of
+	// the previous window are ignored
+	ctx = ctx.WithBlockTime(ctx.BlockTime().Add(24 * time.Hour))
+	k.UndoOutflow(ctx, "channel-0", "uatom", sdk.NewInt(100))
+	require.NoError(t, k.SendPacket(ctx, nil, transferPacket("channel-0", "uatom", 100)))
+	flow, _ = k.GetFlow(ctx, "channel-0", "uatom")
+	require.Equal(t, sdk.NewInt(100), flow.Outflow)
+	require.Equal(t, ctx.BlockTime().Add(24*time.Hour), flow.PeriodEnd)
+}
+
+func TestInflowOfUnmintedDenom(t *testing.T) {
+	voucher := transfertypes.ParseDenomTrace("transfer/channel-0/uosmo").IBCDenom()
+	quota := types.NewQuota("channel-0", voucher, sdk.NewDec(10), sdk.NewDec(5), 24)
+	ctx, k, bk, _ := setupKeeper(t, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000)), quota)
+
+	// the transfers of a denom without supply are rejected
+	err := k.CheckAndUpdateInflow(ctx, "channel-0", voucher, sdk.NewInt(1000000))
+	require.ErrorIs(t, err, types.ErrQuotaExceeded)
+	_, found := k.GetFlow(ctx, "channel-0", voucher)
+	require.False(t, found)
+
+	// until the denom is minted otherwise, e.g. through another channel
+	require.ErrorIs(t, k.CheckAndUpdateInflow(ctx, "channel-0", voucher, sdk.NewInt(1)), types.ErrQuotaExceeded)
+	bk.supply = bk.supply.Add(sdk.NewInt64Coin(voucher, 1000))
+
+	// and the window is valued at the minted supply from then on
+	require.NoError(t, k.CheckAndUpdateInflow(ctx, "channel-0", voucher, sdk.NewInt(50)))
+	require.ErrorIs(t, k.CheckAndUpdateInflow(ctx, "channel-0", voucher, sdk.NewInt(1)), types.ErrQuotaExceeded)
+	flow, found := k.GetFlow(ctx, "channel-0", voucher)
+	require.True(t, found)
+	require.Equal(t, sdk.NewInt(50), flow.Inflow)
+	require.Equal(t, sdk.NewInt(1000), flow.ChannelValue)
+}
@@ -0,0 +1,76 @@
+package keeper
+
+import (
+	abci "github.com/tendermint/tendermint/abci/types"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+
+	"github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+// NewQuerier returns a rate limit Querier handler.
+func NewQuerier(k Keeper, legacyQuerierCdc *codec.LegacyAmino) sdk.Querier {
+	return func(ctx sdk.Context, path []string, req abci.RequestQuery) ([]byte, error) {
+		switch path[0] {
+		case types.QueryQuotas:
+			return queryQuotas(ctx, k, legacyQuerierCdc)
+
+		case types.QueryFlows:
+			return queryFlows(ctx, k, legacyQuerierCdc)
+
+		case types.QueryFlow:
+			return queryFlow(ctx, req, k, legacyQuerierCdc)
+
+		default:
+			return nil, sdkerrors.Wrapf(sdkerrors.ErrUnknownRequest, "unknown query path: %s", path[0])
+		}
+	}
+}
+
+func queryQuotas(ctx sdk.Context, k Keeper, legacyQuerierCdc *codec.LegacyAmino) ([]byte, error) {
+	res, err := codec.MarshalJSONIndent(legacyQuerierCdc, k.GetParams(ctx).Quotas)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrJSONMarshal, err.Error())
+	}
+
+	return res, nil
+}
+
+// queryFlows returns the current usage of every quota. Quotas whose window
+// expired, or that saw no transfer yet, are reported with an empty flow.
+func queryFlows(ctx sdk.Context, k Keeper, legacyQuerierCdc *codec.LegacyAmino) ([]byte, error) {
+	usages := []types.QuotaUsage{}
+	for _, quota := range k.GetParams(ctx).Quotas {
+		usages = append(usages, types.QuotaUsage{Quota: quota, Flow: k.CurrentFlow(ctx, quota)})
+	}
+
+	res, err := codec.MarshalJSONIndent(legacyQuerierCdc, usages)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrJSONMarshal, err.Error())
+	}
+
+	return res, nil
+}
+
+func queryFlow(ctx sdk.Context, req abci.RequestQuery, k Keeper, legacyQuerierCdc *codec.LegacyAmino) ([]byte, error) {
+	var params types.QueryFlowParams
+	if err := legacyQuerierCdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrJSONUnmarshal, err.Error())
+	}
+
+	quota, found := k.GetParams(ctx).GetQuota(params.ChannelID, params.Denom)
+	if !found {
+		return nil, sdkerrors.Wrapf(sdkerrors.ErrKeyNotFound, "no quota for denom %s on channel %s", params.Denom, params.ChannelID)
+	}
+
+	usage := types.QuotaUsage{Quota: quota, Flow: k.CurrentFlow(ctx, quota)}
+
+	res, err := codec.MarshalJSONIndent(legacyQuerierCdc, usage)
+	if err != nil {
+		return nil, sdkerrors.Wrap(sdkerrors.ErrJSONMarshal, err.Error())
+	}
+
+	return res, nil
+}
@@ -0,0 +1,32 @@
+package types
+
+import (
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	"github.com/cosmos/cosmos-sdk/x/ibc/core/exported"
+)
+
+// SendDenom returns the local bank denom of the tokens sent in an outgoing
+// transfer packet.
+func SendDenom(data transfertypes.FungibleTokenPacketData) string {
+	return transfertypes.ParseDenomTrace(data.Denom).IBCDenom()
+}
+
+// RecvDenom returns the local bank denom of the tokens received in an incoming
+// transfer packet: either the unwound denom if the tokens originally came from
+// this chain, or the voucher denom minted for them otherwise.
+func RecvDenom(packet exported.PacketI, data transfertypes.FungibleTokenPacketData) string {
+	if transfertypes.ReceiverChainIsSource(packet.GetSourcePort(), packet.GetSourceChannel(), data.Denom) {
+		voucherPrefix := transfertypes.GetDenomPrefix(packet.GetSourcePort(), packet.GetSourceChannel())
+		return transfertypes.ParseDenomTrace(data.Denom[len(voucherPrefix):]).IBCDenom()
+	}
+
+	prefixedDenom := transfertypes.GetPrefixedDenom(packet.GetDestPort(), packet.GetDestChannel(), data.Denom)
+	return transfertypes.ParseDenomTrace(prefixedDenom).IBCDenom()
+}
+
+// UnmarshalPacketData decodes the ICS-20 data of a transfer packet.
+func UnmarshalPacketData(packet exported.PacketI) (transfertypes.FungibleTokenPacketData, error) {
+	var data transfertypes.FungibleTokenPacketData
+	err := transfertypes.ModuleCdc.UnmarshalJSON(packet.GetData(), &data)
+	return data, err
+}
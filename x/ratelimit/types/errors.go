@@ -0,0 +1,11 @@
+package types
+
+import (
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// x/ratelimit module sentinel errors
+var (
+	ErrQuotaExceeded = sdkerrors.Register(ModuleName, 2, "rate limit quota exceeded")
+	ErrInvalidQuota  = sdkerrors.Register(ModuleName, 3, "invalid rate limit quota")
+)
@@ -0,0 +1,12 @@
+package types
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	bankexported "github.com/cosmos/cosmos-sdk/x/bank/exported"
+)
+
+// BankKeeper defines the expected bank keeper, used to value channels as a
+// share of the total supply of a denom.
+type BankKeeper interface {
+	GetSupply(ctx sdk.Context) bankexported.SupplyI
+}
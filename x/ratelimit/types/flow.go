@@ -0,0 +1,96 @@
+package types
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// Flow tracks the amounts of a denom that went in and out of a channel during
+// the current window of its quota.
+type Flow struct {
+	Inflow  sdk.Int `json:"inflow" yaml:"inflow"`
+	Outflow sdk.Int `json:"outflow" yaml:"outflow"`
+	// ChannelValue is the total supply of the denom when the window started,
+	// or when it was first minted during the window.
+	ChannelValue sdk.Int `json:"channel_value" yaml:"channel_value"`
+	// PeriodEnd is the block time at which the window expires.
+	PeriodEnd time.Time `json:"period_end" yaml:"period_end"`
+}
+
+// NewFlow returns an empty flow for a window starting at the given time.
+func NewFlow(channelValue sdk.Int, start time.Time, quota Quota) Flow {
+	return Flow{
+		Inflow:       sdk.ZeroInt(),
+		Outflow:      sdk.ZeroInt(),
+		ChannelValue: channelValue,
+		PeriodEnd:    start.Add(quota.Duration()),
+	}
+}
+
+// IsExpired returns true if the window of the flow is over.
+func (f Flow) IsExpired(blockTime time.Time) bool {
+	return !blockTime.Before(f.PeriodEnd)
+}
+
+// NetInflow returns the inflow minus the outflow, which may be negative.
+func (f Flow) NetInflow() sdk.Int {
+	return f.Inflow.Sub(f.Outflow)
+}
+
+// NetOutflow returns the outflow minus the inflow, which may be negative.
+func (f Flow) NetOutflow() sdk.Int {
+	return f.Outflow.Sub(f.Inflow)
+}
+
+// AddInflow records an inflow, failing if the net inflow would exceed the
+// receive quota.
+func (f *Flow) AddInflow(amount sdk.Int, quota Quota) error {
+	if err := f.checkQuota(f.NetInflow().Add(amount), quota.MaxPercentRecv); err != nil {
+		return sdkerrors.Wrapf(err, "inflow of %s%s on %s", amount, quota.Denom, quota.ChannelID)
+	}
+
+	f.Inflow = f.Inflow.Add(amount)
+	return nil
+}
+
+// AddOutflow records an outflow, failing if the net outflow would exceed the
+// send quota.
+func (f *Flow) AddOutflow(amount sdk.Int, quota Quota) error {
+	if err := f.checkQuota(f.NetOutflow().Add(amount), quota.MaxPercentSend); err != nil {
+		return sdkerrors.Wrapf(err, "outflow of %s%s on %s", amount, quota.Denom, quota.ChannelID)
+	}
+
+	f.Outflow = f.Outflow.Add(amount)
+	return nil
+}
+
+// UndoOutflow reverts a previously recorded outflow, e.g. when the packet
+// timed out or was acknowledged with an error and the tokens were refunded.
+func (f *Flow) UndoOutflow(amount sdk.Int) {
+	f.Outflow = f.Outflow.Sub(amount)
+	if f.Outflow.IsNegative() {
+		f.Outflow = sdk.ZeroInt()
+	}
+}
+
+// checkQuota fails if the net flow exceeds maxPercent of the channel value. A
+// denom without supply, e.g. an IBC voucher which was never minted, has a
+// threshold of zero: a quota on it rejects any net flow, so that a single
+// transfer cannot mint an unlimited amount of it.
+func (f Flow) checkQuota(netFlow sdk.Int, maxPercent sdk.Dec) error {
+	threshold := maxPercent.MulInt(f.ChannelValue).QuoInt64(100).TruncateInt()
+	if netFlow.GT(threshold) {
+		return sdkerrors.Wrapf(ErrQuotaExceeded, "net flow %s exceeds threshold %s", netFlow, threshold)
+	}
+
+	return nil
+}
+
+// FlowRecord is a flow together with the channel and denom it belongs to.
+type FlowRecord struct {
+	ChannelID string `json:"channel_id" yaml:"channel_id"`
+	Denom     string `json:"denom" yaml:"denom"`
+	Flow      Flow   `json:"flow" yaml:"flow"`
+}
@@ -0,0 +1,79 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/cosmos/gaia/v4/x/ratelimit/types"
+)
+
+func TestFlow(t *testing.T) {
+	quota := types.NewQuota("channel-0", "uatom", sdk.NewDec(10), sdk.NewDec(5), 24)
+	start := time.Unix(1600000000, 0).UTC()
+	flow := types.NewFlow(sdk.NewInt(1000), start, quota)
+
+	require.False(t, flow.IsExpired(start.Add(23*time.Hour)))
+	require.True(t, flow.IsExpired(start.Add(24*time.Hour)))
+
+	// send quota is 10% of 1000
+	require.NoError(t, flow.AddOutflow(sdk.NewInt(60), quota))
+	require.NoError(t, flow.AddOutflow(sdk.NewInt(40), quota))
+	require.ErrorIs(t, flow.AddOutflow(sdk.NewInt(1), quota), types.ErrQuotaExceeded)
+	require.Equal(t, sdk.NewInt(100), flow.Outflow)
+
+	// inflows free up outflow capacity and vice versa
+	require.NoError(t, flow.AddInflow(sdk.NewInt(50), quota))
+	require.NoError(t, flow.AddOutflow(sdk.NewInt(50), quota))
+	require.Equal(t, sdk.NewInt(100), flow.NetOutflow())
+
+	// receive quota is 5% of 1000, on top of the current net outflow
+	require.NoError(t, flow.AddInflow(sdk.NewInt(150), quota))
+	require.ErrorIs(t, flow.AddInflow(sdk.NewInt(1), quota), types.ErrQuotaExceeded)
+	require.Equal(t, sdk.NewInt(50), flow.NetInflow())
+
+	flow.UndoOutflow(sdk.NewInt(500))
+	require.True(t, flow.Outflow.IsZero())
+}
+
+func TestFlowZeroChannelValue(t *testing.T) {
+	quota := types.NewQuota("channel-0", "uatom", sdk.NewDec(10), sdk.NewDec(10), 24)
+	flow := types.NewFlow(sdk.ZeroInt(), time.Now(), quota)
+
+	// a denom without supply has a threshold of zero
+	require.ErrorIs(t, flow.AddInflow(sdk.NewInt(1), quota), types.ErrQuotaExceeded)
+	require.ErrorIs(t, flow.AddOutflow(sdk.NewInt(1), quota), types.ErrQuotaExceeded)
+	require.True(t, flow.Inflow.IsZero())
+
+	flow.ChannelValue = sdk.NewInt(1000)
+	require.NoError(t, flow.AddInflow(sdk.NewInt(100), quota))
+	require.ErrorIs(t, flow.AddInflow(sdk.NewInt(1), quota), types.ErrQuotaExceeded)
+}
+
+func TestParamsValidate(t *testing.T) {
+	require.NoError(t, types.DefaultParams().Validate())
+
+	quota := types.NewQuota("channel-0", "uatom", sdk.NewDec(10), sdk.NewDec(5), 24)
+	require.NoError(t, types.Params{Quotas: []types.Quota{quota}}.Validate())
+	require.ErrorIs(t, types.Params{Quotas: []types.Quota{quota, quota}}.Validate(), types.ErrInvalidQuota)
+
+	invalid := []types.Quota{
+		types.NewQuota("channel", "uatom", sdk.NewDec(10), sdk.NewDec(5), 24),
+		types.NewQuota("channel-0", "", sdk.NewDec(10), sdk.NewDec(5), 24),
+		types.NewQuota("channel-0", "uatom", sdk.NewDec(101), sdk.NewDec(5), 24),
+		types.NewQuota("channel-0", "uatom", sdk.NewDec(10), sdk.NewDec(-1), 24),
+		types.NewQuota("channel-0", "uatom", sdk.NewDec(10), sdk.NewDec(5), 0),
+	}
+	for _, q := range invalid {
+		require.ErrorIs(t, types.Params{Quotas: []types.Quota{q}}.Validate(), types.ErrInvalidQuota, q)
+	}
+
+	found, ok := types.Params{Quotas: []types.Quota{quota}}.GetQuota("channel-0", "uatom")
+	require.True(t, ok)
+	require.Equal(t, quota, found)
+	_, ok = types.Params{Quotas: []types.Quota{quota}}.GetQuota("channel-1", "uatom")
+	require.False(t, ok)
+}
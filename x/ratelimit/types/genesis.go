@@ -0,0 +1,46 @@
+package types
+
+import (
+	"fmt"
+)
+
+// GenesisState defines the rate limit genesis state.
+type GenesisState struct {
+	Params Params       `json:"params" yaml:"params"`
+	Flows  []FlowRecord `json:"flows" yaml:"flows"`
+}
+
+// NewGenesisState creates a new rate limit genesis state.
+func NewGenesisState(params Params, flows []FlowRecord) *GenesisState {
+	return &GenesisState{Params: params, Flows: flows}
+}
+
+// DefaultGenesisState returns the default rate limit genesis state.
+func DefaultGenesisState() *GenesisState {
+	return NewGenesisState(DefaultParams(), []FlowRecord{})
+}
+
+// Validate performs a basic validation of the genesis state.
+func (gs GenesisState) Validate() error {
+	if err := gs.Params.Validate(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, record := range gs.Flows {
+		id := record.ChannelID + "/" + record.Denom
+		if seen[id] {
+			return fmt.Errorf("duplicate flow for denom %s on channel %s", record.Denom, record.ChannelID)
+		}
+		seen[id] = true
+
+		if record.Flow.Inflow.IsNil() || record.Flow.Outflow.IsNil() || record.Flow.ChannelValue.IsNil() {
+			return fmt.Errorf("flow for denom %s on channel %s has unset amounts", record.Denom, record.ChannelID)
+		}
+		if record.Flow.Inflow.IsNegative() || record.Flow.Outflow.IsNegative() || record.Flow.ChannelValue.IsNegative() {
+			return fmt.Errorf("flow for denom %s on channel %s has negative amounts", record.Denom, record.ChannelID)
+		}
+	}
+
+	return nil
+}
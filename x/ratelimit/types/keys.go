@@ -0,0 +1,44 @@
+package types
+
+import (
+	"strings"
+)
+
+const (
+	// ModuleName defines the module name
+	ModuleName = "ratelimit"
+
+	// StoreKey defines the primary module store key
+	StoreKey = ModuleName
+
+	// QuerierRoute defines the module's query routing key
+	QuerierRoute = ModuleName
+)
+
+// query endpoints supported by the rate limit querier
+const (
+	QueryQuotas = "quotas"
+	QueryFlows  = "flows"
+	QueryFlow   = "flow"
+)
+
+// FlowKeyPrefix is the prefix of the store keys holding the flow of a
+// rate-limited channel and denom pair.
+var FlowKeyPrefix = []byte{0x01}
+
+// FlowKey returns the store key of the flow of the given channel and denom.
+// Channel identifiers cannot contain a slash, so the first slash separates the
+// channel from the (possibly prefixed) denom.
+func FlowKey(channelID, denom string) []byte {
+	return append(append([]byte{}, FlowKeyPrefix...), []byte(channelID+"/"+denom)...)
+}
+
+// SplitFlowKey returns the channel and denom of a flow store key.
+func SplitFlowKey(key []byte) (channelID, denom string) {
+	parts := strings.SplitN(string(key[len(FlowKeyPrefix):]), "/", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
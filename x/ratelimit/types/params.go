@@ -0,0 +1,74 @@
+package types
+
+import (
+	"fmt"
+
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	paramtypes "github.com/cosmos/cosmos-sdk/x/params/types"
+)
+
+// KeyQuotas is the parameter store key of the rate limit quotas.
+var KeyQuotas = []byte("Quotas")
+
+var _ paramtypes.ParamSet = (*Params)(nil)
+
+// Params defines the rate limit parameters, set by governance through
+// parameter change proposals.
+type Params struct {
+	Quotas []Quota `json:"quotas" yaml:"quotas"`
+}
+
+// ParamKeyTable returns the key table of the rate limit parameters.
+func ParamKeyTable() paramtypes.KeyTable {
+	return paramtypes.NewKeyTable().RegisterParamSet(&Params{})
+}
+
+// DefaultParams returns the default rate limit parameters: no quota.
+func DefaultParams() Params {
+	return Params{Quotas: []Quota{}}
+}
+
+// ParamSetPairs implements the ParamSet interface.
+func (p *Params) ParamSetPairs() paramtypes.ParamSetPairs {
+	return paramtypes.ParamSetPairs{
+		paramtypes.NewParamSetPair(KeyQuotas, &p.Quotas, validateQuotas),
+	}
+}
+
+// Validate performs a basic validation of the parameters.
+func (p Params) Validate() error {
+	return validateQuotas(p.Quotas)
+}
+
+// GetQuota returns the quota of the given channel and denom.
+func (p Params) GetQuota(channelID, denom string) (Quota, bool) {
+	for _, q := range p.Quotas {
+		if q.ChannelID == channelID && q.Denom == denom {
+			return q, true
+		}
+	}
+
+	return Quota{}, false
+}
+
+func validateQuotas(i interface{}) error {
+	quotas, ok := i.([]Quota)
+	if !ok {
+		return fmt.Errorf("invalid parameter type: %T", i)
+	}
+
+	seen := make(map[string]bool)
+	for _, q := range quotas {
+		if err := q.Validate(); err != nil {
+			return err
+		}
+
+		id := q.ChannelID + "/" + q.Denom
+		if seen[id] {
+			return sdkerrors.Wrapf(ErrInvalidQuota, "duplicate quota for denom %s on channel %s", q.Denom, q.ChannelID)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
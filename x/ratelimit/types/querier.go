@@ -0,0 +1,18 @@
+package types
+
+// QueryFlowParams defines the params of the flow query.
+type QueryFlowParams struct {
+	ChannelID string `json:"channel_id" yaml:"channel_id"`
+	Denom     string `json:"denom" yaml:"denom"`
+}
+
+// NewQueryFlowParams creates a new QueryFlowParams instance.
+func NewQueryFlowParams(channelID, denom string) QueryFlowParams {
+	return QueryFlowParams{ChannelID: channelID, Denom: denom}
+}
+
+// QuotaUsage is the current usage of a quota, as returned by the flow queries.
+type QuotaUsage struct {
+	Quota Quota `json:"quota" yaml:"quota"`
+	Flow  Flow  `json:"flow" yaml:"flow"`
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	host "github.com/cosmos/cosmos-sdk/x/ibc/core/24-host"
+)
+
+// Quota limits the net amount of a denom that may flow through a channel over
+// a rolling window, as a percentage of the total supply of the denom at the
+// start of the window.
+type Quota struct {
+	// ChannelID is the local identifier of the rate-limited transfer channel.
+	ChannelID string `json:"channel_id" yaml:"channel_id"`
+	// Denom is the local bank denom, e.g. "uatom" or "ibc/<hash>".
+	Denom string `json:"denom" yaml:"denom"`
+	// MaxPercentSend is the maximum net outflow, in percent of the supply.
+	MaxPercentSend sdk.Dec `json:"max_percent_send" yaml:"max_percent_send"`
+	// MaxPercentRecv is the maximum net inflow, in percent of the supply.
+	MaxPercentRecv sdk.Dec `json:"max_percent_recv" yaml:"max_percent_recv"`
+	// DurationHours is the length of the window.
+	DurationHours uint64 `json:"duration_hours" yaml:"duration_hours"`
+}
+
+// NewQuota creates a new Quota instance.
+func NewQuota(channelID, denom string, maxPercentSend, maxPercentRecv sdk.Dec, durationHours uint64) Quota {
+	return Quota{
+		ChannelID:      channelID,
+		Denom:          denom,
+		MaxPercentSend: maxPercentSend,
+		MaxPercentRecv: maxPercentRecv,
+		DurationHours:  durationHours,
+	}
+}
+
+// Duration returns the length of the quota window.
+func (q Quota) Duration() time.Duration {
+	return time.Duration(q.DurationHours) * time.Hour
+}
+
+// Validate performs a basic validation of the quota.
+func (q Quota) Validate() error {
+	if err := host.ChannelIdentifierValidator(q.ChannelID); err != nil {
+		return sdkerrors.Wrapf(ErrInvalidQuota, "invalid channel id %s: %s", q.ChannelID, err)
+	}
+	if err := sdk.ValidateDenom(q.Denom); err != nil {
+		return sdkerrors.Wrap(ErrInvalidQuota, err.Error())
+	}
+	if err := validatePercent(q.MaxPercentSend); err != nil {
+		return sdkerrors.Wrapf(ErrInvalidQuota, "invalid max percent send: %s", err)
+	}
+	if err := validatePercent(q.MaxPercentRecv); err != nil {
+		return sdkerrors.Wrapf(ErrInvalidQuota, "invalid max percent recv: %s", err)
+	}
+	if q.DurationHours == 0 {
+		return sdkerrors.Wrap(ErrInvalidQuota, "quota duration cannot be zero")
+	}
+
+	return nil
+}
+
+func validatePercent(p sdk.Dec) error {
+	if p.IsNil() || p.IsNegative() || p.GT(sdk.NewDec(100)) {
+		return fmt.Errorf("percentage must be between 0 and 100, got %s", p)
+	}
+
+	return nil
+}